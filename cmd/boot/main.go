@@ -28,7 +28,7 @@ func main() {
 	copy := new(rockferry.Machine)
 	*copy = *machine
 
-	copy.Status.State = spec.MachineStatusStateBooting
+	copy.Spec.PowerState = spec.MachineSpecPowerStateOn
 
 	if err := cli.Machines().Patch(ctx, machine, copy); err != nil {
 		panic(err)
@@ -0,0 +1,49 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	auditKind  string
+	auditId    string
+	auditLimit int
+)
+
+// auditCmd represents the audit command
+var auditCmd = &cobra.Command{
+	Use:   "audit",
+	Short: "Show who recently created, patched or deleted resources",
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx := context.Background()
+		client, err := connect(controllerUrl)
+		if err != nil {
+			panic(err)
+		}
+
+		events, err := client.AuditEvents(ctx, auditKind, auditId, auditLimit)
+		if err != nil {
+			panic(err)
+		}
+
+		for _, event := range events {
+			out, _ := json.Marshal(event)
+			fmt.Println(string(out))
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(auditCmd)
+
+	auditCmd.Flags().StringVar(&controllerUrl, "controller", "localhost:9090", "grpc address of a controller")
+	auditCmd.Flags().StringVar(&auditKind, "kind", "", "kind of the resources")
+	auditCmd.Flags().StringVar(&auditId, "id", "", "id of the resource, every resource of the kind if empty")
+	auditCmd.Flags().IntVar(&auditLimit, "limit", 0, "how many events to show at most")
+
+	auditCmd.MarkFlagRequired("kind")
+}
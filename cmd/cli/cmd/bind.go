@@ -0,0 +1,57 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/eskpil/rockferry/pkg/rockferry"
+	"github.com/eskpil/rockferry/pkg/rockferry/spec"
+	"github.com/google/uuid"
+	"github.com/spf13/cobra"
+)
+
+var (
+	bindUsers  []string
+	bindGroups []string
+)
+
+// bindCmd represents the bind command
+var bindCmd = &cobra.Command{
+	Use:   "bind <role>",
+	Short: "Grant a role, such as rockferry:tenant, to users and groups",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx := context.Background()
+		client, err := connect(controllerUrl)
+		if err != nil {
+			panic(err)
+		}
+
+		binding := new(rockferry.RoleBinding)
+		binding.Kind = rockferry.ResourceKindRoleBinding
+		binding.Id = uuid.NewString()
+		binding.Spec.Role = args[0]
+
+		for _, user := range bindUsers {
+			binding.Spec.Subjects = append(binding.Spec.Subjects, &spec.RoleSubject{Kind: spec.RoleSubjectKindUser, Name: user})
+		}
+
+		for _, group := range bindGroups {
+			binding.Spec.Subjects = append(binding.Spec.Subjects, &spec.RoleSubject{Kind: spec.RoleSubjectKindGroup, Name: group})
+		}
+
+		if err := client.RoleBindings().Create(ctx, binding); err != nil {
+			panic(err)
+		}
+
+		fmt.Println(binding.Id)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(bindCmd)
+
+	bindCmd.Flags().StringVar(&controllerUrl, "controller", "localhost:9090", "grpc address of a controller")
+	bindCmd.Flags().StringSliceVar(&bindUsers, "user", nil, "users to grant the role to")
+	bindCmd.Flags().StringSliceVar(&bindGroups, "group", nil, "groups to grant the role to")
+}
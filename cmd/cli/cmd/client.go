@@ -0,0 +1,48 @@
+package cmd
+
+import (
+	"os"
+
+	"github.com/eskpil/rockferry/pkg/rockferry"
+)
+
+var (
+	caFile   string
+	certFile string
+	keyFile  string
+	insecure bool
+	token    string
+)
+
+// Connects to the controller at url with the credentials given by the flags.
+func connect(url string) (*rockferry.Client, error) {
+	if insecure {
+		return rockferry.New(url)
+	}
+
+	// NOTE: The controller prefers the certificate, it is left out when there is a token.
+	if token != "" {
+		conf, err := rockferry.LoadTLS(caFile, "", "")
+		if err != nil {
+			return nil, err
+		}
+
+		return rockferry.New(url, rockferry.WithTLS(conf), rockferry.WithBearerToken(token))
+	}
+
+	conf, err := rockferry.LoadTLS(caFile, certFile, keyFile)
+	if err != nil {
+		return nil, err
+	}
+
+	return rockferry.New(url, rockferry.WithTLS(conf))
+}
+
+func init() {
+	// NOTE: The defaults are where the controller writes them, relative to where it runs.
+	rootCmd.PersistentFlags().StringVar(&caFile, "ca", "rockferry-pki/ca.crt", "ca certificate the controller is verified against")
+	rootCmd.PersistentFlags().StringVar(&certFile, "cert", "rockferry-pki/admin.crt", "client certificate presented to the controller")
+	rootCmd.PersistentFlags().StringVar(&keyFile, "key", "rockferry-pki/admin.key", "key of the client certificate")
+	rootCmd.PersistentFlags().StringVar(&token, "token", os.Getenv("ROCKFERRY_TOKEN"), "id token from the openid connect provider, used instead of the client certificate")
+	rootCmd.PersistentFlags().BoolVar(&insecure, "insecure", false, "connect to the controller without tls")
+}
@@ -0,0 +1,45 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+
+	"github.com/eskpil/rockferry/pkg/rockferry"
+	"github.com/spf13/cobra"
+)
+
+// eventsCmd represents the events command
+var eventsCmd = &cobra.Command{
+	Use:   "events <kind> <id>",
+	Short: "Show what happened to a resource, oldest first",
+	Args:  cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx := context.Background()
+		client, err := connect(controllerUrl)
+		if err != nil {
+			panic(err)
+		}
+
+		events, err := client.Events().For(ctx, &rockferry.OwnerRef{Kind: args[0], Id: args[1]})
+		if err != nil && err != rockferry.ErrorNotFound {
+			panic(err)
+		}
+
+		slices.SortFunc(events, func(a, b *rockferry.Event) int {
+			return a.Spec.LastSeen.Compare(b.Spec.LastSeen)
+		})
+
+		for _, event := range events {
+			out, _ := json.Marshal(event.Spec)
+			fmt.Println(string(out))
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(eventsCmd)
+
+	eventsCmd.Flags().StringVar(&controllerUrl, "controller", "localhost:9090", "grpc address of a controller")
+}
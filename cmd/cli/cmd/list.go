@@ -8,7 +8,6 @@ import (
 	"encoding/json"
 	"fmt"
 
-	"github.com/eskpil/rockferry/pkg/rockferry"
 	"github.com/spf13/cobra"
 )
 
@@ -24,7 +23,10 @@ This application is a tool to generate the needed files
 to quickly create a Cobra application.`,
 	Run: func(cmd *cobra.Command, args []string) {
 		ctx := context.Background()
-		client, _ := rockferry.New("10.100.0.102:9090")
+		client, err := connect("10.100.0.102:9090")
+		if err != nil {
+			panic(err)
+		}
 
 		resources, err := client.Generic(args[0]).List(ctx, "", nil)
 		if err != nil {
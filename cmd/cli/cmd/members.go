@@ -0,0 +1,71 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/spf13/cobra"
+)
+
+var controllerUrl string
+
+// membersCmd represents the members command
+var membersCmd = &cobra.Command{
+	Use:   "members",
+	Short: "Manage the controllers making up the cluster",
+}
+
+var membersListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the members of the cluster",
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx := context.Background()
+		client, err := connect(controllerUrl)
+		if err != nil {
+			panic(err)
+		}
+
+		members, err := client.Members(ctx)
+		if err != nil {
+			panic(err)
+		}
+
+		out, _ := json.Marshal(members)
+
+		fmt.Println(string(out))
+	},
+}
+
+var membersRemoveCmd = &cobra.Command{
+	Use:   "remove <id>",
+	Short: "Remove a member from the cluster",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx := context.Background()
+		client, err := connect(controllerUrl)
+		if err != nil {
+			panic(err)
+		}
+
+		// NOTE: Accepts hex ids with a 0x prefix, which is how etcd prints them.
+		id, err := strconv.ParseUint(args[0], 0, 64)
+		if err != nil {
+			panic(err)
+		}
+
+		if err := client.RemoveMember(ctx, id); err != nil {
+			panic(err)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(membersCmd)
+
+	membersCmd.PersistentFlags().StringVar(&controllerUrl, "controller", "localhost:9090", "grpc address of a controller")
+
+	membersCmd.AddCommand(membersListCmd)
+	membersCmd.AddCommand(membersRemoveCmd)
+}
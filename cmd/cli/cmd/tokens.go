@@ -0,0 +1,56 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/eskpil/rockferry/pkg/rockferry"
+	"github.com/spf13/cobra"
+)
+
+var (
+	tokenTTL         time.Duration
+	tokenDescription string
+)
+
+// tokensCmd represents the tokens command
+var tokensCmd = &cobra.Command{
+	Use:   "tokens",
+	Short: "Manage the bootstrap tokens nodes register with",
+}
+
+var tokensCreateCmd = &cobra.Command{
+	Use:   "create",
+	Short: "Create a bootstrap token and print it",
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx := context.Background()
+		client, err := connect(controllerUrl)
+		if err != nil {
+			panic(err)
+		}
+
+		resource, token, err := rockferry.NewBootstrapToken(tokenTTL, tokenDescription)
+		if err != nil {
+			panic(err)
+		}
+
+		if err := client.BootstrapTokens().Create(ctx, resource); err != nil {
+			panic(err)
+		}
+
+		// NOTE: Only a hash is stored, the token can not be shown again.
+		fmt.Println(token)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(tokensCmd)
+
+	tokensCmd.PersistentFlags().StringVar(&controllerUrl, "controller", "localhost:9090", "grpc address of a controller")
+
+	tokensCreateCmd.Flags().DurationVar(&tokenTTL, "ttl", time.Hour, "how long the token can be used")
+	tokensCreateCmd.Flags().StringVar(&tokenDescription, "description", "", "what the token is for")
+
+	tokensCmd.AddCommand(tokensCreateCmd)
+}
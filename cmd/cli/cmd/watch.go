@@ -24,7 +24,10 @@ This application is a tool to generate the needed files
 to quickly create a Cobra application.`,
 	Run: func(cmd *cobra.Command, args []string) {
 		ctx := context.Background()
-		client, _ := rockferry.New("10.100.0.102:9090")
+		client, err := connect("10.100.0.102:9090")
+		if err != nil {
+			panic(err)
+		}
 
 		stream, err := client.Generic(args[0]).Watch(ctx, rockferry.WatchActionAll, "", nil)
 		if err != nil {
@@ -1,78 +1,134 @@
 package main
 
 import (
+	"context"
+	"crypto/tls"
 	"log"
 	"log/slog"
 	"net"
+	"net/http"
+	"os"
+	"os/signal"
 	"sync"
-	"time"
+	"syscall"
 	"fmt"
 
 	"github.com/eskpil/rockferry/controllerapi"
 	"github.com/eskpil/rockferry/internal/controller"
 	"github.com/eskpil/rockferry/internal/controller/api"
+	"github.com/eskpil/rockferry/internal/controller/audit"
+	"github.com/eskpil/rockferry/internal/controller/auth"
+	"github.com/eskpil/rockferry/internal/controller/config"
 	"github.com/eskpil/rockferry/internal/controller/controllers/resource"
+	"github.com/eskpil/rockferry/internal/controller/controllers/status"
 	"github.com/eskpil/rockferry/internal/controller/db"
+	"github.com/eskpil/rockferry/internal/controller/pki"
+	"github.com/eskpil/rockferry/internal/controller/rbac"
 	"github.com/eskpil/rockferry/internal/controller/runtime"
+	"github.com/eskpil/rockferry/pkg/rockferry"
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/reflection"
-
-	clientv3 "go.etcd.io/etcd/client/v3"
-	"go.etcd.io/etcd/server/v3/embed"
 )
 
-func runDb(dir string) {
-	cfg := embed.NewConfig()
-	cfg.Dir = dir
-	e, err := embed.StartEtcd(cfg)
+func main() {
+	conf, err := config.Load(os.Args[1:])
 	if err != nil {
 		log.Fatal(err)
 	}
 
-	defer e.Close()
-	select {
-	case <-e.Server.ReadyNotify():
-		log.Printf("Database is running")
-	case <-time.After(60 * time.Second):
-		e.Server.Stop() // trigger a shutdown
-		log.Printf("Server took too long to start!")
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	s, err := controller.OpenStore(conf)
+	if err != nil {
+		log.Fatal(err)
 	}
 
-	log.Fatal(<-e.Err())
-}
+	defer s.Close()
 
-func main() {
-	wg := new(sync.WaitGroup)
+	if err := controller.Initialize(s); err != nil {
+		panic(err)
+	}
 
-	wg.Add(1)
-	go func(wg *sync.WaitGroup) {
-		// TODO: Add clustering support
-		runDb("salmon_vm.etcd")
-		wg.Done()
-	}(wg)
+	r := runtime.New(s)
+	r.FailoverGracePeriod = conf.FailoverGracePeriod
+	r.EventTTL = conf.EventTTL
+	r.CpuOvercommit = conf.CpuOvercommit
 
-	if err := controller.Initialize(); err != nil {
-		panic(err)
+	var ca *pki.CA
+	var serving *tls.Config
+	var tokens *auth.Verifier
+	var authorizer *rbac.Authorizer
+
+	if conf.Pki.Insecure {
+		fmt.Println("serving the apis without tls, anyone able to reach them can use them")
+	} else {
+		ca, serving, err = controller.ServingTLS(ctx, conf, s)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		// NOTE: Without tls nobody is identified, there is nothing to authorize.
+		authorizer = rbac.New(r)
 	}
 
-	// TODO: Enable some kind of config
-	// TODO: Avoid multiple db connections
-	cli, err := clientv3.New(clientv3.Config{
-		Endpoints:   []string{"localhost:2379"},
-		DialTimeout: 5 * time.Second,
-	})
+	if conf.Oidc.Issuer != "" {
+		tokens, err = auth.NewVerifier(&conf.Oidc)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
 
-	// TODO: Avoid panic
+	logger, err := audit.Open(&conf.Audit, r)
 	if err != nil {
-		panic(err)
+		log.Fatal(err)
+	}
+
+	// NOTE: Every controller serves the api, but only the leader runs the background loops.
+	loops := []func(context.Context){r.RunAllocator}
+
+	if conf.Features.GarbageCollection {
+		loops = append(loops, r.CollectGarbage)
+	}
+
+	if conf.Features.Scheduler {
+		loops = append(loops, r.RunScheduler)
 	}
 
-	r := runtime.New(cli)
+	if conf.Features.NodeMonitor {
+		loops = append(loops, r.MonitorNodes)
+	}
+
+	if conf.Features.Failover {
+		loops = append(loops, r.RunFailover)
+	}
 
-	wg.Add(1)
-	go func(wg *sync.WaitGroup) {
+	if conf.Audit.Sink == config.AuditSinkResource {
+		loops = append(loops, logger.RunRetention)
+	}
+
+	elected := make(chan struct{})
+	go func() {
+		defer close(elected)
+
+		r.RunElected(ctx, func(ctx context.Context) {
+			wg := new(sync.WaitGroup)
+			for _, loop := range loops {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					loop(ctx)
+				}()
+			}
+
+			wg.Wait()
+		})
+	}()
+
+	go func() {
 		server := echo.New()
 
 		server.Use(r.EchoMiddleware())
@@ -87,43 +143,90 @@ func main() {
 
 		server.Use(middleware.CORS())
 
-		server.Use(db.Middleware())
+		if serving != nil {
+			server.Use(auth.EchoMiddleware(tokens))
+		}
+
+		server.Use(authorizer.EchoMiddleware())
+
+		server.Use(db.Middleware(s))
 
 		server.GET("/v1/resources/events", resource.Watch())
 		server.GET("/v1/resources", resource.List())
-		server.POST("v1/resources", resource.Create())
-		server.DELETE("/v1/resources", resource.Delete())
-		server.PATCH("/v1/resources", resource.Patch())
+		server.POST("v1/resources", resource.Create(), logger.EchoMiddleware(rockferry.VerbCreate, ""))
+		server.DELETE("/v1/resources", resource.Delete(), logger.EchoMiddleware(rockferry.VerbDelete, ""))
+		server.PATCH("/v1/resources", resource.Patch(), logger.EchoMiddleware(rockferry.VerbPatch, ""))
+		server.PATCH("/v1/resources/status", resource.PatchStatus(), logger.EchoMiddleware(rockferry.VerbPatch, "status"))
+
+		server.GET("/v1/audit", logger.List())
+
+		server.GET("/v1/status", status.Leader())
+
+		// NOTE: Served with tls when the config is set.
+		if err := server.StartServer(&http.Server{Addr: conf.Http.Address, TLSConfig: serving}); err != nil {
+			panic(err)
+		}
+	}()
 
-		if err := server.Start("0.0.0.0:8080"); err != nil {
+	go func() {
+		listener, err := net.Listen("tcp", conf.Grpc.Address)
+		if err != nil {
 			panic(err)
 		}
-	}(wg)
 
-	wg.Add(1)
-	go func(wg *sync.WaitGroup) {
-		listener, err := net.Listen("tcp", "0.0.0.0:9090")
+		admin, err := api.NewAdmin(r, conf.Store.Cluster.JoinToken)
 		if err != nil {
 			panic(err)
 		}
 
+		admin.CA = ca
+		admin.CertificateLifetime = conf.Pki.CertificateLifetime
+		admin.Authorizer = authorizer
+		admin.Audit = logger
+
 		api, err := api.New(r)
 		if err != nil {
 			panic(err)
 		}
 
-		server := grpc.NewServer()
+		api.Authorizer = authorizer
+
+		options := []grpc.ServerOption{}
+		unary := []grpc.UnaryServerInterceptor{}
+
+		if serving != nil {
+			options = append(options,
+				grpc.Creds(credentials.NewTLS(serving)),
+				grpc.ChainStreamInterceptor(auth.StreamInterceptor(tokens)),
+			)
+
+			unary = append(unary, auth.UnaryInterceptor(tokens))
+		}
+
+		// NOTE: Comes after authentication so the caller is known.
+		unary = append(unary, logger.UnaryInterceptor())
+		options = append(options, grpc.ChainUnaryInterceptor(unary...))
+
+		server := grpc.NewServer(options...)
 		controllerapi.RegisterControllerApiServer(server, api)
+		controllerapi.RegisterAdminApiServer(server, admin)
 
-		reflection.Register(server)
+		if conf.Grpc.Reflection {
+			reflection.Register(server)
+		}
 
-		fmt.Println("Serving gRPC api on port 9090")
+		fmt.Println("Serving gRPC api on", conf.Grpc.Address)
 
 		if err := server.Serve(listener); err != nil {
 			slog.Error("could not serve requests", slog.Any("err", err))
 		}
 
-	}(wg)
+	}()
+
+	<-ctx.Done()
+
+	fmt.Println("shutting down")
 
-	wg.Wait()
+	// NOTE: Lets the leader step down before the store is closed.
+	<-elected
 }
@@ -0,0 +1,272 @@
+package api
+
+import (
+	"context"
+	"crypto/subtle"
+	"crypto/x509"
+	"fmt"
+	"time"
+
+	"github.com/eskpil/rockferry/controllerapi"
+	"github.com/eskpil/rockferry/internal/controller/audit"
+	"github.com/eskpil/rockferry/internal/controller/pki"
+	"github.com/eskpil/rockferry/internal/controller/rbac"
+	"github.com/eskpil/rockferry/internal/controller/runtime"
+	"github.com/eskpil/rockferry/internal/controller/store"
+	"github.com/eskpil/rockferry/pkg/rockferry"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type Admin struct {
+	controllerapi.UnimplementedAdminApiServer
+	R *runtime.Runtime
+
+	// Members joining through this controller have to present it, joining is
+	// refused without one.
+	JoinToken string
+
+	// Issues the certificates of registered nodes, none are issued without it.
+	CA                  *pki.CA
+	CertificateLifetime time.Duration
+
+	// Only operators manage the members of the cluster, anyone does without one.
+	Authorizer *rbac.Authorizer
+
+	// Answers queries for audit events, nil when nothing is recorded.
+	Audit *audit.Logger
+}
+
+func NewAdmin(r *runtime.Runtime, joinToken string) (Admin, error) {
+	admin := new(Admin)
+
+	admin.R = r
+	admin.JoinToken = joinToken
+
+	return *admin, nil
+}
+
+func (a Admin) cluster() (store.Cluster, error) {
+	cluster, ok := a.R.Store.(store.Cluster)
+	if !ok {
+		return nil, status.Error(codes.FailedPrecondition, store.ErrNotClustered.Error())
+	}
+
+	return cluster, nil
+}
+
+func mapMember(m *store.Member) *controllerapi.Member {
+	out := new(controllerapi.Member)
+	out.Id = m.Id
+	out.Name = m.Name
+	out.PeerUrls = m.PeerUrls
+	out.ClientUrls = m.ClientUrls
+	return out
+}
+
+func mapMembers(members []*store.Member) []*controllerapi.Member {
+	out := make([]*controllerapi.Member, len(members))
+	for i, m := range members {
+		out[i] = mapMember(m)
+	}
+
+	return out
+}
+
+// Joining members present the join token instead.
+func (a Admin) authorizeOperator(ctx context.Context) error {
+	if err := a.Authorizer.AuthorizeOperator(ctx); err != nil {
+		if err == rockferry.ErrorForbidden {
+			return status.Errorf(codes.PermissionDenied, "only operators manage the members of the cluster")
+		}
+
+		fmt.Println("failed to look up permissions", err)
+		return status.Errorf(codes.Internal, "something wrong happend")
+	}
+
+	return nil
+}
+
+func (a Admin) Status(ctx context.Context, req *controllerapi.StatusRequest) (*controllerapi.StatusResponse, error) {
+	leader, err := a.R.Leader(ctx)
+	if err != nil {
+		fmt.Println("failed to fetch leader", err)
+		return nil, status.Errorf(codes.Internal, "something wrong happend")
+	}
+
+	response := new(controllerapi.StatusResponse)
+	response.Identity = leader.Identity
+	response.Leader = leader.Leader
+
+	return response, nil
+}
+
+func (a Admin) ListMembers(ctx context.Context, req *controllerapi.ListMembersRequest) (*controllerapi.ListMembersResponse, error) {
+	if err := a.authorizeOperator(ctx); err != nil {
+		return nil, err
+	}
+
+	cluster, err := a.cluster()
+	if err != nil {
+		return nil, err
+	}
+
+	members, err := cluster.Members(ctx)
+	if err != nil {
+		fmt.Println("failed to list members", err)
+		return nil, status.Errorf(codes.Internal, "something wrong happend")
+	}
+
+	response := new(controllerapi.ListMembersResponse)
+	response.Members = mapMembers(members)
+
+	return response, nil
+}
+
+func validToken(expected string, presented string) bool {
+	return expected != "" && subtle.ConstantTimeCompare([]byte(expected), []byte(presented)) == 1
+}
+
+func (a Admin) AddMember(ctx context.Context, req *controllerapi.AddMemberRequest) (*controllerapi.AddMemberResponse, error) {
+	if !validToken(a.JoinToken, req.JoinToken) {
+		return nil, status.Errorf(codes.PermissionDenied, "invalid join token")
+	}
+
+	if len(req.PeerUrls) == 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "a member needs at least one peer url")
+	}
+
+	cluster, err := a.cluster()
+	if err != nil {
+		return nil, err
+	}
+
+	member, members, err := cluster.AddMember(ctx, req.PeerUrls)
+	if err == store.ErrClusterUnavailable {
+		return nil, status.Error(codes.Unavailable, err.Error())
+	}
+
+	if err != nil {
+		fmt.Println("failed to add member", err)
+		return nil, status.Errorf(codes.Internal, "something wrong happend")
+	}
+
+	fmt.Println("added member", member.Id, member.PeerUrls)
+
+	response := new(controllerapi.AddMemberResponse)
+	response.Member = mapMember(member)
+	response.Members = mapMembers(members)
+
+	return response, nil
+}
+
+func (a Admin) RemoveMember(ctx context.Context, req *controllerapi.RemoveMemberRequest) (*controllerapi.RemoveMemberResponse, error) {
+	if err := a.authorizeOperator(ctx); err != nil {
+		return nil, err
+	}
+
+	cluster, err := a.cluster()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := cluster.RemoveMember(ctx, req.Id); err != nil {
+		if err == store.ErrMemberNotFound {
+			return nil, status.Errorf(codes.NotFound, "member not found")
+		}
+
+		fmt.Println("failed to remove member", err)
+		return nil, status.Errorf(codes.Internal, "something wrong happend")
+	}
+
+	fmt.Println("removed member", req.Id)
+
+	return new(controllerapi.RemoveMemberResponse), nil
+}
+
+func (a Admin) RegisterNode(ctx context.Context, req *controllerapi.RegisterNodeRequest) (*controllerapi.RegisterNodeResponse, error) {
+	var request *x509.CertificateRequest
+
+	// NOTE: Checked before the token is used up by registering the node.
+	if a.CA != nil {
+		var err error
+		if request, err = pki.ParseRequest(req.Csr); err != nil {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+	}
+
+	node, err := a.R.RegisterNode(ctx, req.BootstrapToken)
+	if err == rockferry.ErrorInvalidToken {
+		return nil, status.Errorf(codes.PermissionDenied, "invalid bootstrap token")
+	}
+
+	if err != nil {
+		fmt.Println("failed to register node", err)
+		return nil, status.Errorf(codes.Internal, "something wrong happend")
+	}
+
+	response := new(controllerapi.RegisterNodeResponse)
+	response.Id = node.Id
+
+	if a.CA == nil {
+		return response, nil
+	}
+
+	response.Certificate, err = a.CA.SignRequest(request, node.Id, []string{pki.GroupNodes}, a.CertificateLifetime)
+	if err != nil {
+		fmt.Println("failed to sign node certificate", err)
+		return nil, status.Errorf(codes.Internal, "something wrong happend")
+	}
+
+	response.Ca = a.CA.CertPEM
+
+	return response, nil
+}
+
+func (a Admin) ListAuditEvents(ctx context.Context, req *controllerapi.ListAuditEventsRequest) (*controllerapi.ListAuditEventsResponse, error) {
+	if req.Kind == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "kind must be specified")
+	}
+
+	resource := new(rockferry.Generic)
+	resource.Kind = rockferry.ResourceKindAuditEvent
+
+	if err := a.Authorizer.Authorize(ctx, rockferry.VerbList, resource); err != nil {
+		if err == rockferry.ErrorForbidden {
+			return nil, status.Errorf(codes.PermissionDenied, "not allowed to list %s", rockferry.ResourceKindAuditEvent)
+		}
+
+		fmt.Println("failed to look up permissions", err)
+		return nil, status.Errorf(codes.Internal, "something wrong happend")
+	}
+
+	if a.Audit == nil {
+		return nil, status.Errorf(codes.FailedPrecondition, "nothing is audited")
+	}
+
+	events, err := a.Audit.Query(ctx, req.Kind, req.GetId(), int(req.Limit))
+	if err != nil {
+		fmt.Println("failed to query audit events", err)
+		return nil, status.Errorf(codes.Internal, "something wrong happend")
+	}
+
+	response := new(controllerapi.ListAuditEventsResponse)
+	for _, event := range events {
+		out := new(controllerapi.AuditEvent)
+		out.Time = event.Time.Format(time.RFC3339Nano)
+		out.User = event.User
+		out.Groups = event.Groups
+		out.Source = event.Source
+		out.Api = event.Api
+		out.Verb = event.Verb
+		out.Kind = event.Kind
+		out.Id = event.Id
+		out.Subresource = event.Subresource
+		out.Patch = event.Patch
+		out.Code = event.Code
+
+		response.Events = append(response.Events, out)
+	}
+
+	return response, nil
+}
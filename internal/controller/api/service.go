@@ -8,10 +8,14 @@ import (
 
 	"github.com/eskpil/rockferry/controllerapi"
 	"github.com/eskpil/rockferry/internal/controller/models"
+	"github.com/eskpil/rockferry/internal/controller/rbac"
+	"github.com/eskpil/rockferry/internal/controller/runtime"
+	"github.com/eskpil/rockferry/internal/controller/store"
+	"github.com/eskpil/rockferry/internal/controller/validation"
 	"github.com/eskpil/rockferry/pkg/rockferry"
 	jsonpatch "github.com/evanphx/json-patch/v5"
 	"github.com/google/uuid"
-	clientv3 "go.etcd.io/etcd/client/v3"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
@@ -31,125 +35,265 @@ func (c Controller) Watch(req *controllerapi.WatchRequest, res grpc.ServerStream
 		owner.Kind = req.Owner.Kind
 	}
 
-	stream, canceled, err := c.R.Watch(ctx, req.Action, req.Kind, id, owner)
+	labels, fields, err := parseSelectors(req.GetLabelSelector(), req.GetFieldSelector())
+	if err != nil {
+		return err
+	}
+
+	permissions, err := c.Authorizer.For(ctx)
+	if err != nil {
+		fmt.Println("failed to look up permissions", err)
+		return status.Errorf(codes.Internal, "something wrong happend")
+	}
+
+	if !permissions.Can(rockferry.VerbWatch, req.Kind) {
+		return status.Errorf(codes.PermissionDenied, "not allowed to watch %s", req.Kind)
+	}
+
+	options := runtime.WatchOptions{
+		Labels:    labels,
+		Fields:    fields,
+		Revision:  req.GetRevision(),
+		Bookmarks: req.Bookmarks,
+	}
+
+	stream, canceled, err := c.R.Watch(ctx, req.Action, req.Kind, id, owner, options)
 	if err != nil {
 		return err
 	}
 
 	for {
 		select {
-		case <-canceled:
+		case err := <-canceled:
+			if err == rockferry.ErrorCompacted {
+				return status.Error(codes.OutOfRange, err.Error())
+			}
+
 			return status.Error(codes.Aborted, "stream closed")
-		case e := <-stream:
-			response := new(controllerapi.WatchResponse)
-			response.Resource, err = e.Resource.Transport()
-			if err != nil {
-				panic(err)
+		case e, ok := <-stream:
+			if !ok {
+				return ctx.Err()
 			}
 
-			if e.Prev != nil {
-				response.PrevResource, err = e.Prev.Transport()
+			response := new(controllerapi.WatchResponse)
+			response.Revision = e.Revision
+			response.Bookmark = e.Bookmark
+
+			if !e.Bookmark {
+				allowed, err := watchable(ctx, permissions, e)
+				if err != nil {
+					fmt.Println("failed to authorize event", err)
+					return status.Errorf(codes.Internal, "something wrong happend")
+				}
+
+				if !allowed {
+					continue
+				}
+
+				response.Action = e.Action
+
+				response.Resource, err = e.Resource.Transport()
 				if err != nil {
 					panic(err)
 				}
+
+				if e.Prev != nil {
+					response.PrevResource, err = e.Prev.Transport()
+					if err != nil {
+						panic(err)
+					}
+				}
+
+				response.SpecChanged = e.SpecChanged
+				response.StatusChanged = e.StatusChanged
 			}
 
 			if err := res.Send(response); err != nil {
-				panic(err)
+				return err
 			}
 		}
 	}
 }
 
-func (c Controller) List(ctx context.Context, req *controllerapi.ListRequest) (*controllerapi.ListResponse, error) {
-	var opts []clientv3.OpOption
+// Events are sent if the caller may watch the resource either before or after
+// the change, they learn when a resource is handed to someone else.
+func watchable(ctx context.Context, permissions *rbac.Permissions, e *rockferry.WatchEvent[any, any]) (bool, error) {
+	permissions.Forget()
 
-	path := ""
-	if req.Id == nil {
-		opts = append(opts, clientv3.WithPrefix())
-		path = fmt.Sprintf("%s/%s/", models.RootKey, req.Kind)
-	} else {
-		path = fmt.Sprintf("%s/%s/%s", models.RootKey, req.Kind, *req.Id)
+	allowed, err := permissions.Allowed(ctx, rockferry.VerbWatch, e.Resource)
+	if err != nil || allowed || e.Prev == nil {
+		return allowed, err
 	}
 
-	// TODO: Avoid this hack
-	if req.Kind == rockferry.ResourceKindStorageVolume && req.Owner != nil && req.Id == nil {
-		path = fmt.Sprintf("%s/%s/%s", models.RootKey, req.Kind, req.Owner.Id)
+	return permissions.Allowed(ctx, rockferry.VerbWatch, e.Prev)
+}
+
+func (c Controller) List(ctx context.Context, req *controllerapi.ListRequest) (*controllerapi.ListResponse, error) {
+	labels, fields, err := parseSelectors(req.GetLabelSelector(), req.GetFieldSelector())
+	if err != nil {
+		return nil, err
 	}
 
-	res, err := c.Db.Get(ctx, path, opts...)
+	permissions, err := c.Authorizer.For(ctx)
 	if err != nil {
-		fmt.Println("failed to fetch resources", err)
+		fmt.Println("failed to look up permissions", err)
 		return nil, status.Errorf(codes.Internal, "something wrong happend")
 	}
 
-	if 0 >= len(res.Kvs) {
+	if !permissions.Can(rockferry.VerbList, req.Kind) {
+		return nil, status.Errorf(codes.PermissionDenied, "not allowed to list %s", req.Kind)
+	}
+
+	var kvs []*store.KeyValue
+	var revision int64
+
+	if req.Id != nil {
+		kv, rev, err := c.R.Store.Get(ctx, models.ResourceKey(req.Kind, *req.Id))
+		if err != nil {
+			fmt.Println("failed to fetch resources", err)
+			return nil, status.Errorf(codes.Internal, "something wrong happend")
+		}
+
+		if kv != nil {
+			kvs = append(kvs, kv)
+		}
+
+		revision = rev
+	} else {
+		prefix := models.KindPrefix(req.Kind)
+
+		// TODO: Avoid this hack
+		if req.Kind == rockferry.ResourceKindStorageVolume && req.Owner != nil {
+			prefix = models.ResourceKey(req.Kind, req.Owner.Id) + "/"
+		}
+
+		kvs, revision, err = c.R.Store.List(ctx, prefix)
+		if err != nil {
+			fmt.Println("failed to fetch resources", err)
+			return nil, status.Errorf(codes.Internal, "something wrong happend")
+		}
+	}
+
+	// NOTE: An empty list is not an error unless a single resource was asked for,
+	// the revision is still needed to start a watch from.
+	if 0 >= len(kvs) && req.Id != nil {
 		return nil, status.Errorf(codes.NotFound, "resource not found")
 	}
 
 	response := new(controllerapi.ListResponse)
+	response.Revision = revision
 
-	for _, kv := range res.Kvs {
+	for _, kv := range kvs {
 		resource := new(controllerapi.Resource)
 		if err := json.Unmarshal(kv.Value, resource); err != nil {
 			fmt.Println("unable to unmarshal resource", err)
 			return nil, status.Errorf(codes.Internal, "something wrong happend")
 		}
 
-		if req.Owner != nil && req.Owner.Id != "" && req.Owner.Kind != "" && resource.Owner != nil {
-			if req.Owner.Id != resource.Owner.Id && req.Owner.Kind != resource.Owner.Kind {
+		resource.ResourceVersion = kv.ModRevision
+
+		if req.Owner != nil && req.Owner.Id != "" && req.Owner.Kind != "" {
+			if resource.Owner == nil || req.Owner.Id != resource.Owner.Id || req.Owner.Kind != resource.Owner.Kind {
+				continue
+			}
+		}
+
+		mapped := rockferry.MapResource(resource)
+
+		if len(labels) > 0 || len(fields) > 0 {
+			if !labels.MatchesLabels(mapped.Annotations) || !fields.MatchesFields(mapped) {
 				continue
 			}
 		}
 
+		allowed, err := permissions.Allowed(ctx, rockferry.VerbList, mapped)
+		if err != nil {
+			fmt.Println("failed to authorize resource", err)
+			return nil, status.Errorf(codes.Internal, "something wrong happend")
+		}
+
+		// NOTE: Lists only contain what the caller may see, asking for something else is denied.
+		if !allowed {
+			if req.Id != nil {
+				return nil, status.Errorf(codes.PermissionDenied, "not allowed to list %s %s", req.Kind, *req.Id)
+			}
+
+			continue
+		}
+
 		response.Resources = append(response.Resources, resource)
 	}
 
 	return response, nil
 }
 
-func (c Controller) Patch(ctx context.Context, req *controllerapi.PatchRequest) (*controllerapi.PatchResponse, error) {
-	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
-	defer cancel()
-
-	if req.Id == nil {
-		return nil, status.Errorf(codes.InvalidArgument, "resource id must be specified")
+func parseSelectors(labelSelector string, fieldSelector string) (rockferry.Selector, rockferry.Selector, error) {
+	labels, err := rockferry.ParseSelector(labelSelector)
+	if err != nil {
+		return nil, nil, status.Error(codes.InvalidArgument, err.Error())
 	}
 
-	path := fmt.Sprintf("%s/%s/%s", models.RootKey, req.Kind, *req.Id)
-
-	res, err := c.Db.Get(ctx, path)
+	fields, err := rockferry.ParseFieldSelector(fieldSelector)
 	if err != nil {
-		fmt.Println("failed to fetch resource", err)
-		return nil, status.Errorf(codes.Internal, "something wrong happend")
+		return nil, nil, status.Error(codes.InvalidArgument, err.Error())
 	}
 
-	if 0 >= len(res.Kvs) {
-		return nil, status.Errorf(codes.NotFound, "resource not found")
-	}
+	return labels, fields, nil
+}
 
-	if 2 <= len(res.Kvs) {
-		panic("more than 1 response")
-	}
+type patchFunc func(context.Context, rockferry.ResourceKind, string, jsonpatch.Patch, int64, runtime.PatchCheck) (int64, int64, error)
 
-	original := res.Kvs[0].Value
+func (c Controller) patch(ctx context.Context, req *controllerapi.PatchRequest, apply patchFunc) (*controllerapi.PatchResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
 
-	patch, err := jsonpatch.DecodePatch(req.Patches)
-	if err != nil {
-		panic(err)
+	if req.Id == nil {
+		return nil, status.Errorf(codes.InvalidArgument, "resource id must be specified")
 	}
 
-	modified, err := patch.Apply(original)
+	patch, err := jsonpatch.DecodePatch(req.Patches)
 	if err != nil {
-		panic(err)
+		return nil, status.Errorf(codes.InvalidArgument, "malformed patch")
 	}
 
-	_, err = c.Db.Put(ctx, path, string(modified))
+	// NOTE: Authorized against the resource as it is before and after the patch.
+	version, generation, err := apply(ctx, req.Kind, *req.Id, patch, req.GetResourceVersion(), c.Authorizer.AuthorizePatch)
 	if err != nil {
-		panic(err)
+		if errs, ok := err.(validation.Errors); ok {
+			return nil, invalidArgument(errs)
+		}
+
+		switch err {
+		case rockferry.ErrorNotFound:
+			return nil, status.Errorf(codes.NotFound, "resource not found")
+		case rockferry.ErrorConflict:
+			return nil, status.Errorf(codes.Aborted, "resource has been modified")
+		case rockferry.ErrorBadArguments:
+			return nil, status.Errorf(codes.InvalidArgument, "patch could not be applied")
+		case rockferry.ErrorStatusPatch, rockferry.ErrorSpecPatch, rockferry.ErrorDeletionTimestamp, rockferry.ErrorCreatorAnnotation:
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		case rockferry.ErrorForbidden:
+			return nil, status.Errorf(codes.PermissionDenied, "not allowed to patch %s %s", req.Kind, *req.Id)
+		}
+
+		fmt.Println("failed to patch resource", err)
+		return nil, status.Errorf(codes.Internal, "something wrong happend")
 	}
 
-	return new(controllerapi.PatchResponse), nil
+	response := new(controllerapi.PatchResponse)
+	response.Ok = true
+	response.ResourceVersion = version
+	response.Generation = generation
+
+	return response, nil
+}
+
+func (c Controller) Patch(ctx context.Context, req *controllerapi.PatchRequest) (*controllerapi.PatchResponse, error) {
+	return c.patch(ctx, req, c.R.Patch)
+}
+
+func (c Controller) PatchStatus(ctx context.Context, req *controllerapi.PatchRequest) (*controllerapi.PatchResponse, error) {
+	return c.patch(ctx, req, c.R.PatchStatus)
 }
 
 func (c Controller) Create(ctx context.Context, input *controllerapi.CreateRequest) (*controllerapi.CreateResponse, error) {
@@ -158,26 +302,101 @@ func (c Controller) Create(ctx context.Context, input *controllerapi.CreateReque
 	}
 
 	mapped := rockferry.MapResource(input.GetResource())
+	rbac.SetCreator(ctx, mapped)
+
+	err := c.Authorizer.Authorize(ctx, rockferry.VerbCreate, mapped)
+	if err == nil {
+		err = c.R.CreateResource(ctx, mapped)
+	}
+
+	if err != nil {
+		if errs, ok := err.(validation.Errors); ok {
+			return nil, invalidArgument(errs)
+		}
+
+		if err == rockferry.ErrorForbidden {
+			return nil, status.Errorf(codes.PermissionDenied, "not allowed to create %s", mapped.Kind)
+		}
+
+		if err == rockferry.ErrorAlreadyExists {
+			return nil, status.Errorf(codes.AlreadyExists, "%s %s already exists", mapped.Kind, mapped.Id)
+		}
 
-	if err := c.R.CreateResource(ctx, mapped); err != nil {
 		fmt.Println("failed to insert resource", err)
 		return nil, status.Errorf(codes.Internal, "something wrong happend")
 	}
 
-	return new(controllerapi.CreateResponse), nil
+	response := new(controllerapi.CreateResponse)
+	response.Id = mapped.Id
+	response.ResourceVersion = mapped.ResourceVersion
+
+	return response, nil
 }
 
 func (c Controller) Delete(ctx context.Context, req *controllerapi.DeleteRequest) (*controllerapi.DeleteResponse, error) {
 	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
 	defer cancel()
 
-	path := fmt.Sprintf("%s/%s/%s", models.RootKey, req.Kind, req.Id)
+	err := c.Authorizer.AuthorizeStored(ctx, rockferry.VerbDelete, req.Kind, req.Id)
+	if err == nil {
+		err = c.R.Delete(ctx, req.Kind, req.Id)
+	}
 
-	_, err := c.Db.Delete(ctx, path)
 	if err != nil {
-		fmt.Println("failed to delete resource")
+		switch err {
+		case rockferry.ErrorNotFound:
+			return nil, status.Errorf(codes.NotFound, "resource not found")
+		case rockferry.ErrorConflict:
+			return nil, status.Errorf(codes.Aborted, "resource has been modified")
+		case rockferry.ErrorForbidden:
+			return nil, status.Errorf(codes.PermissionDenied, "not allowed to delete %s %s", req.Kind, req.Id)
+		}
+
+		fmt.Println("failed to delete resource", err)
 		return nil, status.Errorf(codes.Internal, "something wrong happend")
 	}
 
 	return new(controllerapi.DeleteResponse), nil
 }
+
+func (c Controller) RenewLease(ctx context.Context, req *controllerapi.RenewLeaseRequest) (*controllerapi.RenewLeaseResponse, error) {
+	// NOTE: Renewing the lease keeps the node ready, which is a change to the node.
+	err := c.Authorizer.AuthorizeStored(ctx, rockferry.VerbPatch, rockferry.ResourceKindNode, req.NodeId)
+	if err == nil {
+		err = c.R.RenewNodeLease(ctx, req.NodeId)
+	}
+
+	if err != nil {
+		if err == rockferry.ErrorNotFound {
+			return nil, status.Errorf(codes.NotFound, "node not found")
+		}
+
+		if err == rockferry.ErrorForbidden {
+			return nil, status.Errorf(codes.PermissionDenied, "not allowed to renew the lease of node %s", req.NodeId)
+		}
+
+		fmt.Println("failed to renew node lease", err)
+		return nil, status.Errorf(codes.Internal, "something wrong happend")
+	}
+
+	return new(controllerapi.RenewLeaseResponse), nil
+}
+
+// Field paths are attached as BadRequest details so clients do not have to parse the message.
+func invalidArgument(errs validation.Errors) error {
+	details := new(errdetails.BadRequest)
+	for _, err := range errs {
+		violation := new(errdetails.BadRequest_FieldViolation)
+		violation.Field = err.Field
+		violation.Description = err.Message
+
+		details.FieldViolations = append(details.FieldViolations, violation)
+	}
+
+	s, err := status.New(codes.InvalidArgument, errs.Error()).WithDetails(details)
+	if err != nil {
+		return status.Error(codes.InvalidArgument, errs.Error())
+	}
+
+	return s.Err()
+}
@@ -0,0 +1,121 @@
+// Package audit records who created, patched or deleted what through the apis.
+// Events are written to a sink, which can also be asked for the latest events
+// about a resource.
+package audit
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/eskpil/rockferry/internal/controller/auth"
+	"github.com/eskpil/rockferry/internal/controller/config"
+	"github.com/eskpil/rockferry/internal/controller/runtime"
+	"github.com/eskpil/rockferry/pkg/rockferry/spec"
+)
+
+const (
+	ApiGrpc = "grpc"
+	ApiHttp = "http"
+)
+
+const (
+	// How many events are returned by a query unless asked for fewer.
+	DefaultLimit = 100
+	MaxLimit     = 1000
+)
+
+type Sink interface {
+	Write(ctx context.Context, event *spec.AuditEventSpec) error
+	// Query returns up to limit of the latest events about resources of kind,
+	// newest first. An empty id matches every resource of the kind.
+	Query(ctx context.Context, kind string, id string, limit int) ([]*spec.AuditEventSpec, error)
+}
+
+// Logger records events to its sink. A nil Logger records nothing.
+type Logger struct {
+	sink Sink
+	// Whether the status patches of nodes are recorded.
+	nodeStatus bool
+}
+
+func New(sink Sink) *Logger {
+	l := new(Logger)
+	l.sink = sink
+	return l
+}
+
+// Open returns a logger for the sink of the config, nil if nothing is to be recorded.
+func Open(c *config.Audit, r *runtime.Runtime) (*Logger, error) {
+	switch c.Sink {
+	case config.AuditSinkStdout:
+		return withConfig(New(NewStdoutSink()), c), nil
+	case config.AuditSinkFile:
+		sink, err := NewFileSink(c.Path, int64(c.MaxSize)<<20, c.MaxBackups)
+		if err != nil {
+			return nil, err
+		}
+
+		return withConfig(New(sink), c), nil
+	case config.AuditSinkResource:
+		return withConfig(New(NewResourceSink(r, c.Retention)), c), nil
+	}
+
+	return nil, nil
+}
+
+func withConfig(l *Logger, c *config.Audit) *Logger {
+	l.nodeStatus = c.NodeStatus
+	return l
+}
+
+// RunRetention removes old events until ctx is canceled, if the sink keeps
+// them around for a limited time.
+func (l *Logger) RunRetention(ctx context.Context) {
+	if l == nil {
+		return
+	}
+
+	if sink, ok := l.sink.(*ResourceSink); ok {
+		sink.RunRetention(ctx)
+	}
+}
+
+// Record fills in the caller and writes the event. The call has already been
+// answered, so failing to record it is only reported. Status patches made by
+// nodes are skipped unless asked for, nodes report their status all the time.
+func (l *Logger) Record(ctx context.Context, event *spec.AuditEventSpec) {
+	if l == nil {
+		return
+	}
+
+	if identity, ok := auth.FromContext(ctx); ok {
+		if _, node := identity.Node(); node && !l.nodeStatus && event.Subresource == "status" {
+			return
+		}
+
+		event.User = identity.Name
+		event.Groups = identity.Groups
+	}
+
+	if err := l.sink.Write(ctx, event); err != nil {
+		fmt.Println("failed to write audit event", err)
+	}
+}
+
+// Query returns the latest events about resources of kind, see Sink. The limit
+// is clamped to MaxLimit, DefaultLimit is used if it is not positive.
+func (l *Logger) Query(ctx context.Context, kind string, id string, limit int) ([]*spec.AuditEventSpec, error) {
+	if l == nil {
+		return nil, nil
+	}
+
+	if limit <= 0 {
+		limit = DefaultLimit
+	}
+
+	return l.sink.Query(ctx, kind, id, min(limit, MaxLimit))
+}
+
+func matches(event *spec.AuditEventSpec, kind string, id string) bool {
+	return event.Kind == kind && (id == "" || event.Id == id)
+}
@@ -0,0 +1,127 @@
+package audit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/eskpil/rockferry/internal/controller/controllers/common"
+	"github.com/eskpil/rockferry/internal/controller/rbac"
+	"github.com/eskpil/rockferry/pkg/rockferry"
+	"github.com/eskpil/rockferry/pkg/rockferry/spec"
+	"github.com/labstack/echo/v4"
+)
+
+// Handlers creating a resource put its id here, the body does not carry one.
+const ResourceIdKey = "audit.resource.id"
+
+// The parts of the bodies of creates, patches and deletes which are recorded.
+type body struct {
+	Kind    string          `json:"kind"`
+	Id      string          `json:"id"`
+	Patches json.RawMessage `json:"patches"`
+}
+
+// EchoMiddleware is UnaryInterceptor for a route of the http api which
+// performs verb. It has to come after the authentication middleware.
+func (l *Logger) EchoMiddleware(verb string, subresource string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if l == nil {
+				return next(c)
+			}
+
+			start := time.Now().UTC()
+
+			// NOTE: The handler reads the body as well.
+			contents, err := io.ReadAll(c.Request().Body)
+			if err != nil {
+				return err
+			}
+
+			c.Request().Body = io.NopCloser(bytes.NewReader(contents))
+
+			err = next(c)
+
+			in := new(body)
+			_ = json.Unmarshal(contents, in)
+
+			event := new(spec.AuditEventSpec)
+			event.Time = start
+			event.Source = c.Request().RemoteAddr
+			event.Api = ApiHttp
+			event.Verb = verb
+			event.Kind = in.Kind
+			event.Id = in.Id
+			event.Subresource = subresource
+
+			if len(in.Patches) > 0 && string(in.Patches) != "null" {
+				event.Patch = in.Patches
+			}
+
+			if id, ok := c.Get(ResourceIdKey).(string); ok {
+				event.Id = id
+			}
+
+			code := c.Response().Status
+			if err != nil {
+				code = http.StatusInternalServerError
+				if he, ok := err.(*echo.HTTPError); ok {
+					code = he.Code
+				}
+			}
+
+			event.Code = strconv.Itoa(code)
+
+			l.Record(c.Request().Context(), event)
+
+			return err
+		}
+	}
+}
+
+type ListFilter struct {
+	Kind  string `query:"kind"`
+	Id    string `query:"id"`
+	Limit int    `query:"limit"`
+}
+
+// List answers with the latest events about a resource, or every resource of a
+// kind, newest first.
+func (l *Logger) List() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		ctx, cancel := context.WithTimeout(c.Request().Context(), 10*time.Second)
+		defer cancel()
+
+		filter := new(ListFilter)
+		if err := c.Bind(filter); err != nil || filter.Kind == "" {
+			return c.JSON(http.StatusBadRequest, common.MalformedInput())
+		}
+
+		resource := new(rockferry.Generic)
+		resource.Kind = rockferry.ResourceKindAuditEvent
+
+		err := rbac.ExtractAuthorizer(c).Authorize(ctx, rockferry.VerbList, resource)
+		if err == rockferry.ErrorForbidden {
+			return c.JSON(http.StatusForbidden, common.Forbidden("not allowed to list "+rockferry.ResourceKindAuditEvent))
+		}
+
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, common.InternalServerError())
+		}
+
+		events, err := l.Query(ctx, filter.Kind, filter.Id, filter.Limit)
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, common.InternalServerError())
+		}
+
+		list := new(common.ListResponse[spec.AuditEventSpec])
+		list.List = events
+
+		return c.JSON(http.StatusOK, list)
+	}
+}
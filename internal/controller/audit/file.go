@@ -0,0 +1,165 @@
+package audit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/eskpil/rockferry/pkg/rockferry/spec"
+)
+
+// Writes events as json lines to a file. Once the file grows past the max size
+// it is moved to path.1, the one before that to path.2 and so on, only so many
+// of them are kept.
+type FileSink struct {
+	path       string
+	maxSize    int64
+	maxBackups int
+
+	mu   sync.Mutex
+	file *os.File
+	size int64
+}
+
+func NewFileSink(path string, maxSize int64, maxBackups int) (*FileSink, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return nil, err
+	}
+
+	s := new(FileSink)
+	s.path = path
+	s.maxSize = maxSize
+	s.maxBackups = maxBackups
+
+	if err := s.open(); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+func (s *FileSink) open() error {
+	file, err := os.OpenFile(s.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return err
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+
+	s.file = file
+	s.size = info.Size()
+
+	return nil
+}
+
+func (s *FileSink) backup(n int) string {
+	return fmt.Sprintf("%s.%d", s.path, n)
+}
+
+// Has to be called with mu held.
+func (s *FileSink) rotate() error {
+	if err := s.file.Close(); err != nil {
+		return err
+	}
+
+	if err := os.Remove(s.backup(s.maxBackups)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	for n := s.maxBackups - 1; n >= 1; n-- {
+		if err := os.Rename(s.backup(n), s.backup(n+1)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+
+	// NOTE: Without backups the events are simply dropped.
+	if s.maxBackups > 0 {
+		if err := os.Rename(s.path, s.backup(1)); err != nil {
+			return err
+		}
+	} else if err := os.Remove(s.path); err != nil {
+		return err
+	}
+
+	return s.open()
+}
+
+func (s *FileSink) Write(ctx context.Context, event *spec.AuditEventSpec) error {
+	line, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	line = append(line, '\n')
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.size > 0 && s.size+int64(len(line)) > s.maxSize {
+		if err := s.rotate(); err != nil {
+			return err
+		}
+	}
+
+	n, err := s.file.Write(line)
+	s.size += int64(n)
+
+	return err
+}
+
+// Reads the file and the backups, newest first.
+func (s *FileSink) Query(ctx context.Context, kind string, id string, limit int) ([]*spec.AuditEventSpec, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	paths := []string{s.path}
+	for n := 1; n <= s.maxBackups; n++ {
+		paths = append(paths, s.backup(n))
+	}
+
+	events := []*spec.AuditEventSpec{}
+
+	for _, path := range paths {
+		contents, err := os.ReadFile(path)
+		if os.IsNotExist(err) {
+			break
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		lines := bytes.Split(bytes.TrimSpace(contents), []byte{'\n'})
+		for i := len(lines) - 1; i >= 0; i-- {
+			if len(events) >= limit {
+				return events, nil
+			}
+
+			event := new(spec.AuditEventSpec)
+			if err := json.Unmarshal(lines[i], event); err != nil {
+				continue
+			}
+
+			if matches(event, kind, id) {
+				events = append(events, event)
+			}
+		}
+	}
+
+	return events, nil
+}
+
+func (s *FileSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.file.Close()
+}
@@ -0,0 +1,107 @@
+package audit
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/eskpil/rockferry/controllerapi"
+	"github.com/eskpil/rockferry/pkg/rockferry"
+	"github.com/eskpil/rockferry/pkg/rockferry/spec"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// Members of the store cluster are not resources, their changes are recorded
+// under this kind.
+const KindMember = "member"
+
+func memberId(id uint64) string {
+	// NOTE: Hex with a 0x prefix, which the cli accepts as well.
+	return fmt.Sprintf("%#x", id)
+}
+
+// Describes the call, returns nil for calls which change nothing.
+func describe(method string, req any, res any) *spec.AuditEventSpec {
+	event := new(spec.AuditEventSpec)
+
+	switch method {
+	case controllerapi.ControllerApi_Create_FullMethodName:
+		in := req.(*controllerapi.CreateRequest)
+		event.Verb = rockferry.VerbCreate
+		event.Kind = in.GetResource().GetKind()
+		event.Id = in.GetResource().GetId()
+
+		// NOTE: The id of some kinds is derived from their spec.
+		if out, ok := res.(*controllerapi.CreateResponse); ok && out != nil {
+			event.Id = out.Id
+		}
+	case controllerapi.ControllerApi_Patch_FullMethodName, controllerapi.ControllerApi_PatchStatus_FullMethodName:
+		in := req.(*controllerapi.PatchRequest)
+		event.Verb = rockferry.VerbPatch
+		event.Kind = in.Kind
+		event.Id = in.GetId()
+		event.Patch = in.Patches
+
+		if method == controllerapi.ControllerApi_PatchStatus_FullMethodName {
+			event.Subresource = "status"
+		}
+	case controllerapi.ControllerApi_Delete_FullMethodName:
+		in := req.(*controllerapi.DeleteRequest)
+		event.Verb = rockferry.VerbDelete
+		event.Kind = in.Kind
+		event.Id = in.Id
+	case controllerapi.AdminApi_AddMember_FullMethodName:
+		event.Verb = rockferry.VerbCreate
+		event.Kind = KindMember
+
+		if out, ok := res.(*controllerapi.AddMemberResponse); ok && out != nil {
+			event.Id = memberId(out.GetMember().GetId())
+		}
+	case controllerapi.AdminApi_RemoveMember_FullMethodName:
+		in := req.(*controllerapi.RemoveMemberRequest)
+		event.Verb = rockferry.VerbDelete
+		event.Kind = KindMember
+		event.Id = memberId(in.Id)
+	case controllerapi.AdminApi_RegisterNode_FullMethodName:
+		// NOTE: Neither the token nor the certificate request is recorded.
+		event.Verb = rockferry.VerbCreate
+		event.Kind = rockferry.ResourceKindNode
+
+		if out, ok := res.(*controllerapi.RegisterNodeResponse); ok && out != nil {
+			event.Id = out.Id
+		}
+	default:
+		return nil
+	}
+
+	return event
+}
+
+// UnaryInterceptor records the creates, patches and deletes made through the
+// grpc api, along with the members added and removed and the nodes registered. It has to come after the authentication interceptor.
+func (l *Logger) UnaryInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		start := time.Now().UTC()
+
+		res, err := handler(ctx, req)
+
+		event := describe(info.FullMethod, req, res)
+		if event == nil {
+			return res, err
+		}
+
+		event.Time = start
+		event.Api = ApiGrpc
+		event.Code = status.Code(err).String()
+
+		if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+			event.Source = p.Addr.String()
+		}
+
+		l.Record(ctx, event)
+
+		return res, err
+	}
+}
@@ -0,0 +1,55 @@
+package audit
+
+import (
+	"testing"
+
+	"github.com/eskpil/rockferry/controllerapi"
+	"github.com/eskpil/rockferry/pkg/rockferry"
+)
+
+func TestDescribeAdmin(t *testing.T) {
+	tests := []struct {
+		method string
+		req    any
+		res    any
+		verb   string
+		kind   string
+		id     string
+	}{
+		{
+			controllerapi.AdminApi_AddMember_FullMethodName,
+			&controllerapi.AddMemberRequest{PeerUrls: []string{"http://b:2380"}, JoinToken: "secret"},
+			&controllerapi.AddMemberResponse{Member: &controllerapi.Member{Id: 0xabc}},
+			rockferry.VerbCreate, KindMember, "0xabc",
+		},
+		{
+			controllerapi.AdminApi_AddMember_FullMethodName,
+			&controllerapi.AddMemberRequest{JoinToken: "wrong"},
+			(*controllerapi.AddMemberResponse)(nil),
+			rockferry.VerbCreate, KindMember, "",
+		},
+		{
+			controllerapi.AdminApi_RemoveMember_FullMethodName,
+			&controllerapi.RemoveMemberRequest{Id: 0xabc},
+			&controllerapi.RemoveMemberResponse{},
+			rockferry.VerbDelete, KindMember, "0xabc",
+		},
+		{
+			controllerapi.AdminApi_RegisterNode_FullMethodName,
+			&controllerapi.RegisterNodeRequest{BootstrapToken: "a.b"},
+			&controllerapi.RegisterNodeResponse{Id: "node"},
+			rockferry.VerbCreate, rockferry.ResourceKindNode, "node",
+		},
+	}
+
+	for _, test := range tests {
+		event := describe(test.method, test.req, test.res)
+		if event == nil {
+			t.Fatalf("%s is not audited", test.method)
+		}
+
+		if event.Verb != test.verb || event.Kind != test.kind || event.Id != test.id || event.Patch != nil {
+			t.Fatalf("%s is described as %s %s/%s", test.method, event.Verb, event.Kind, event.Id)
+		}
+	}
+}
@@ -0,0 +1,191 @@
+package audit
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/eskpil/rockferry/internal/controller/models"
+	"github.com/eskpil/rockferry/internal/controller/runtime"
+	"github.com/eskpil/rockferry/pkg/rockferry"
+	"github.com/eskpil/rockferry/pkg/rockferry/spec"
+	"github.com/google/uuid"
+)
+
+// How often events older than the retention are removed.
+const retentionInterval = 5 * time.Minute
+
+// Stores events as AuditEvent resources, shared by every controller and kept
+// for as long as the retention. Events are indexed by the resource they are
+// about, queries only read the events they return.
+type ResourceSink struct {
+	r         *runtime.Runtime
+	retention time.Duration
+}
+
+func NewResourceSink(r *runtime.Runtime, retention time.Duration) *ResourceSink {
+	s := new(ResourceSink)
+	s.r = r
+	s.retention = retention
+	return s
+}
+
+func (s *ResourceSink) Write(ctx context.Context, event *spec.AuditEventSpec) error {
+	resource := new(rockferry.AuditEvent)
+	// NOTE: Ids sort by time, which is the order events are queried in.
+	resource.Id = fmt.Sprintf("%020d-%s", event.Time.UnixNano(), uuid.NewString()[:8])
+	resource.Kind = rockferry.ResourceKindAuditEvent
+	resource.Phase = rockferry.PhaseCreated
+	resource.Spec = *event
+
+	if err := s.r.Update(ctx, resource.Generic()); err != nil {
+		return err
+	}
+
+	return s.index(ctx, resource)
+}
+
+func indexKey(event *rockferry.AuditEvent) string {
+	return models.AuditIndexPrefix(event.Spec.Kind, event.Spec.Id) + event.Id
+}
+
+// NOTE: Written after the event, an event without an index entry is only
+// missing from queries until the retention removes it.
+func (s *ResourceSink) index(ctx context.Context, event *rockferry.AuditEvent) error {
+	_, err := s.r.Store.Put(ctx, indexKey(event), []byte(event.Id))
+	return err
+}
+
+func (s *ResourceSink) list(ctx context.Context) ([]*rockferry.AuditEvent, error) {
+	generics, err := s.r.ListOwned(ctx, rockferry.ResourceKindAuditEvent, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	events := make([]*rockferry.AuditEvent, len(generics))
+	for i, generic := range generics {
+		events[i] = rockferry.CastFromMap[spec.AuditEventSpec, rockferry.DefaultStatus](generic)
+	}
+
+	return events, nil
+}
+
+func (s *ResourceSink) Query(ctx context.Context, kind string, id string, limit int) ([]*spec.AuditEventSpec, error) {
+	kvs, _, err := s.r.Store.List(ctx, models.AuditIndexPrefix(kind, id))
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]string, len(kvs))
+	for i, kv := range kvs {
+		ids[i] = string(kv.Value)
+	}
+
+	// NOTE: The events of a kind are indexed per resource.
+	slices.SortFunc(ids, func(a, b string) int {
+		return strings.Compare(b, a)
+	})
+
+	events := []*spec.AuditEventSpec{}
+	for _, id := range ids {
+		if len(events) >= limit {
+			break
+		}
+
+		generic, err := s.r.Fetch(ctx, rockferry.ResourceKindAuditEvent, id)
+		if err == rockferry.ErrorNotFound {
+			// Removed by the retention since it was listed.
+			continue
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		resource := rockferry.CastFromMap[spec.AuditEventSpec, rockferry.DefaultStatus](generic)
+		events = append(events, &resource.Spec)
+	}
+
+	return events, nil
+}
+
+// Indexes events recorded before events were indexed.
+func (s *ResourceSink) reindex(ctx context.Context) error {
+	kvs, _, err := s.r.Store.List(ctx, models.AuditIndexKey+"/")
+	if err != nil {
+		return err
+	}
+
+	indexed := map[string]bool{}
+	for _, kv := range kvs {
+		indexed[kv.Key] = true
+	}
+
+	events, err := s.list(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, event := range events {
+		if indexed[indexKey(event)] {
+			continue
+		}
+
+		if err := s.index(ctx, event); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *ResourceSink) prune(ctx context.Context) error {
+	events, err := s.list(ctx)
+	if err != nil {
+		return err
+	}
+
+	cutoff := time.Now().Add(-s.retention)
+
+	for _, event := range events {
+		if event.Spec.Time.After(cutoff) {
+			continue
+		}
+
+		if err := s.r.Delete(ctx, rockferry.ResourceKindAuditEvent, event.Id); err != nil && err != rockferry.ErrorNotFound {
+			fmt.Println("failed to remove audit event", err)
+			continue
+		}
+
+		if _, err := s.r.Store.Delete(ctx, indexKey(event)); err != nil {
+			fmt.Println("failed to remove audit event from the index", err)
+		}
+	}
+
+	return nil
+}
+
+// RunRetention removes events older than the retention until ctx is canceled.
+// Only one controller has to run it.
+func (s *ResourceSink) RunRetention(ctx context.Context) {
+	if err := s.reindex(ctx); err != nil {
+		fmt.Println("failed to index audit events", err)
+	}
+
+	ticker := time.NewTicker(retentionInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := s.prune(ctx); err != nil {
+			fmt.Println("failed to prune audit events", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,162 @@
+package audit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/eskpil/rockferry/internal/controller"
+	"github.com/eskpil/rockferry/internal/controller/auth"
+	"github.com/eskpil/rockferry/internal/controller/config"
+	"github.com/eskpil/rockferry/internal/controller/pki"
+	"github.com/eskpil/rockferry/internal/controller/runtime"
+	"github.com/eskpil/rockferry/internal/controller/store"
+	"github.com/eskpil/rockferry/pkg/rockferry"
+	"github.com/eskpil/rockferry/pkg/rockferry/spec"
+)
+
+func newResourceLogger(t *testing.T, c *config.Audit) (*Logger, *ResourceSink) {
+	t.Helper()
+
+	s := store.NewMemory()
+	t.Cleanup(func() { s.Close() })
+
+	if err := controller.Initialize(s); err != nil {
+		t.Fatal(err)
+	}
+
+	c.Sink = config.AuditSinkResource
+
+	l, err := Open(c, runtime.New(s))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return l, l.sink.(*ResourceSink)
+}
+
+func record(ctx context.Context, l *Logger, verb string, kind string, id string, subresource string) {
+	event := new(spec.AuditEventSpec)
+	event.Time = time.Now().UTC()
+	event.Api = ApiGrpc
+	event.Verb = verb
+	event.Kind = kind
+	event.Id = id
+	event.Subresource = subresource
+
+	l.Record(ctx, event)
+}
+
+func TestResourceSinkQuery(t *testing.T) {
+	ctx := context.Background()
+	l, _ := newResourceLogger(t, &config.Default().Audit)
+
+	record(ctx, l, rockferry.VerbCreate, rockferry.ResourceKindMachine, "a", "")
+	record(ctx, l, rockferry.VerbCreate, rockferry.ResourceKindMachine, "ab", "")
+	record(ctx, l, rockferry.VerbPatch, rockferry.ResourceKindMachine, "a", "")
+	record(ctx, l, rockferry.VerbCreate, rockferry.ResourceKindNetwork, "a", "")
+	record(ctx, l, rockferry.VerbDelete, rockferry.ResourceKindMachine, "a", "")
+
+	tests := []struct {
+		name  string
+		kind  string
+		id    string
+		limit int
+		verbs []string
+	}{
+		{"resource", rockferry.ResourceKindMachine, "a", 0, []string{rockferry.VerbDelete, rockferry.VerbPatch, rockferry.VerbCreate}},
+		{"limited", rockferry.ResourceKindMachine, "a", 2, []string{rockferry.VerbDelete, rockferry.VerbPatch}},
+		{"kind", rockferry.ResourceKindMachine, "", 0, []string{rockferry.VerbDelete, rockferry.VerbPatch, rockferry.VerbCreate, rockferry.VerbCreate}},
+		{"nothing", rockferry.ResourceKindNode, "", 0, []string{}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			events, err := l.Query(ctx, test.kind, test.id, test.limit)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(events) != len(test.verbs) {
+				t.Fatalf("got %d events, want %d", len(events), len(test.verbs))
+			}
+
+			for i, event := range events {
+				if event.Kind != test.kind || (test.id != "" && event.Id != test.id) || event.Verb != test.verbs[i] {
+					t.Fatalf("event %d is %s %s/%s, want %s", i, event.Verb, event.Kind, event.Id, test.verbs[i])
+				}
+			}
+		})
+	}
+}
+
+func TestResourceSinkPrune(t *testing.T) {
+	ctx := context.Background()
+
+	c := config.Default().Audit
+	c.Retention = time.Hour
+	l, sink := newResourceLogger(t, &c)
+
+	old := new(spec.AuditEventSpec)
+	old.Time = time.Now().Add(-2 * time.Hour).UTC()
+	old.Verb = rockferry.VerbCreate
+	old.Kind = rockferry.ResourceKindMachine
+	old.Id = "a"
+	l.Record(ctx, old)
+
+	record(ctx, l, rockferry.VerbDelete, rockferry.ResourceKindMachine, "a", "")
+
+	if err := sink.prune(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	events, err := l.Query(ctx, rockferry.ResourceKindMachine, "a", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(events) != 1 || events[0].Verb != rockferry.VerbDelete {
+		t.Fatalf("got %+v after pruning, want only the recent event", events)
+	}
+
+	index, _, err := sink.r.Store.List(ctx, "rockferry-audit/")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(index) != 1 {
+		t.Fatalf("%d events are indexed after pruning, want 1", len(index))
+	}
+}
+
+func TestNodeStatusSkipped(t *testing.T) {
+	node := new(auth.Identity)
+	node.Name = "a"
+	node.Groups = []string{pki.GroupNodes}
+	node.Certificate = true
+
+	ctx := auth.WithIdentity(context.Background(), node)
+
+	for _, recorded := range []bool{false, true} {
+		c := config.Default().Audit
+		c.NodeStatus = recorded
+		l, _ := newResourceLogger(t, &c)
+
+		record(ctx, l, rockferry.VerbPatch, rockferry.ResourceKindNode, "a", "status")
+		record(ctx, l, rockferry.VerbPatch, rockferry.ResourceKindNode, "a", "")
+
+		events, err := l.Query(ctx, rockferry.ResourceKindNode, "a", 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		want := 1
+		if recorded {
+			want = 2
+		}
+
+		if len(events) != want {
+			t.Fatalf("got %d events with node status set to %v, want %d", len(events), recorded, want)
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/eskpil/rockferry/pkg/rockferry/spec"
+)
+
+// How many events the stdout sink keeps around to answer queries with.
+const recentEvents = 1024
+
+// Writes events as json lines to stdout. Only the latest events recorded by
+// this controller can be queried.
+type StdoutSink struct {
+	mu     sync.Mutex
+	out    io.Writer
+	recent []*spec.AuditEventSpec
+}
+
+func NewStdoutSink() *StdoutSink {
+	s := new(StdoutSink)
+	s.out = os.Stdout
+	return s
+}
+
+func (s *StdoutSink) Write(ctx context.Context, event *spec.AuditEventSpec) error {
+	line, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.recent = append(s.recent, event)
+	if len(s.recent) > recentEvents {
+		s.recent = s.recent[len(s.recent)-recentEvents:]
+	}
+
+	_, err = s.out.Write(append(line, '\n'))
+	return err
+}
+
+func (s *StdoutSink) Query(ctx context.Context, kind string, id string, limit int) ([]*spec.AuditEventSpec, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	events := []*spec.AuditEventSpec{}
+	for i := len(s.recent) - 1; i >= 0 && len(events) < limit; i-- {
+		if matches(s.recent[i], kind, id) {
+			events = append(events, s.recent[i])
+		}
+	}
+
+	return events, nil
+}
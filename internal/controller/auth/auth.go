@@ -0,0 +1,77 @@
+// Package auth finds out who is calling the apis. Callers are identified by the
+// client certificate the internal ca issued them, or by an id token from an
+// OpenID Connect provider.
+package auth
+
+import (
+	"context"
+	"crypto/tls"
+	"slices"
+	"strings"
+
+	"github.com/eskpil/rockferry/internal/controller/pki"
+)
+
+// Identity is who a request is made by.
+type Identity struct {
+	Name   string
+	Groups []string
+
+	// Set for identities taken from a certificate issued by the internal ca.
+	// Only those are trusted with the groups of package pki, anyone else could
+	// be named after them.
+	Certificate bool
+}
+
+func (i *Identity) InGroup(group string) bool {
+	return slices.Contains(i.Groups, group)
+}
+
+// Node returns the id of the node, if the identity belongs to a node agent.
+func (i *Identity) Node() (string, bool) {
+	if !i.Certificate || !i.InGroup(pki.GroupNodes) {
+		return "", false
+	}
+
+	return i.Name, true
+}
+
+type identityKey struct{}
+
+func WithIdentity(ctx context.Context, identity *Identity) context.Context {
+	return context.WithValue(ctx, identityKey{}, identity)
+}
+
+// FromContext returns the identity of the caller, if it is known.
+func FromContext(ctx context.Context) (*Identity, bool) {
+	identity, ok := ctx.Value(identityKey{}).(*Identity)
+	return identity, ok
+}
+
+// Finds the token in the values of an authorization header.
+func bearerToken(values ...string) (string, bool) {
+	for _, value := range values {
+		scheme, token, ok := strings.Cut(value, " ")
+		if ok && strings.EqualFold(scheme, "bearer") && token != "" {
+			return token, true
+		}
+	}
+
+	return "", false
+}
+
+// Only certificates which have been verified against the ca identify anyone.
+func fromConnection(state *tls.ConnectionState) (*Identity, bool) {
+	if state == nil || len(state.VerifiedChains) == 0 || len(state.VerifiedChains[0]) == 0 {
+		return nil, false
+	}
+
+	cert := state.VerifiedChains[0][0]
+
+	identity := new(Identity)
+	identity.Name = cert.Subject.CommonName
+	identity.Groups = cert.Subject.Organization
+	identity.Certificate = true
+
+	return identity, true
+}
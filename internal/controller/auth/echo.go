@@ -0,0 +1,36 @@
+package auth
+
+import (
+	"net/http"
+
+	"github.com/eskpil/rockferry/internal/controller/controllers/common"
+	"github.com/labstack/echo/v4"
+)
+
+// EchoMiddleware is UnaryInterceptor for the http api.
+func EchoMiddleware(tokens *Verifier) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			identity, ok := fromConnection(c.Request().TLS)
+
+			if !ok && tokens != nil {
+				if token, found := bearerToken(c.Request().Header.Values(echo.HeaderAuthorization)...); found {
+					verified, err := tokens.Verify(c.Request().Context(), token)
+					if err != nil {
+						return c.JSON(http.StatusUnauthorized, common.Unauthorized(err.Error()))
+					}
+
+					identity, ok = verified, true
+				}
+			}
+
+			if !ok {
+				return c.JSON(http.StatusUnauthorized, common.Unauthorized("a client certificate or bearer token is required"))
+			}
+
+			c.SetRequest(c.Request().WithContext(WithIdentity(c.Request().Context(), identity)))
+
+			return next(c)
+		}
+	}
+}
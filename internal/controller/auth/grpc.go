@@ -0,0 +1,81 @@
+package auth
+
+import (
+	"context"
+
+	"github.com/eskpil/rockferry/controllerapi"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// Called before the caller has a certificate, they check a token instead.
+var public = map[string]bool{
+	controllerapi.AdminApi_AddMember_FullMethodName:    true,
+	controllerapi.AdminApi_RegisterNode_FullMethodName: true,
+}
+
+func authenticate(ctx context.Context, tokens *Verifier, method string) (context.Context, error) {
+	if p, ok := peer.FromContext(ctx); ok {
+		if info, ok := p.AuthInfo.(credentials.TLSInfo); ok {
+			if identity, ok := fromConnection(&info.State); ok {
+				return WithIdentity(ctx, identity), nil
+			}
+		}
+	}
+
+	if md, ok := metadata.FromIncomingContext(ctx); ok && tokens != nil {
+		if token, ok := bearerToken(md.Get("authorization")...); ok {
+			identity, err := tokens.Verify(ctx, token)
+			if err != nil {
+				return nil, status.Error(codes.Unauthenticated, err.Error())
+			}
+
+			return WithIdentity(ctx, identity), nil
+		}
+	}
+
+	if public[method] {
+		return ctx, nil
+	}
+
+	return nil, status.Errorf(codes.Unauthenticated, "a client certificate or bearer token is required")
+}
+
+// UnaryInterceptor refuses callers without a client certificate or a bearer
+// token verified by tokens, which may be nil. The identity of the others is
+// made available through FromContext.
+func UnaryInterceptor(tokens *Verifier) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		ctx, err := authenticate(ctx, tokens, info.FullMethod)
+		if err != nil {
+			return nil, err
+		}
+
+		return handler(ctx, req)
+	}
+}
+
+type identifiedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *identifiedStream) Context() context.Context {
+	return s.ctx
+}
+
+// StreamInterceptor is UnaryInterceptor for streams.
+func StreamInterceptor(tokens *Verifier) grpc.StreamServerInterceptor {
+	return func(srv any, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, err := authenticate(stream.Context(), tokens, info.FullMethod)
+		if err != nil {
+			return err
+		}
+
+		return handler(srv, &identifiedStream{ServerStream: stream, ctx: ctx})
+	}
+}
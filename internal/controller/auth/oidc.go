@@ -0,0 +1,342 @@
+package auth
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/eskpil/rockferry/internal/controller/config"
+	"github.com/golang-jwt/jwt/v4"
+	"golang.org/x/sync/singleflight"
+)
+
+const (
+	// How long the keys of the provider are trusted before they are fetched again.
+	keysLifetime = time.Hour
+	// Tokens signed by a key which is not known yet make the keys be fetched
+	// again, but not more often than this.
+	keysRefetchInterval = 10 * time.Second
+
+	requestTimeout = 10 * time.Second
+)
+
+var ErrInvalidToken = errors.New("invalid token")
+
+// Signing algorithms accepted from the provider.
+var signingMethods = []string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512"}
+
+// Verifier checks id tokens issued by an OpenID Connect provider. The provider
+// is discovered and its keys fetched on first use, they are cached afterwards.
+type Verifier struct {
+	config *config.Oidc
+	client *http.Client
+	parser *jwt.Parser
+
+	// Requests waiting for the keys share a single fetch.
+	fetches singleflight.Group
+
+	mu      sync.Mutex
+	jwksUri string
+	keys    map[string]any
+	fetched time.Time
+}
+
+func NewVerifier(c *config.Oidc) (*Verifier, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+
+	if c.CA != "" {
+		contents, err := os.ReadFile(c.CA)
+		if err != nil {
+			return nil, err
+		}
+
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(contents) {
+			return nil, fmt.Errorf("no certificates found in %s", c.CA)
+		}
+
+		transport.TLSClientConfig = &tls.Config{RootCAs: pool}
+	}
+
+	v := new(Verifier)
+	v.config = c
+	v.client = &http.Client{Transport: transport, Timeout: requestTimeout}
+	v.parser = jwt.NewParser(jwt.WithValidMethods(signingMethods))
+
+	return v, nil
+}
+
+func (v *Verifier) get(ctx context.Context, url string, out any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+
+	res, err := v.client.Do(req)
+	if err != nil {
+		return err
+	}
+
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s responded with %s", url, res.Status)
+	}
+
+	return json.NewDecoder(res.Body).Decode(out)
+}
+
+type discovery struct {
+	Issuer  string `json:"issuer"`
+	JwksUri string `json:"jwks_uri"`
+}
+
+// Finds where the provider keeps its keys.
+func (v *Verifier) discover(ctx context.Context) (string, error) {
+	url := strings.TrimSuffix(v.config.Issuer, "/") + "/.well-known/openid-configuration"
+
+	d := new(discovery)
+	if err := v.get(ctx, url, d); err != nil {
+		return "", err
+	}
+
+	// NOTE: Otherwise a provider could hand out tokens in the name of another.
+	if d.Issuer != v.config.Issuer {
+		return "", fmt.Errorf("provider claims to be %s rather than %s", d.Issuer, v.config.Issuer)
+	}
+
+	if d.JwksUri == "" {
+		return "", fmt.Errorf("provider %s has no jwks uri", v.config.Issuer)
+	}
+
+	return d.JwksUri, nil
+}
+
+type jwk struct {
+	Kid string `json:"kid"`
+	Kty string `json:"kty"`
+	Use string `json:"use"`
+
+	// RSA
+	N string `json:"n"`
+	E string `json:"e"`
+
+	// EC
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+func decodeInt(value string) (*big.Int, error) {
+	bytes, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, err
+	}
+
+	return new(big.Int).SetBytes(bytes), nil
+}
+
+func (k *jwk) publicKey() (any, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeInt(k.N)
+		if err != nil {
+			return nil, err
+		}
+
+		e, err := decodeInt(k.E)
+		if err != nil {
+			return nil, err
+		}
+
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve %s", k.Crv)
+		}
+
+		x, err := decodeInt(k.X)
+		if err != nil {
+			return nil, err
+		}
+
+		y, err := decodeInt(k.Y)
+		if err != nil {
+			return nil, err
+		}
+
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	}
+
+	return nil, fmt.Errorf("unsupported key type %s", k.Kty)
+}
+
+// Fetches the keys of the provider, discovering it first if needed. Must not be
+// called with mu held, the provider may take its time to answer.
+func (v *Verifier) fetchKeys(ctx context.Context) error {
+	v.mu.Lock()
+	uri := v.jwksUri
+	v.mu.Unlock()
+
+	// NOTE: Stamped once done, requests arriving meanwhile wait for this fetch
+	// rather than giving up on a key it may bring. Failed attempts count as
+	// well, an unreachable provider is not asked for every request.
+	defer func() {
+		v.mu.Lock()
+		v.fetched = time.Now()
+		v.mu.Unlock()
+	}()
+
+	if uri == "" {
+		discovered, err := v.discover(ctx)
+		if err != nil {
+			return err
+		}
+
+		uri = discovered
+	}
+
+	set := new(struct {
+		Keys []*jwk `json:"keys"`
+	})
+
+	if err := v.get(ctx, uri, set); err != nil {
+		return err
+	}
+
+	keys := map[string]any{}
+	for _, k := range set.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+
+		key, err := k.publicKey()
+		if err != nil {
+			fmt.Println("skipping key", k.Kid, "of the openid provider", err)
+			continue
+		}
+
+		keys[k.Kid] = key
+	}
+
+	v.mu.Lock()
+	v.jwksUri = uri
+	v.keys = keys
+	v.mu.Unlock()
+
+	return nil
+}
+
+func (v *Verifier) cached(kid string) (any, bool, time.Duration) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	key, ok := v.keys[kid]
+	return key, ok, time.Since(v.fetched)
+}
+
+// Returns the key the token was signed by.
+func (v *Verifier) key(ctx context.Context, kid string) (any, error) {
+	key, known, age := v.cached(kid)
+
+	// NOTE: An unknown key usually means the provider has rotated its keys.
+	if age > keysLifetime || (!known && age > keysRefetchInterval) {
+		// NOTE: The fetch is shared, the request starting it going away must not
+		// cancel it for the others.
+		_, err, _ := v.fetches.Do("keys", func() (any, error) {
+			// NOTE: Another request may have just fetched them.
+			if _, _, age := v.cached(kid); age < keysRefetchInterval {
+				return nil, nil
+			}
+
+			return nil, v.fetchKeys(context.WithoutCancel(ctx))
+		})
+		if err != nil {
+			if !known {
+				return nil, err
+			}
+
+			// NOTE: Known keys keep being trusted while the provider is unreachable.
+			fmt.Println("failed to refresh the keys of the openid provider", err)
+		}
+
+		key, known, _ = v.cached(kid)
+	}
+
+	if !known {
+		return nil, fmt.Errorf("signed by unknown key %q", kid)
+	}
+
+	return key, nil
+}
+
+// Verify checks the signature, issuer, audience and expiry of the id token and
+// returns who it was issued to.
+func (v *Verifier) Verify(ctx context.Context, raw string) (*Identity, error) {
+	claims := jwt.MapClaims{}
+
+	_, err := v.parser.ParseWithClaims(raw, claims, func(token *jwt.Token) (any, error) {
+		kid, _ := token.Header["kid"].(string)
+		return v.key(ctx, kid)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+
+	now := time.Now().Unix()
+
+	if !claims.VerifyExpiresAt(now, true) {
+		return nil, fmt.Errorf("%w: expired", ErrInvalidToken)
+	}
+
+	if !claims.VerifyIssuer(v.config.Issuer, true) {
+		return nil, fmt.Errorf("%w: issued by someone else", ErrInvalidToken)
+	}
+
+	if !claims.VerifyAudience(v.config.ClientId, true) {
+		return nil, fmt.Errorf("%w: issued to someone else", ErrInvalidToken)
+	}
+
+	username, _ := claims[v.config.UsernameClaim].(string)
+	if username == "" {
+		return nil, fmt.Errorf("%w: claim %s is missing", ErrInvalidToken, v.config.UsernameClaim)
+	}
+
+	identity := new(Identity)
+	identity.Name = v.config.Prefix + username
+
+	// NOTE: Providers send a single group as a string rather than a list.
+	switch groups := claims[v.config.GroupsClaim].(type) {
+	case string:
+		identity.Groups = append(identity.Groups, v.config.Prefix+groups)
+	case []any:
+		for _, group := range groups {
+			if name, ok := group.(string); ok {
+				identity.Groups = append(identity.Groups, v.config.Prefix+name)
+			}
+		}
+	}
+
+	return identity, nil
+}
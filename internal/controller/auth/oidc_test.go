@@ -0,0 +1,263 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/eskpil/rockferry/internal/controller/config"
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// An OpenID Connect provider serving discovery and the public halves of keys.
+type issuer struct {
+	server *httptest.Server
+
+	mu   sync.Mutex
+	keys map[string]*rsa.PrivateKey
+	// How often the keys were asked for.
+	fetches atomic.Int32
+}
+
+func newIssuer(t *testing.T) *issuer {
+	t.Helper()
+
+	i := new(issuer)
+	i.keys = map[string]*rsa.PrivateKey{}
+
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{"issuer": i.server.URL, "jwks_uri": i.server.URL + "/keys"})
+	})
+
+	mux.HandleFunc("/keys", func(w http.ResponseWriter, r *http.Request) {
+		i.fetches.Add(1)
+
+		i.mu.Lock()
+		defer i.mu.Unlock()
+
+		keys := []map[string]string{}
+		for kid, key := range i.keys {
+			keys = append(keys, map[string]string{
+				"kid": kid,
+				"kty": "RSA",
+				"use": "sig",
+				"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+			})
+		}
+
+		json.NewEncoder(w).Encode(map[string]any{"keys": keys})
+	})
+
+	i.server = httptest.NewServer(mux)
+	t.Cleanup(i.server.Close)
+
+	return i
+}
+
+// Replaces the keys of the provider by a new one.
+func (i *issuer) rotate(t *testing.T, kid string) {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	i.keys = map[string]*rsa.PrivateKey{kid: key}
+}
+
+func (i *issuer) sign(t *testing.T, kid string, claims jwt.MapClaims) string {
+	t.Helper()
+
+	i.mu.Lock()
+	key := i.keys[kid]
+	i.mu.Unlock()
+
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = kid
+
+	raw, err := token.SignedString(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return raw
+}
+
+func (i *issuer) claims() jwt.MapClaims {
+	return jwt.MapClaims{
+		"iss":    i.server.URL,
+		"aud":    "rockferry",
+		"sub":    "alice",
+		"groups": []string{"ops"},
+		"exp":    time.Now().Add(time.Hour).Unix(),
+	}
+}
+
+func newTestVerifier(t *testing.T, i *issuer) *Verifier {
+	t.Helper()
+
+	c := config.Default().Oidc
+	c.Issuer = i.server.URL
+	c.ClientId = "rockferry"
+
+	v, err := NewVerifier(&c)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return v
+}
+
+func TestVerify(t *testing.T) {
+	i := newIssuer(t)
+	i.rotate(t, "first")
+
+	tests := []struct {
+		name   string
+		mutate func(claims jwt.MapClaims)
+		valid  bool
+	}{
+		{"valid", func(jwt.MapClaims) {}, true},
+		{"wrong audience", func(c jwt.MapClaims) { c["aud"] = "someone-else" }, false},
+		{"wrong issuer", func(c jwt.MapClaims) { c["iss"] = "https://evil.example.com" }, false},
+		{"expired", func(c jwt.MapClaims) { c["exp"] = time.Now().Add(-time.Minute).Unix() }, false},
+		{"without expiry", func(c jwt.MapClaims) { delete(c, "exp") }, false},
+		{"without username", func(c jwt.MapClaims) { delete(c, "sub") }, false},
+	}
+
+	v := newTestVerifier(t, i)
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			claims := i.claims()
+			test.mutate(claims)
+
+			identity, err := v.Verify(context.Background(), i.sign(t, "first", claims))
+			if !test.valid {
+				if !errors.Is(err, ErrInvalidToken) {
+					t.Fatalf("verifying gave %v, want %v", err, ErrInvalidToken)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if identity.Name != "oidc:alice" || !slices.Equal(identity.Groups, []string{"oidc:ops"}) {
+				t.Fatalf("identity is %+v", identity)
+			}
+		})
+	}
+}
+
+func TestVerifyForgedSignature(t *testing.T) {
+	i := newIssuer(t)
+	i.rotate(t, "first")
+
+	v := newTestVerifier(t, i)
+
+	// NOTE: Signed by a key of the same id the provider never published.
+	forger := newIssuer(t)
+	forger.rotate(t, "first")
+
+	if _, err := v.Verify(context.Background(), forger.sign(t, "first", i.claims())); !errors.Is(err, ErrInvalidToken) {
+		t.Fatalf("verifying a forged token gave %v, want %v", err, ErrInvalidToken)
+	}
+}
+
+func TestVerifyKeyRotation(t *testing.T) {
+	ctx := context.Background()
+
+	i := newIssuer(t)
+	i.rotate(t, "first")
+
+	v := newTestVerifier(t, i)
+
+	if _, err := v.Verify(ctx, i.sign(t, "first", i.claims())); err != nil {
+		t.Fatal(err)
+	}
+
+	i.rotate(t, "second")
+	rotated := i.sign(t, "second", i.claims())
+
+	// NOTE: Keys are only fetched again a while after the last fetch.
+	if _, err := v.Verify(ctx, rotated); err == nil {
+		t.Fatal("token signed by a key fetched too early was accepted")
+	}
+
+	v.mu.Lock()
+	v.fetched = time.Now().Add(-keysRefetchInterval)
+	v.mu.Unlock()
+
+	before := i.fetches.Load()
+
+	// Requests waiting for the new keys share a single fetch.
+	wg := new(sync.WaitGroup)
+	errs := make(chan error, 10)
+
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			_, err := v.Verify(ctx, rotated)
+			errs <- err
+		}()
+	}
+
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if fetches := i.fetches.Load() - before; fetches != 1 {
+		t.Fatalf("keys were fetched %d times, want once", fetches)
+	}
+}
+
+func TestVerifiedIdentityIsNoNode(t *testing.T) {
+	i := newIssuer(t)
+	i.rotate(t, "first")
+
+	v := newTestVerifier(t, i)
+
+	claims := i.claims()
+	claims["groups"] = []string{"nodes"}
+
+	// NOTE: Even a provider naming its groups after those of the ca is not
+	// trusted with them.
+	v.config.Prefix = "rockferry:"
+
+	identity, err := v.Verify(context.Background(), i.sign(t, "first", claims))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := identity.Node(); ok || identity.Certificate {
+		t.Fatalf("identity %+v from an id token passes as a node", identity)
+	}
+}
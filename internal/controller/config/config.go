@@ -1,10 +1,497 @@
+// Package config is the configuration of the controller. Defaults are
+// overridden by the config file, then by environment variables and last by
+// flags.
 package config
 
-type Config struct {
-	Nodes []Node `yaml:"nodes"`
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	// Runs etcd inside of the controller process.
+	StoreBackendEmbedded = "embedded"
+	// Connects to an etcd cluster managed elsewhere.
+	StoreBackendExternal = "external"
+	// Keeps everything in memory, nothing survives a restart.
+	StoreBackendMemory = "memory"
+)
+
+const (
+	// Writes audit events as json lines to stdout.
+	AuditSinkStdout = "stdout"
+	// Writes audit events as json lines to a file, which is rotated.
+	AuditSinkFile = "file"
+	// Stores audit events as resources, they are shared by every controller.
+	AuditSinkResource = "resource"
+	// Records nothing.
+	AuditSinkNone = "none"
+)
+
+var ErrInvalidConfig = errors.New("invalid config")
+
+// Users and groups starting with it belong to the controller, such as the
+// groups of the certificates in package pki.
+const reservedPrefix = "rockferry:"
+
+type TLS struct {
+	// Verifies the server, the system roots are used if empty.
+	CA string `yaml:"ca"`
+	// Client certificate and key, both or neither have to be set.
+	Cert string `yaml:"cert"`
+	Key  string `yaml:"key"`
 }
 
-type Node struct {
+// How the embedded etcd forms a cluster with the etcd of other controllers.
+type Cluster struct {
+	// The name of this member, unique within the cluster.
 	Name string `yaml:"name"`
-	Url  string `yaml:"url"`
+	// Where other members and clients reach this member.
+	PeerUrl   string `yaml:"peer_url"`
+	ClientUrl string `yaml:"client_url"`
+	// Where to listen for other members, defaults to the peer url.
+	ListenPeerUrl string `yaml:"listen_peer_url"`
+	// Where to listen for clients, defaults to localhost on the port of the
+	// client url. The controller talks to its member in process.
+	ListenClientUrl string `yaml:"listen_client_url"`
+
+	// Members forming a new cluster, name to peer url, this one included. Left
+	// out for a cluster of one.
+	InitialCluster map[string]string `yaml:"initial_cluster"`
+	// The grpc address of a controller in an existing cluster. This member is
+	// added to the cluster through it on the first start.
+	Join string `yaml:"join"`
+	// Has to be presented by members joining through this controller. Joining
+	// is refused if it is empty.
+	JoinToken string `yaml:"join_token"`
+	// The ca certificate of the cluster being joined, found in the pki dir of
+	// any of its controllers.
+	JoinCA string `yaml:"join_ca"`
+	// Keeps separate clusters from mixing up their members.
+	Token string `yaml:"token"`
+	// Remove this member from the cluster, and its data, on shutdown.
+	LeaveOnShutdown bool `yaml:"leave_on_shutdown"`
+}
+
+type Store struct {
+	// Either "embedded", the default, "external" or "memory".
+	Backend string `yaml:"backend"`
+	// Where the embedded etcd keeps its data.
+	DataDir string `yaml:"data_dir"`
+	// The etcd endpoints of an external store.
+	Endpoints   []string      `yaml:"endpoints"`
+	DialTimeout time.Duration `yaml:"dial_timeout"`
+	// Only used for external stores.
+	TLS *TLS `yaml:"tls"`
+	// Only used for the embedded store.
+	Cluster Cluster `yaml:"cluster"`
+}
+
+type Http struct {
+	Address string `yaml:"address"`
+}
+
+type Grpc struct {
+	Address string `yaml:"address"`
+	// Lets tools like grpcurl discover the api.
+	Reflection bool `yaml:"reflection"`
+}
+
+// The apis are served with tls, by certificates from the internal ca. Clients
+// have to present a certificate from the same ca.
+type Pki struct {
+	// Serves the apis without tls, anyone able to reach them can use them.
+	Insecure bool `yaml:"insecure"`
+	// Where the ca certificate and a certificate for admins are written.
+	Dir string `yaml:"dir"`
+	// Names and addresses the controller is reached on, besides the hostname
+	// and localhost.
+	Hosts []string `yaml:"hosts"`
+	// How long the certificates issued are valid.
+	CertificateLifetime time.Duration `yaml:"certificate_lifetime"`
+	// Holds the secret the key of the ca is sealed with in the store, shared by
+	// every controller of the cluster. Defaults to ca.secret in the dir, which
+	// the first controller creates.
+	SecretFile string `yaml:"secret_file"`
+}
+
+// Users are authenticated with the id tokens of an OpenID Connect provider,
+// presented as bearer tokens. Needs the apis to be served with tls.
+type Oidc struct {
+	// Where the provider is discovered, turned off if empty.
+	Issuer string `yaml:"issuer"`
+	// Tokens have to be issued to it.
+	ClientId string `yaml:"client_id"`
+	// Verifies the provider, the system roots are used if empty.
+	CA string `yaml:"ca"`
+	// The claim naming the user and the claim listing their groups.
+	UsernameClaim string `yaml:"username_claim"`
+	GroupsClaim   string `yaml:"groups_claim"`
+	// Put in front of the username and groups, keeps users from passing as
+	// nodes or admins.
+	Prefix string `yaml:"prefix"`
+}
+
+// Every create, patch and delete made through the apis is recorded.
+type Audit struct {
+	// Either "stdout", the default, "file", "resource" or "none".
+	Sink string `yaml:"sink"`
+	// Where the file sink writes. The file is rotated once it grows past
+	// MaxSize megabytes, MaxBackups of the rotated files are kept.
+	Path       string `yaml:"path"`
+	MaxSize    int    `yaml:"max_size"`
+	MaxBackups int    `yaml:"max_backups"`
+	// How long the resource sink keeps events.
+	Retention time.Duration `yaml:"retention"`
+	// Records the status patches made by nodes as well, which are frequent
+	// enough to drown out everything else.
+	NodeStatus bool `yaml:"node_status"`
+}
+
+// Background loops of the controller which can be turned off.
+type Features struct {
+	Scheduler         bool `yaml:"scheduler"`
+	Failover          bool `yaml:"failover"`
+	GarbageCollection bool `yaml:"garbage_collection"`
+	NodeMonitor       bool `yaml:"node_monitor"`
+}
+
+type Config struct {
+	Http     Http     `yaml:"http"`
+	Grpc     Grpc     `yaml:"grpc"`
+	Store    Store    `yaml:"store"`
+	Pki      Pki      `yaml:"pki"`
+	Oidc     Oidc     `yaml:"oidc"`
+	Audit    Audit    `yaml:"audit"`
+	Features Features `yaml:"features"`
+
+	// How long a node has to be not ready before its machines are failed over.
+	FailoverGracePeriod time.Duration `yaml:"failover_grace_period"`
+	// How long events are kept after they were last seen.
+	EventTTL time.Duration `yaml:"event_ttl"`
+	// How many vcpus the scheduler hands out for every thread of a node.
+	CpuOvercommit uint64 `yaml:"cpu_overcommit"`
+}
+
+func Default() *Config {
+	c := new(Config)
+
+	c.Http.Address = "0.0.0.0:8080"
+
+	c.Grpc.Address = "0.0.0.0:9090"
+	c.Grpc.Reflection = true
+
+	c.Store.Backend = StoreBackendEmbedded
+	c.Store.DataDir = "salmon_vm.etcd"
+	c.Store.Endpoints = []string{"localhost:2379"}
+	c.Store.DialTimeout = 5 * time.Second
+
+	c.Store.Cluster.Name = "default"
+	c.Store.Cluster.PeerUrl = "http://localhost:2380"
+	c.Store.Cluster.ClientUrl = "http://localhost:2379"
+	c.Store.Cluster.Token = "rockferry"
+
+	c.Pki.Dir = "rockferry-pki"
+	c.Pki.CertificateLifetime = 365 * 24 * time.Hour
+
+	c.Oidc.UsernameClaim = "sub"
+	c.Oidc.GroupsClaim = "groups"
+	c.Oidc.Prefix = "oidc:"
+
+	c.Audit.Sink = AuditSinkStdout
+	c.Audit.Path = "audit.log"
+	c.Audit.MaxSize = 100
+	c.Audit.MaxBackups = 5
+	c.Audit.Retention = 30 * 24 * time.Hour
+
+	c.Features.Scheduler = true
+	c.Features.Failover = true
+	c.Features.GarbageCollection = true
+	c.Features.NodeMonitor = true
+
+	c.FailoverGracePeriod = 2 * time.Minute
+	c.EventTTL = time.Hour
+	c.CpuOvercommit = 4
+
+	return c
+}
+
+// A setting which can be given both as a flag and an environment variable.
+type setting struct {
+	flag  string
+	env   string
+	usage string
+	apply func(c *Config, value string) error
+}
+
+func ensureTLS(c *Config) *TLS {
+	if c.Store.TLS == nil {
+		c.Store.TLS = new(TLS)
+	}
+
+	return c.Store.TLS
+}
+
+var settings = []setting{
+	{"http-address", "ROCKFERRY_HTTP_ADDRESS", "address the http api listens on", func(c *Config, value string) error {
+		c.Http.Address = value
+		return nil
+	}},
+	{"grpc-address", "ROCKFERRY_GRPC_ADDRESS", "address the grpc api listens on", func(c *Config, value string) error {
+		c.Grpc.Address = value
+		return nil
+	}},
+	{"store", "ROCKFERRY_STORE", "store backend, one of embedded, external or memory", func(c *Config, value string) error {
+		c.Store.Backend = value
+		return nil
+	}},
+	{"data-dir", "ROCKFERRY_DATA_DIR", "where the embedded etcd keeps its data", func(c *Config, value string) error {
+		c.Store.DataDir = value
+		return nil
+	}},
+	{"name", "ROCKFERRY_NAME", "name of this controller within the cluster", func(c *Config, value string) error {
+		c.Store.Cluster.Name = value
+		return nil
+	}},
+	{"peer-url", "ROCKFERRY_PEER_URL", "url other controllers reach the embedded etcd on", func(c *Config, value string) error {
+		c.Store.Cluster.PeerUrl = value
+		return nil
+	}},
+	{"client-url", "ROCKFERRY_CLIENT_URL", "url clients reach the embedded etcd on", func(c *Config, value string) error {
+		c.Store.Cluster.ClientUrl = value
+		return nil
+	}},
+	{"join", "ROCKFERRY_JOIN", "grpc address of a controller in the cluster to join", func(c *Config, value string) error {
+		c.Store.Cluster.Join = value
+		return nil
+	}},
+	{"join-token", "ROCKFERRY_JOIN_TOKEN", "token joining controllers have to present", func(c *Config, value string) error {
+		c.Store.Cluster.JoinToken = value
+		return nil
+	}},
+	{"join-ca", "ROCKFERRY_JOIN_CA", "ca certificate of the cluster to join", func(c *Config, value string) error {
+		c.Store.Cluster.JoinCA = value
+		return nil
+	}},
+	{"insecure", "ROCKFERRY_INSECURE", "serve the apis without tls when true", func(c *Config, value string) error {
+		insecure, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+
+		c.Pki.Insecure = insecure
+		return nil
+	}},
+	{"pki-dir", "ROCKFERRY_PKI_DIR", "where the ca and admin certificates are written", func(c *Config, value string) error {
+		c.Pki.Dir = value
+		return nil
+	}},
+	{"pki-secret-file", "ROCKFERRY_PKI_SECRET_FILE", "file holding the secret the ca key is sealed with", func(c *Config, value string) error {
+		c.Pki.SecretFile = value
+		return nil
+	}},
+	{"hosts", "ROCKFERRY_HOSTS", "comma separated names and addresses the controller is reached on", func(c *Config, value string) error {
+		c.Pki.Hosts = strings.Split(value, ",")
+		return nil
+	}},
+	{"oidc-issuer", "ROCKFERRY_OIDC_ISSUER", "openid connect provider users are authenticated by", func(c *Config, value string) error {
+		c.Oidc.Issuer = value
+		return nil
+	}},
+	{"oidc-client-id", "ROCKFERRY_OIDC_CLIENT_ID", "client id the id tokens have to be issued to", func(c *Config, value string) error {
+		c.Oidc.ClientId = value
+		return nil
+	}},
+	{"audit-sink", "ROCKFERRY_AUDIT_SINK", "where audit events go, one of stdout, file, resource or none", func(c *Config, value string) error {
+		c.Audit.Sink = value
+		return nil
+	}},
+	{"audit-path", "ROCKFERRY_AUDIT_PATH", "file the file audit sink writes to", func(c *Config, value string) error {
+		c.Audit.Path = value
+		return nil
+	}},
+	{"cpu-overcommit", "ROCKFERRY_CPU_OVERCOMMIT", "vcpus handed out for every thread of a node", func(c *Config, value string) error {
+		overcommit, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			return err
+		}
+
+		c.CpuOvercommit = overcommit
+		return nil
+	}},
+	{"etcd-endpoints", "ROCKFERRY_ETCD_ENDPOINTS", "comma separated endpoints of an external etcd", func(c *Config, value string) error {
+		c.Store.Endpoints = strings.Split(value, ",")
+		return nil
+	}},
+	{"etcd-ca", "ROCKFERRY_ETCD_CA", "ca certificate of an external etcd", func(c *Config, value string) error {
+		ensureTLS(c).CA = value
+		return nil
+	}},
+	{"etcd-cert", "ROCKFERRY_ETCD_CERT", "client certificate for an external etcd", func(c *Config, value string) error {
+		ensureTLS(c).Cert = value
+		return nil
+	}},
+	{"etcd-key", "ROCKFERRY_ETCD_KEY", "client key for an external etcd", func(c *Config, value string) error {
+		ensureTLS(c).Key = value
+		return nil
+	}},
+}
+
+// Load builds the config from the defaults, the config file given by -config or
+// ROCKFERRY_CONFIG, the environment and args.
+func Load(args []string) (*Config, error) {
+	flags := flag.NewFlagSet("controller", flag.ExitOnError)
+
+	path := flags.String("config", os.Getenv("ROCKFERRY_CONFIG"), "path to a yaml config file")
+
+	values := map[string]*string{}
+	for _, s := range settings {
+		values[s.flag] = flags.String(s.flag, "", fmt.Sprintf("%s (env %s)", s.usage, s.env))
+	}
+
+	if err := flags.Parse(args); err != nil {
+		return nil, err
+	}
+
+	c := Default()
+
+	if *path != "" {
+		contents, err := os.ReadFile(*path)
+		if err != nil {
+			return nil, err
+		}
+
+		// NOTE: Anything left out of the file keeps its default.
+		if err := yaml.UnmarshalStrict(contents, c); err != nil {
+			return nil, fmt.Errorf("%w: %s: %v", ErrInvalidConfig, *path, err)
+		}
+	}
+
+	for _, s := range settings {
+		if value, ok := os.LookupEnv(s.env); ok {
+			if err := s.apply(c, value); err != nil {
+				return nil, fmt.Errorf("%w: %s: %v", ErrInvalidConfig, s.env, err)
+			}
+		}
+	}
+
+	var err error
+	flags.Visit(func(f *flag.Flag) {
+		for _, s := range settings {
+			if s.flag == f.Name && err == nil {
+				if applyErr := s.apply(c, *values[s.flag]); applyErr != nil {
+					err = fmt.Errorf("%w: -%s: %v", ErrInvalidConfig, s.flag, applyErr)
+				}
+			}
+		}
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+func (c *Config) Validate() error {
+	switch c.Store.Backend {
+	case StoreBackendEmbedded:
+		if c.Store.DataDir == "" {
+			return fmt.Errorf("%w: the embedded store needs a data dir", ErrInvalidConfig)
+		}
+
+		if err := c.Store.Cluster.validate(); err != nil {
+			return err
+		}
+
+		if c.Store.Cluster.Join != "" && !c.Pki.Insecure && c.Store.Cluster.JoinCA == "" {
+			return fmt.Errorf("%w: joining a cluster needs its ca certificate", ErrInvalidConfig)
+		}
+	case StoreBackendExternal:
+		if len(c.Store.Endpoints) == 0 {
+			return fmt.Errorf("%w: the external store needs at least one endpoint", ErrInvalidConfig)
+		}
+	case StoreBackendMemory:
+	default:
+		return fmt.Errorf("%w: unknown store backend %q", ErrInvalidConfig, c.Store.Backend)
+	}
+
+	if tls := c.Store.TLS; tls != nil && (tls.Cert == "") != (tls.Key == "") {
+		return fmt.Errorf("%w: the etcd client certificate and key must be given together", ErrInvalidConfig)
+	}
+
+	if !c.Pki.Insecure && c.Pki.Dir == "" {
+		return fmt.Errorf("%w: the pki needs a dir", ErrInvalidConfig)
+	}
+
+	if c.Oidc.Issuer != "" {
+		if c.Pki.Insecure {
+			return fmt.Errorf("%w: openid connect needs the apis to be served with tls", ErrInvalidConfig)
+		}
+
+		if c.Oidc.ClientId == "" || c.Oidc.UsernameClaim == "" {
+			return fmt.Errorf("%w: openid connect needs a client id and username claim", ErrInvalidConfig)
+		}
+
+		// NOTE: Otherwise the provider could name its users and groups after
+		// the admins and nodes of the internal ca.
+		if c.Oidc.Prefix == "" || strings.HasPrefix(c.Oidc.Prefix, reservedPrefix) {
+			return fmt.Errorf("%w: the openid connect prefix can not be empty or start with %s", ErrInvalidConfig, reservedPrefix)
+		}
+	}
+
+	switch c.Audit.Sink {
+	case AuditSinkStdout, AuditSinkNone:
+	case AuditSinkFile:
+		if c.Audit.Path == "" || c.Audit.MaxSize <= 0 || c.Audit.MaxBackups < 0 {
+			return fmt.Errorf("%w: the file audit sink needs a path and a max size", ErrInvalidConfig)
+		}
+	case AuditSinkResource:
+		if c.Audit.Retention <= 0 {
+			return fmt.Errorf("%w: the resource audit sink needs a retention", ErrInvalidConfig)
+		}
+	default:
+		return fmt.Errorf("%w: unknown audit sink %q", ErrInvalidConfig, c.Audit.Sink)
+	}
+
+	if c.EventTTL <= 0 {
+		return fmt.Errorf("%w: the event ttl must be positive", ErrInvalidConfig)
+	}
+
+	if c.CpuOvercommit == 0 {
+		return fmt.Errorf("%w: the cpu overcommit must be at least one", ErrInvalidConfig)
+	}
+
+	if c.Http.Address == "" || c.Grpc.Address == "" {
+		return fmt.Errorf("%w: listen addresses can not be empty", ErrInvalidConfig)
+	}
+
+	return nil
+}
+
+func (c *Cluster) validate() error {
+	if c.Name == "" || c.PeerUrl == "" || c.ClientUrl == "" {
+		return fmt.Errorf("%w: a cluster member needs a name, peer url and client url", ErrInvalidConfig)
+	}
+
+	if c.Join != "" && len(c.InitialCluster) > 0 {
+		return fmt.Errorf("%w: a member either forms a new cluster or joins an existing one", ErrInvalidConfig)
+	}
+
+	if len(c.InitialCluster) > 0 && c.InitialCluster[c.Name] == "" {
+		return fmt.Errorf("%w: the initial cluster does not contain %s", ErrInvalidConfig, c.Name)
+	}
+
+	return nil
 }
@@ -0,0 +1,72 @@
+package config
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestLoadInsecure(t *testing.T) {
+	tests := []struct {
+		name     string
+		env      string
+		args     []string
+		insecure bool
+		valid    bool
+	}{
+		{"default", "", nil, false, true},
+		{"env", "true", nil, true, true},
+		{"flag", "", []string{"-insecure", "1"}, true, true},
+		{"flag over env", "true", []string{"-insecure", "false"}, false, true},
+		{"malformed env", "yes please", nil, false, false},
+		{"malformed flag", "", []string{"-insecure", "maybe"}, false, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if test.env != "" {
+				t.Setenv("ROCKFERRY_INSECURE", test.env)
+			}
+
+			c, err := Load(append([]string{"-store", "memory"}, test.args...))
+			if !test.valid {
+				if !errors.Is(err, ErrInvalidConfig) {
+					t.Fatalf("loading gave %v, want %v", err, ErrInvalidConfig)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if c.Pki.Insecure != test.insecure {
+				t.Fatalf("insecure is %v, want %v", c.Pki.Insecure, test.insecure)
+			}
+		})
+	}
+}
+
+func TestValidateOidcPrefix(t *testing.T) {
+	tests := []struct {
+		prefix string
+		valid  bool
+	}{
+		{"oidc:", true},
+		{"", false},
+		{"rockferry:", false},
+		{"rockferry:oidc:", false},
+	}
+
+	for _, test := range tests {
+		c := Default()
+		c.Store.Backend = StoreBackendMemory
+		c.Oidc.Issuer = "https://accounts.example.com"
+		c.Oidc.ClientId = "rockferry"
+		c.Oidc.Prefix = test.prefix
+
+		if err := c.Validate(); (err == nil) != test.valid {
+			t.Fatalf("validating prefix %q gave %v", test.prefix, err)
+		}
+	}
+}
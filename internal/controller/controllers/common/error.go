@@ -1,6 +1,10 @@
 package common
 
-import "net/http"
+import (
+	"net/http"
+
+	"github.com/eskpil/rockferry/internal/controller/validation"
+)
 
 type Error struct {
 	Code    int    `json:"code"`
@@ -14,6 +18,28 @@ func MalformedInput() Error {
 	}
 }
 
+func BadRequest(message string) Error {
+	return Error{
+		Code:    http.StatusBadRequest,
+		Message: message,
+	}
+}
+
+type ValidationError struct {
+	Error
+	Fields validation.Errors `json:"fields"`
+}
+
+func UnprocessableEntity(errs validation.Errors) ValidationError {
+	return ValidationError{
+		Error: Error{
+			Code:    http.StatusUnprocessableEntity,
+			Message: "resource failed validation",
+		},
+		Fields: errs,
+	}
+}
+
 func InternalServerError() Error {
 	return Error{
 		Code:    http.StatusInternalServerError,
@@ -27,3 +53,24 @@ func NotFound() Error {
 		Message: "resource not found",
 	}
 }
+
+func Conflict() Error {
+	return Error{
+		Code:    http.StatusConflict,
+		Message: "resource has been modified",
+	}
+}
+
+func Unauthorized(message string) Error {
+	return Error{
+		Code:    http.StatusUnauthorized,
+		Message: message,
+	}
+}
+
+func Forbidden(message string) Error {
+	return Error{
+		Code:    http.StatusForbidden,
+		Message: message,
+	}
+}
@@ -6,8 +6,11 @@ import (
 	"net/http"
 	"time"
 
+	"github.com/eskpil/rockferry/internal/controller/audit"
 	"github.com/eskpil/rockferry/internal/controller/controllers/common"
+	"github.com/eskpil/rockferry/internal/controller/rbac"
 	"github.com/eskpil/rockferry/internal/controller/runtime"
+	"github.com/eskpil/rockferry/internal/controller/validation"
 	"github.com/eskpil/rockferry/pkg/rockferry"
 	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
@@ -22,6 +25,8 @@ type CreateResourceInput struct {
 
 type res struct {
 	Ok bool `json:"ok"`
+	// Only set for creations.
+	Id string `json:"id,omitempty"`
 }
 
 func Create() echo.HandlerFunc {
@@ -45,15 +50,37 @@ func Create() echo.HandlerFunc {
 		resource.Annotations = input.Annotations
 		resource.Spec = input.Spec
 
+		rbac.SetCreator(ctx, resource)
+
 		r := runtime.ExtractRuntime(c)
 
-		if err := r.CreateResource(ctx, resource); err != nil {
+		err := rbac.ExtractAuthorizer(c).Authorize(ctx, rockferry.VerbCreate, resource)
+		if err == nil {
+			err = r.CreateResource(ctx, resource)
+		}
+
+		if err != nil {
+			if errs, ok := err.(validation.Errors); ok {
+				return c.JSON(http.StatusUnprocessableEntity, common.UnprocessableEntity(errs))
+			}
+
+			if err == rockferry.ErrorForbidden {
+				return c.JSON(http.StatusForbidden, common.Forbidden("not allowed to create "+resource.Kind))
+			}
+
+			if err == rockferry.ErrorAlreadyExists {
+				return c.JSON(http.StatusConflict, common.Conflict())
+			}
+
 			fmt.Println(err)
 			return c.JSON(http.StatusInternalServerError, common.InternalServerError())
 		}
 
+		c.Set(audit.ResourceIdKey, resource.Id)
+
 		response := new(res)
 		response.Ok = true
+		response.Id = resource.Id
 
 		return c.JSON(http.StatusCreated, response)
 	}
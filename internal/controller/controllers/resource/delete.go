@@ -7,8 +7,9 @@ import (
 	"time"
 
 	"github.com/eskpil/rockferry/internal/controller/controllers/common"
-	"github.com/eskpil/rockferry/internal/controller/db"
-	"github.com/eskpil/rockferry/internal/controller/models"
+	"github.com/eskpil/rockferry/internal/controller/rbac"
+	"github.com/eskpil/rockferry/internal/controller/runtime"
+	"github.com/eskpil/rockferry/pkg/rockferry"
 	"github.com/labstack/echo/v4"
 )
 
@@ -28,12 +29,26 @@ func Delete() echo.HandlerFunc {
 			return c.JSON(http.StatusBadRequest, common.MalformedInput())
 		}
 
-		db := db.Extract(c)
+		r := runtime.ExtractRuntime(c)
 
-		path := fmt.Sprintf("%s/%s/%s", models.RootKey, req.Kind, req.Id)
+		err := rbac.ExtractAuthorizer(c).AuthorizeStored(ctx, rockferry.VerbDelete, req.Kind, req.Id)
+		if err == nil {
+			err = r.Delete(ctx, req.Kind, req.Id)
+		}
 
-		_, err := db.Delete(ctx, path)
 		if err != nil {
+			if err == rockferry.ErrorNotFound {
+				return c.JSON(http.StatusNotFound, common.NotFound())
+			}
+
+			if err == rockferry.ErrorForbidden {
+				return c.JSON(http.StatusForbidden, common.Forbidden("not allowed to delete "+req.Kind+" "+req.Id))
+			}
+
+			if err == rockferry.ErrorConflict {
+				return c.JSON(http.StatusConflict, common.Conflict())
+			}
+
 			fmt.Println("failed to delete resource", err)
 			return c.JSON(http.StatusInternalServerError, common.InternalServerError())
 		}
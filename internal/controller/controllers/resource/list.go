@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/eskpil/rockferry/internal/controller/controllers/common"
+	"github.com/eskpil/rockferry/internal/controller/rbac"
 	"github.com/eskpil/rockferry/internal/controller/runtime"
 	"github.com/eskpil/rockferry/pkg/rockferry"
 	"github.com/labstack/echo/v4"
@@ -17,6 +18,9 @@ type ListFilter struct {
 
 	OwnerKind string `query:"owner_kind"`
 	OwnerId   string `query:"owner_id"`
+
+	LabelSelector string `query:"label_selector"`
+	FieldSelector string `query:"field_selector"`
 }
 
 func List() echo.HandlerFunc {
@@ -29,8 +33,22 @@ func List() echo.HandlerFunc {
 			return c.JSON(http.StatusBadRequest, common.MalformedInput())
 		}
 
+		labels, fields, err := parseSelectors(filter.LabelSelector, filter.FieldSelector)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, common.BadRequest(err.Error()))
+		}
+
 		r := runtime.ExtractRuntime(c)
 
+		permissions, err := rbac.ExtractAuthorizer(c).For(ctx)
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, common.InternalServerError())
+		}
+
+		if !permissions.Can(rockferry.VerbList, filter.Kind) {
+			return c.JSON(http.StatusForbidden, common.Forbidden("not allowed to list "+filter.Kind))
+		}
+
 		var owner *rockferry.OwnerRef
 		if filter.OwnerKind != "" && filter.OwnerId != "" {
 			owner = new(rockferry.OwnerRef)
@@ -38,14 +56,45 @@ func List() echo.HandlerFunc {
 			owner.Id = filter.OwnerId
 		}
 
-		resources, err := r.List(ctx, filter.Kind, filter.Id, owner, nil)
+		resources, err := r.List(ctx, filter.Kind, filter.Id, owner, labels, fields)
 		if err != nil && err != rockferry.ErrorNotFound {
 			return c.JSON(http.StatusInternalServerError, common.InternalServerError())
 		}
 
 		list := new(common.ListResponse[rockferry.Generic])
-		list.List = resources
+
+		// NOTE: Lists only contain what the caller may see, asking for something else is denied.
+		for _, resource := range resources {
+			allowed, err := permissions.Allowed(ctx, rockferry.VerbList, resource)
+			if err != nil {
+				return c.JSON(http.StatusInternalServerError, common.InternalServerError())
+			}
+
+			if !allowed {
+				if filter.Id != "" {
+					return c.JSON(http.StatusForbidden, common.Forbidden("not allowed to list "+filter.Kind+" "+filter.Id))
+				}
+
+				continue
+			}
+
+			list.List = append(list.List, resource)
+		}
 
 		return c.JSON(http.StatusOK, list)
 	}
 }
+
+func parseSelectors(labelSelector string, fieldSelector string) (rockferry.Selector, rockferry.Selector, error) {
+	labels, err := rockferry.ParseSelector(labelSelector)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	fields, err := rockferry.ParseFieldSelector(fieldSelector)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return labels, fields, nil
+}
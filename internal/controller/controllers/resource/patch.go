@@ -7,7 +7,9 @@ import (
 	"time"
 
 	"github.com/eskpil/rockferry/internal/controller/controllers/common"
+	"github.com/eskpil/rockferry/internal/controller/rbac"
 	"github.com/eskpil/rockferry/internal/controller/runtime"
+	"github.com/eskpil/rockferry/internal/controller/validation"
 	"github.com/eskpil/rockferry/pkg/rockferry"
 	jsonpatch "github.com/evanphx/json-patch/v5"
 	"github.com/labstack/echo/v4"
@@ -17,9 +19,23 @@ type PatchResourceInput struct {
 	Kind    rockferry.ResourceKind `json:"kind"`
 	Id      string                 `json:"id"`
 	Patches jsonpatch.Patch        `json:"patches"`
+
+	// Optional, when set the patch is only applied to this version.
+	ResourceVersion int64 `json:"resource_version"`
 }
 
+type patchFunc func(*runtime.Runtime, context.Context, rockferry.ResourceKind, string, jsonpatch.Patch, int64, runtime.PatchCheck) (int64, int64, error)
+
 func Patch() echo.HandlerFunc {
+	return patch((*runtime.Runtime).Patch)
+}
+
+// PatchStatus only accepts patches to the status and phase of a resource.
+func PatchStatus() echo.HandlerFunc {
+	return patch((*runtime.Runtime).PatchStatus)
+}
+
+func patch(apply patchFunc) echo.HandlerFunc {
 	return func(c echo.Context) error {
 		ctx, cancel := context.WithTimeout(c.Request().Context(), 10*time.Second)
 		defer cancel()
@@ -36,16 +52,39 @@ func Patch() echo.HandlerFunc {
 
 		r := runtime.ExtractRuntime(c)
 
-		if err := r.Patch(ctx, input.Kind, input.Id, input.Patches); err != nil {
+		// NOTE: Authorized against the resource as it is before and after the patch.
+		_, generation, err := apply(r, ctx, input.Kind, input.Id, input.Patches, input.ResourceVersion, rbac.ExtractAuthorizer(c).AuthorizePatch)
+		if err != nil {
 			if err == rockferry.ErrorNotFound {
 				return c.JSON(http.StatusNotFound, common.NotFound())
 			}
 
+			if err == rockferry.ErrorForbidden {
+				return c.JSON(http.StatusForbidden, common.Forbidden("not allowed to patch "+input.Kind+" "+input.Id))
+			}
+
+			if err == rockferry.ErrorConflict {
+				return c.JSON(http.StatusConflict, common.Conflict())
+			}
+
+			if errs, ok := err.(validation.Errors); ok {
+				return c.JSON(http.StatusUnprocessableEntity, common.UnprocessableEntity(errs))
+			}
+
+			if err == rockferry.ErrorStatusPatch || err == rockferry.ErrorSpecPatch || err == rockferry.ErrorDeletionTimestamp || err == rockferry.ErrorCreatorAnnotation {
+				return c.JSON(http.StatusBadRequest, common.BadRequest(err.Error()))
+			}
+
 			return c.JSON(http.StatusBadRequest, common.InternalServerError())
 		}
 
-		response := new(struct{ Ok bool })
+		response := new(struct {
+			Ok bool
+			// Lets the caller wait for the reconciler to act on the patch.
+			Generation int64
+		})
 		response.Ok = true
+		response.Generation = generation
 
 		return c.JSON(http.StatusCreated, response)
 	}
@@ -2,9 +2,11 @@ package resource
 
 import (
 	"encoding/json"
+	"fmt"
 	"net/http"
 
 	"github.com/eskpil/rockferry/internal/controller/controllers/common"
+	"github.com/eskpil/rockferry/internal/controller/rbac"
 	"github.com/eskpil/rockferry/internal/controller/runtime"
 	"github.com/eskpil/rockferry/pkg/rockferry"
 	"github.com/labstack/echo/v4"
@@ -28,6 +30,9 @@ type WatchFilter struct {
 
 	OwnerKind string `query:"owner_kind"`
 	OwnerId   string `query:"owner_id"`
+
+	LabelSelector string `query:"label_selector"`
+	FieldSelector string `query:"field_selector"`
 }
 
 func Watch() echo.HandlerFunc {
@@ -37,9 +42,32 @@ func Watch() echo.HandlerFunc {
 			return c.JSON(http.StatusBadRequest, common.MalformedInput())
 		}
 
+		labels, fields, err := parseSelectors(filter.LabelSelector, filter.FieldSelector)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, common.BadRequest(err.Error()))
+		}
+
 		r := runtime.ExtractRuntime(c)
 
-		stream, canceled, err := r.Watch(c.Request().Context(), filter.Action, filter.Kind, filter.Id, nil)
+		permissions, err := rbac.ExtractAuthorizer(c).For(c.Request().Context())
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, common.InternalServerError())
+		}
+
+		if !permissions.Can(rockferry.VerbWatch, filter.Kind) {
+			return c.JSON(http.StatusForbidden, common.Forbidden("not allowed to watch "+filter.Kind))
+		}
+
+		var owner *rockferry.OwnerRef
+		if filter.OwnerKind != "" && filter.OwnerId != "" {
+			owner = new(rockferry.OwnerRef)
+			owner.Kind = filter.OwnerKind
+			owner.Id = filter.OwnerId
+		}
+
+		options := runtime.WatchOptions{Labels: labels, Fields: fields}
+
+		stream, canceled, err := r.Watch(c.Request().Context(), filter.Action, filter.Kind, filter.Id, owner, options)
 		if err != nil {
 			return err
 		}
@@ -53,6 +81,22 @@ func Watch() echo.HandlerFunc {
 				case <-canceled:
 					return
 				case e := <-stream:
+					permissions.Forget()
+
+					allowed, err := permissions.Allowed(c.Request().Context(), rockferry.VerbWatch, e.Resource)
+					if err == nil && !allowed && e.Prev != nil {
+						allowed, err = permissions.Allowed(c.Request().Context(), rockferry.VerbWatch, e.Prev)
+					}
+
+					if err != nil {
+						fmt.Println("failed to authorize event", err)
+						return
+					}
+
+					if !allowed {
+						continue
+					}
+
 					response, err := json.Marshal(e)
 					if err != nil {
 						panic(err)
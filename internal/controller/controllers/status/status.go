@@ -0,0 +1,28 @@
+package status
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/eskpil/rockferry/internal/controller/controllers/common"
+	"github.com/eskpil/rockferry/internal/controller/runtime"
+	"github.com/labstack/echo/v4"
+)
+
+// Leader reports which controller runs the background loops.
+func Leader() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		ctx, cancel := context.WithTimeout(c.Request().Context(), 10*time.Second)
+		defer cancel()
+
+		r := runtime.ExtractRuntime(c)
+
+		status, err := r.Leader(ctx)
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, common.InternalServerError())
+		}
+
+		return c.JSON(http.StatusOK, status)
+	}
+}
@@ -2,23 +2,21 @@ package controller
 
 import (
 	"context"
-	"fmt"
-	"time"
 
 	"github.com/eskpil/rockferry/internal/controller/models"
+	"github.com/eskpil/rockferry/internal/controller/store"
 	"github.com/eskpil/rockferry/pkg/rockferry"
-	clientv3 "go.etcd.io/etcd/client/v3"
 )
 
-func ensureInstance(ctx context.Context, db *clientv3.Client) error {
-	path := fmt.Sprintf("%s/%s/%s", models.RootKey, rockferry.ResourceKindInstance, "self")
+func ensureInstance(ctx context.Context, s store.Store) error {
+	path := models.ResourceKey(rockferry.ResourceKindInstance, "self")
 
-	results, err := db.Get(ctx, path, clientv3.WithLimit(1))
+	kv, _, err := s.Get(ctx, path)
 	if err != nil {
 		return err
 	}
 
-	if results.Count == 0 {
+	if kv == nil {
 		// NOTE: This is only expected to happen the first time the rockferry instance
 		// 		 is spun up.
 
@@ -38,26 +36,13 @@ func ensureInstance(ctx context.Context, db *clientv3.Client) error {
 			return err
 		}
 
-		_, err = db.Put(ctx, path, string(bytes))
+		_, err = s.Put(ctx, path, bytes)
 		return err
 	}
 
 	return nil
 }
 
-func Initialize() error {
-	ctx := context.Background()
-
-	// TODO: Enable some kind of config
-	// TODO: Avoid multiple db connections
-	cli, err := clientv3.New(clientv3.Config{
-		Endpoints:   []string{"localhost:2379"},
-		DialTimeout: 5 * time.Second,
-	})
-
-	if err != nil {
-		return err
-	}
-
-	return ensureInstance(ctx, cli)
+func Initialize(s store.Store) error {
+	return ensureInstance(context.Background(), s)
 }
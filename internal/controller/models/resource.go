@@ -1,3 +1,52 @@
 package models
 
+import (
+	"fmt"
+	"net/url"
+
+	"github.com/eskpil/rockferry/pkg/rockferry"
+)
+
 const RootKey = "rockferry"
+
+// Leases live outside of RootKey, they are not resources.
+const LeaseKey = "rockferry-leases"
+
+// Held by the controller running the background loops.
+const LeaderKey = "rockferry-leader"
+
+// The certificate authority shared by the controllers, see package pki.
+const CAKey = "rockferry-pki/ca"
+
+// Indexes audit events by the resource they are about, see package audit.
+const AuditIndexKey = "rockferry-audit"
+
+// The prefix the audit events about a resource are indexed under, those about
+// every resource of kind if id is empty.
+func AuditIndexPrefix(kind string, id string) string {
+	if id == "" {
+		return fmt.Sprintf("%s/%s/", AuditIndexKey, kind)
+	}
+
+	// NOTE: Escaped, ids containing slashes would otherwise match those of
+	// other resources.
+	return fmt.Sprintf("%s/%s/%s/", AuditIndexKey, kind, url.PathEscape(id))
+}
+
+func ResourceKey(kind rockferry.ResourceKind, id string) string {
+	return fmt.Sprintf("%s/%s/%s", RootKey, kind, id)
+}
+
+// The prefix every resource of kind is stored under. The trailing slash keeps
+// kinds sharing a prefix, like machine and machinerequest, apart.
+func KindPrefix(kind rockferry.ResourceKind) string {
+	if kind == rockferry.ResourceKindAll {
+		return fmt.Sprintf("%s/", RootKey)
+	}
+
+	return fmt.Sprintf("%s/%s/", RootKey, kind)
+}
+
+func NodeLeaseKey(id string) string {
+	return fmt.Sprintf("%s/%s/%s", LeaseKey, rockferry.ResourceKindNode, id)
+}
@@ -0,0 +1,425 @@
+// Package pki is the certificate authority of the controllers. It is kept in
+// the store, so every controller of a cluster issues certificates from the same
+// ca and trusts the certificates of the others. The key of the ca is sealed with
+// a secret shared by the controllers, the store never sees it in the clear.
+package pki
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/eskpil/rockferry/internal/controller/models"
+	"github.com/eskpil/rockferry/internal/controller/store"
+)
+
+const (
+	// Node agents, the common name is the id of the node.
+	GroupNodes = "rockferry:nodes"
+	// Allowed to do everything.
+	GroupAdmins = "rockferry:admins"
+)
+
+const (
+	caLifetime = 10 * 365 * 24 * time.Hour
+	// Leaves room for clocks which are a bit behind.
+	clockSkew = 5 * time.Minute
+)
+
+var (
+	ErrInvalidRequest = errors.New("invalid certificate request")
+	// The secret differs from the one the ca in the store was sealed with.
+	ErrWrongSecret = errors.New("the ca key can not be unsealed with the pki secret")
+)
+
+// CA issues the certificates of controllers, node agents and admins.
+type CA struct {
+	Cert *x509.Certificate
+	// Pem encoded Cert, handed out to clients.
+	CertPEM []byte
+
+	key *ecdsa.PrivateKey
+}
+
+// How the ca is kept in the store.
+type stored struct {
+	Cert []byte `json:"cert"`
+	// The pem encoded key sealed with the secret, prefixed by the nonce.
+	SealedKey []byte `json:"sealed_key"`
+}
+
+func aead(secret []byte) (cipher.AEAD, error) {
+	if len(secret) == 0 {
+		return nil, errors.New("the pki secret is empty")
+	}
+
+	sum := sha256.Sum256(secret)
+
+	block, err := aes.NewCipher(sum[:])
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
+
+// The certificate is authenticated along with the key, a sealed key can not be
+// paired with another certificate.
+func seal(secret []byte, cert []byte, key []byte) ([]byte, error) {
+	gcm, err := aead(secret)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+
+	return gcm.Seal(nonce, nonce, key, cert), nil
+}
+
+func unseal(secret []byte, s *stored) ([]byte, error) {
+	gcm, err := aead(secret)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(s.SealedKey) < gcm.NonceSize() {
+		return nil, ErrWrongSecret
+	}
+
+	nonce, sealed := s.SealedKey[:gcm.NonceSize()], s.SealedKey[gcm.NonceSize():]
+
+	key, err := gcm.Open(nil, nonce, sealed, s.Cert)
+	if err != nil {
+		return nil, ErrWrongSecret
+	}
+
+	return key, nil
+}
+
+func encodeKey(key *ecdsa.PrivateKey) ([]byte, error) {
+	der, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der}), nil
+}
+
+func encodeCert(der []byte) []byte {
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+}
+
+func serialNumber() (*big.Int, error) {
+	return rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+}
+
+func parse(certPEM []byte, keyPEM []byte) (*CA, error) {
+	block, _ := pem.Decode(certPEM)
+	if block == nil {
+		return nil, errors.New("no certificate found in the stored ca")
+	}
+
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+
+	block, _ = pem.Decode(keyPEM)
+	if block == nil {
+		return nil, errors.New("no key found in the stored ca")
+	}
+
+	key, err := x509.ParseECPrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+
+	ca := new(CA)
+	ca.Cert = cert
+	ca.CertPEM = certPEM
+	ca.key = key
+
+	return ca, nil
+}
+
+// Returns the pem encoded certificate and key.
+func generate() ([]byte, []byte, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	serial, err := serialNumber()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	now := time.Now()
+
+	template := new(x509.Certificate)
+	template.SerialNumber = serial
+	template.Subject = pkix.Name{CommonName: "rockferry ca"}
+	template.NotBefore = now.Add(-clockSkew)
+	template.NotAfter = now.Add(caLifetime)
+	template.KeyUsage = x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature
+	template.BasicConstraintsValid = true
+	template.IsCA = true
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	encoded, err := encodeKey(key)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return encodeCert(der), encoded, nil
+}
+
+// Load returns the ca kept in the store, the first controller to start creates
+// it. Its key is sealed with the secret, which has to be the same for every
+// controller of the cluster.
+func Load(ctx context.Context, s store.Store, secret []byte) (*CA, error) {
+	kv, _, err := s.Get(ctx, models.CAKey)
+	if err != nil {
+		return nil, err
+	}
+
+	if kv == nil {
+		certPEM, keyPEM, err := generate()
+		if err != nil {
+			return nil, err
+		}
+
+		generated := new(stored)
+		generated.Cert = certPEM
+		if generated.SealedKey, err = seal(secret, certPEM, keyPEM); err != nil {
+			return nil, err
+		}
+
+		bytes, err := json.Marshal(generated)
+		if err != nil {
+			return nil, err
+		}
+
+		// NOTE: Another controller may be starting at the same time, whichever
+		// comes first decides the ca.
+		created, _, err := s.Txn(ctx, []store.Condition{{Key: models.CAKey}}, store.OpPut(models.CAKey, bytes))
+		if err != nil {
+			return nil, err
+		}
+
+		if created {
+			return parse(certPEM, keyPEM)
+		}
+
+		if kv, _, err = s.Get(ctx, models.CAKey); err != nil {
+			return nil, err
+		}
+	}
+
+	existing := new(stored)
+	if err := json.Unmarshal(kv.Value, existing); err != nil {
+		return nil, err
+	}
+
+	keyPEM, err := unseal(secret, existing)
+	if err != nil {
+		return nil, err
+	}
+
+	return parse(existing.Cert, keyPEM)
+}
+
+// Pool trusts certificates issued by the ca.
+func (ca *CA) Pool() *x509.CertPool {
+	pool := x509.NewCertPool()
+	pool.AddCert(ca.Cert)
+	return pool
+}
+
+func (ca *CA) sign(template *x509.Certificate, public any, lifetime time.Duration) ([]byte, error) {
+	serial, err := serialNumber()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+
+	template.SerialNumber = serial
+	template.NotBefore = now.Add(-clockSkew)
+	template.NotAfter = now.Add(lifetime)
+	template.KeyUsage = x509.KeyUsageDigitalSignature
+
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.Cert, public, ca.key)
+	if err != nil {
+		return nil, err
+	}
+
+	return encodeCert(der), nil
+}
+
+func (ca *CA) keyPair(template *x509.Certificate, lifetime time.Duration) (*tls.Certificate, []byte, []byte, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	certPEM, err := ca.sign(template, &key.PublicKey, lifetime)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	keyPEM, err := encodeKey(key)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	cert, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	return &cert, certPEM, keyPEM, nil
+}
+
+// IssueServer creates a serving certificate for the names and addresses in hosts.
+func (ca *CA) IssueServer(hosts []string, lifetime time.Duration) (*tls.Certificate, error) {
+	template := new(x509.Certificate)
+	template.Subject = pkix.Name{CommonName: "rockferry controller"}
+	template.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth}
+
+	for _, host := range hosts {
+		if ip := net.ParseIP(host); ip != nil {
+			template.IPAddresses = append(template.IPAddresses, ip)
+		} else {
+			template.DNSNames = append(template.DNSNames, host)
+		}
+	}
+
+	cert, _, _, err := ca.keyPair(template, lifetime)
+	return cert, err
+}
+
+// IssueClient creates a client certificate and key, both pem encoded, for name
+// as a member of groups.
+func (ca *CA) IssueClient(name string, groups []string, lifetime time.Duration) ([]byte, []byte, error) {
+	template := new(x509.Certificate)
+	template.Subject = pkix.Name{CommonName: name, Organization: groups}
+	template.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth}
+
+	_, certPEM, keyPEM, err := ca.keyPair(template, lifetime)
+	return certPEM, keyPEM, err
+}
+
+// ParseRequest decodes a pem encoded certificate request and checks that it is
+// signed by the key it carries.
+func ParseRequest(csr []byte) (*x509.CertificateRequest, error) {
+	block, _ := pem.Decode(csr)
+	if block == nil || block.Type != "CERTIFICATE REQUEST" {
+		return nil, ErrInvalidRequest
+	}
+
+	request, err := x509.ParseCertificateRequest(block.Bytes)
+	if err != nil {
+		return nil, ErrInvalidRequest
+	}
+
+	// NOTE: Proves the caller holds the key it wants a certificate for.
+	if err := request.CheckSignature(); err != nil {
+		return nil, ErrInvalidRequest
+	}
+
+	return request, nil
+}
+
+// SignRequest issues a pem encoded client certificate for the key of request.
+// The name and groups are decided by the caller, not the request.
+func (ca *CA) SignRequest(request *x509.CertificateRequest, name string, groups []string, lifetime time.Duration) ([]byte, error) {
+	template := new(x509.Certificate)
+	template.Subject = pkix.Name{CommonName: name, Organization: groups}
+	template.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth}
+
+	return ca.sign(template, request.PublicKey, lifetime)
+}
+
+// LoadSecret reads the secret the ca key is sealed with. A missing secret is
+// created when create is set, otherwise it has to be copied from another
+// controller of the cluster.
+func LoadSecret(path string, create bool) ([]byte, error) {
+	secret, err := os.ReadFile(path)
+	if err == nil {
+		return bytes.TrimSpace(secret), nil
+	}
+
+	if !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	if !create {
+		return nil, fmt.Errorf("no pki secret found at %s, copy it from a controller in the cluster", path)
+	}
+
+	random := make([]byte, 32)
+	if _, err := rand.Read(random); err != nil {
+		return nil, err
+	}
+
+	secret = []byte(hex.EncodeToString(random))
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return nil, err
+	}
+
+	return secret, os.WriteFile(path, secret, 0o600)
+}
+
+// WriteAdmin writes the ca certificate and a fresh admin client certificate to
+// dir, for the cli and other tools to use.
+func (ca *CA) WriteAdmin(dir string, lifetime time.Duration) error {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return err
+	}
+
+	certPEM, keyPEM, err := ca.IssueClient("admin", []string{GroupAdmins}, lifetime)
+	if err != nil {
+		return err
+	}
+
+	files := map[string][]byte{
+		"ca.crt":    ca.CertPEM,
+		"admin.crt": certPEM,
+		"admin.key": keyPEM,
+	}
+
+	for name, contents := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), contents, 0o600); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,34 @@
+package pki
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/eskpil/rockferry/internal/controller/store"
+)
+
+func TestLoad(t *testing.T) {
+	ctx := context.Background()
+
+	s := store.NewMemory()
+	t.Cleanup(func() { s.Close() })
+
+	created, err := Load(ctx, s, []byte("secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := Load(ctx, s, []byte("secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(created.CertPEM, loaded.CertPEM) {
+		t.Fatal("loaded another ca than the one created")
+	}
+
+	if _, err := Load(ctx, s, []byte("other")); err != ErrWrongSecret {
+		t.Fatalf("got %v, expected %v", err, ErrWrongSecret)
+	}
+}
@@ -0,0 +1,22 @@
+package rbac
+
+import (
+	"github.com/labstack/echo/v4"
+)
+
+const AuthorizerKey = "authorizer"
+
+func (a *Authorizer) EchoMiddleware() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			c.Set(AuthorizerKey, a)
+			return next(c)
+		}
+	}
+}
+
+// ExtractAuthorizer returns nil when the api is served without one.
+func ExtractAuthorizer(c echo.Context) *Authorizer {
+	a, _ := c.Get(AuthorizerKey).(*Authorizer)
+	return a
+}
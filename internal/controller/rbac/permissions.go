@@ -0,0 +1,198 @@
+package rbac
+
+import (
+	"context"
+	"slices"
+
+	"github.com/eskpil/rockferry/internal/controller/auth"
+	"github.com/eskpil/rockferry/internal/controller/runtime"
+	"github.com/eskpil/rockferry/pkg/rockferry"
+	"github.com/eskpil/rockferry/pkg/rockferry/spec"
+)
+
+// Permissions are the rules which apply to a single caller. They are meant to
+// live as long as a request, the owners looked up to check scopes are kept.
+// A nil Permissions allows everything.
+type Permissions struct {
+	r        *runtime.Runtime
+	identity *auth.Identity
+	rules    []*spec.RoleRule
+
+	resources map[rockferry.OwnerRef]*rockferry.Generic
+}
+
+func matches(values []string, value string) bool {
+	return slices.Contains(values, "*") || slices.Contains(values, value)
+}
+
+func (p *Permissions) matching(verb string, kind rockferry.ResourceKind) []*spec.RoleRule {
+	out := []*spec.RoleRule{}
+	for _, rule := range p.rules {
+		if rule != nil && matches(rule.Verbs, verb) && matches(rule.Kinds, kind) {
+			out = append(out, rule)
+		}
+	}
+
+	return out
+}
+
+// Can reports whether the caller may perform verb on at least some resources of kind.
+func (p *Permissions) Can(verb string, kind rockferry.ResourceKind) bool {
+	if p == nil {
+		return true
+	}
+
+	return len(p.matching(verb, kind)) > 0
+}
+
+// Unrestricted reports whether the caller may do anything to every resource.
+func (p *Permissions) Unrestricted() bool {
+	if p == nil {
+		return true
+	}
+
+	for _, rule := range p.rules {
+		if rule != nil && slices.Contains(rule.Verbs, rockferry.VerbAll) && slices.Contains(rule.Kinds, rockferry.KindAll) && unscoped(rule) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func unscoped(rule *spec.RoleRule) bool {
+	return rule.Scope == "" || rule.Scope == spec.RoleScopeAll
+}
+
+// Allowed reports whether the caller may perform verb on resource.
+func (p *Permissions) Allowed(ctx context.Context, verb string, resource *rockferry.Generic) (bool, error) {
+	if p == nil {
+		return true, nil
+	}
+
+	for _, rule := range p.matching(verb, resource.Kind) {
+		in, err := p.inScope(ctx, rule.Scope, resource)
+		if err != nil {
+			return false, err
+		}
+
+		if in {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+func (p *Permissions) inScope(ctx context.Context, scope spec.RoleScope, resource *rockferry.Generic) (bool, error) {
+	switch scope {
+	case "", spec.RoleScopeAll:
+		return true, nil
+	case spec.RoleScopeNode:
+		id, ok := p.identity.Node()
+		if !ok {
+			return false, nil
+		}
+
+		return p.descends(ctx, resource, func(r *rockferry.Generic) bool {
+			return r.Kind == rockferry.ResourceKindNode && r.Id == id
+		})
+	case spec.RoleScopeOwn:
+		return p.descends(ctx, resource, func(r *rockferry.Generic) bool {
+			return r.Annotations[rockferry.AnnotationCreator] == p.identity.Name
+		})
+	}
+
+	return false, nil
+}
+
+// Events are in scope where the resource they are about is.
+func eventObject(resource *rockferry.Generic) *rockferry.OwnerRef {
+	if resource.Kind != rockferry.ResourceKindEvent {
+		return nil
+	}
+
+	event := rockferry.CastFromMap[spec.EventSpec, rockferry.DefaultStatus](resource)
+	if event.Spec.Object.Kind == "" || event.Spec.Object.Id == "" {
+		return nil
+	}
+
+	object := new(rockferry.OwnerRef)
+	object.Kind = event.Spec.Object.Kind
+	object.Id = event.Spec.Object.Id
+	return object
+}
+
+// Reports whether resource, its owner or its parents, or theirs, match.
+func (p *Permissions) descends(ctx context.Context, resource *rockferry.Generic, match func(*rockferry.Generic) bool) (bool, error) {
+	visited := map[rockferry.OwnerRef]bool{}
+	current := []*rockferry.Generic{resource}
+
+	for range maxDepth {
+		next := []*rockferry.Generic{}
+
+		for _, r := range current {
+			if match(r) {
+				return true, nil
+			}
+
+			refs := slices.Clone(r.Parents)
+			if r.Owner != nil {
+				refs = append(refs, r.Owner)
+			}
+
+			if object := eventObject(r); object != nil {
+				refs = append(refs, object)
+			}
+
+			for _, ref := range refs {
+				if ref == nil || visited[*ref] {
+					continue
+				}
+
+				visited[*ref] = true
+
+				related, err := p.fetch(ctx, ref)
+				if err != nil {
+					return false, err
+				}
+
+				if related != nil {
+					next = append(next, related)
+				}
+			}
+		}
+
+		if len(next) == 0 {
+			break
+		}
+
+		current = next
+	}
+
+	return false, nil
+}
+
+// Returns nil if the resource does not exist.
+func (p *Permissions) fetch(ctx context.Context, ref *rockferry.OwnerRef) (*rockferry.Generic, error) {
+	if resource, ok := p.resources[*ref]; ok {
+		return resource, nil
+	}
+
+	resource, err := p.r.Fetch(ctx, ref.Kind, ref.Id)
+	if err != nil && err != rockferry.ErrorNotFound {
+		return nil, err
+	}
+
+	p.resources[*ref] = resource
+
+	return resource, nil
+}
+
+// Forget drops the resources looked up so far. Watches outlive them, they
+// forget between events.
+func (p *Permissions) Forget() {
+	if p != nil {
+		clear(p.resources)
+	}
+}
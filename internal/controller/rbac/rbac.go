@@ -0,0 +1,260 @@
+// Package rbac decides what callers of the apis may do. Roles grant verbs on
+// kinds of resources, optionally limited to a scope, and role bindings grant
+// roles to users and groups. Both are stored like any other resource, next to
+// the builtin roles in rockferry.BuiltinRoles.
+package rbac
+
+import (
+	"context"
+	"slices"
+
+	"github.com/eskpil/rockferry/internal/controller/auth"
+	"github.com/eskpil/rockferry/internal/controller/pki"
+	"github.com/eskpil/rockferry/internal/controller/runtime"
+	"github.com/eskpil/rockferry/pkg/rockferry"
+	"github.com/eskpil/rockferry/pkg/rockferry/spec"
+)
+
+// How many owners and parents are followed to find out whether a resource is in scope.
+const maxDepth = 8
+
+// The roles the certificates issued by the controller are bound to.
+var builtinBindings = map[string]string{
+	pki.GroupAdmins: rockferry.RoleOperator,
+	pki.GroupNodes:  rockferry.RoleNode,
+}
+
+type Authorizer struct {
+	R *runtime.Runtime
+}
+
+func New(r *runtime.Runtime) *Authorizer {
+	a := new(Authorizer)
+	a.R = r
+	return a
+}
+
+// The rules of the roles bound to identity.
+func (a *Authorizer) rules(ctx context.Context, identity *auth.Identity) ([]*spec.RoleRule, error) {
+	roles := []string{}
+
+	// NOTE: Only the certificates issued by the controller are in these groups,
+	// whatever the groups of other identities are named.
+	if identity.Certificate {
+		for group, role := range builtinBindings {
+			if identity.InGroup(group) {
+				roles = append(roles, role)
+			}
+		}
+	}
+
+	// TODO: Cache the bindings and roles instead of reading them for every request.
+	bindings, err := a.R.ListOwned(ctx, rockferry.ResourceKindRoleBinding, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, generic := range bindings {
+		binding := rockferry.CastFromMap[spec.RoleBindingSpec, rockferry.DefaultStatus](generic)
+
+		for _, subject := range binding.Spec.Subjects {
+			if subject == nil {
+				continue
+			}
+
+			if (subject.Kind == spec.RoleSubjectKindUser && subject.Name == identity.Name) ||
+				(subject.Kind == spec.RoleSubjectKindGroup && identity.InGroup(subject.Name)) {
+				roles = append(roles, binding.Spec.Role)
+				break
+			}
+		}
+	}
+
+	slices.Sort(roles)
+
+	rules := []*spec.RoleRule{}
+	for _, name := range slices.Compact(roles) {
+		// NOTE: Builtin roles can not be replaced by a stored role with the same id.
+		if role, ok := rockferry.BuiltinRoles[name]; ok {
+			rules = append(rules, role.Rules...)
+			continue
+		}
+
+		generic, err := a.R.Fetch(ctx, rockferry.ResourceKindRole, name)
+		if err == rockferry.ErrorNotFound {
+			continue
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		role := rockferry.CastFromMap[spec.RoleSpec, rockferry.DefaultStatus](generic)
+		rules = append(rules, role.Spec.Rules...)
+	}
+
+	return rules, nil
+}
+
+// For returns what the caller may do. A nil authorizer, or a caller without an
+// identity, which only happens when the apis are served without tls, may do
+// everything.
+func (a *Authorizer) For(ctx context.Context) (*Permissions, error) {
+	if a == nil {
+		return nil, nil
+	}
+
+	identity, ok := auth.FromContext(ctx)
+	if !ok {
+		return nil, nil
+	}
+
+	rules, err := a.rules(ctx, identity)
+	if err != nil {
+		return nil, err
+	}
+
+	p := new(Permissions)
+	p.r = a.R
+	p.identity = identity
+	p.rules = rules
+	p.resources = map[rockferry.OwnerRef]*rockferry.Generic{}
+
+	return p, nil
+}
+
+// Authorize returns ErrorForbidden unless the caller may perform verb on resource.
+func (a *Authorizer) Authorize(ctx context.Context, verb string, resource *rockferry.Generic) error {
+	p, err := a.For(ctx)
+	if err != nil {
+		return err
+	}
+
+	allowed, err := p.Allowed(ctx, verb, resource)
+	if err != nil {
+		return err
+	}
+
+	if !allowed {
+		return rockferry.ErrorForbidden
+	}
+
+	return nil
+}
+
+// AuthorizeStored is Authorize for the stored resource, returns ErrorNotFound
+// if it does not exist.
+func (a *Authorizer) AuthorizeStored(ctx context.Context, verb string, kind rockferry.ResourceKind, id string) error {
+	if a == nil {
+		return nil
+	}
+
+	resource, err := a.R.Fetch(ctx, kind, id)
+	if err != nil {
+		return err
+	}
+
+	return a.Authorize(ctx, verb, resource)
+}
+
+// AuthorizePatch returns ErrorForbidden unless the caller may patch previous,
+// the stored resource, into patched. Both have to be in the scope of the
+// caller, a patch can not move a resource out of it by changing its owner or
+// parents. The only exception is the source node of a migration handing the
+// machine over to the target node.
+func (a *Authorizer) AuthorizePatch(ctx context.Context, previous *rockferry.Generic, patched *rockferry.Generic) error {
+	if a == nil {
+		return nil
+	}
+
+	p, err := a.For(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, resource := range []*rockferry.Generic{previous, patched} {
+		allowed, err := p.Allowed(ctx, rockferry.VerbPatch, resource)
+		if err != nil {
+			return err
+		}
+
+		if allowed {
+			continue
+		}
+
+		if resource == patched {
+			if handing, err := a.handsOver(ctx, p, previous, patched); err != nil || handing {
+				return err
+			}
+		}
+
+		return rockferry.ErrorForbidden
+	}
+
+	return nil
+}
+
+// Reports whether patched only hands the machine over to the target node of a
+// migration the calling node is performing.
+func (a *Authorizer) handsOver(ctx context.Context, p *Permissions, previous *rockferry.Generic, patched *rockferry.Generic) (bool, error) {
+	node, ok := p.identity.Node()
+	if !ok || previous.Kind != rockferry.ResourceKindMachine {
+		return false, nil
+	}
+
+	owned := func(r *rockferry.Generic, id string) bool {
+		return r.Owner != nil && r.Owner.Kind == rockferry.ResourceKindNode && r.Owner.Id == id
+	}
+
+	if !owned(previous, node) || patched.Owner == nil || !slices.EqualFunc(previous.Parents, patched.Parents, func(a, b *rockferry.OwnerRef) bool { return *a == *b }) {
+		return false, nil
+	}
+
+	migrations, err := a.R.ListOwned(ctx, rockferry.ResourceKindMachineMigration, &rockferry.OwnerRef{Kind: rockferry.ResourceKindNode, Id: node})
+	if err != nil {
+		return false, err
+	}
+
+	for _, generic := range migrations {
+		migration := rockferry.CastFromMap[spec.MachineMigrationSpec, spec.MachineMigrationStatus](generic)
+
+		// NOTE: The node marks the migration as creating while it migrates the machine.
+		if migration.Phase == rockferry.PhaseCreating && !migration.Deleting() && migration.Spec.Machine == previous.Id && owned(patched, migration.Spec.TargetNode) {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// AuthorizeOperator returns ErrorForbidden unless the caller may do anything
+// to every resource, which is required to manage the cluster itself.
+func (a *Authorizer) AuthorizeOperator(ctx context.Context) error {
+	p, err := a.For(ctx)
+	if err != nil {
+		return err
+	}
+
+	if !p.Unrestricted() {
+		return rockferry.ErrorForbidden
+	}
+
+	return nil
+}
+
+// SetCreator records the caller as the creator of resource, replacing whatever
+// the caller claimed.
+func SetCreator(ctx context.Context, resource *rockferry.Generic) {
+	delete(resource.Annotations, rockferry.AnnotationCreator)
+
+	identity, ok := auth.FromContext(ctx)
+	if !ok {
+		return
+	}
+
+	if resource.Annotations == nil {
+		resource.Annotations = map[string]string{}
+	}
+
+	resource.Annotations[rockferry.AnnotationCreator] = identity.Name
+}
@@ -0,0 +1,116 @@
+package rbac
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/eskpil/rockferry/internal/controller/auth"
+	"github.com/eskpil/rockferry/internal/controller/pki"
+	"github.com/eskpil/rockferry/internal/controller/runtime"
+	"github.com/eskpil/rockferry/internal/controller/store"
+	"github.com/eskpil/rockferry/pkg/rockferry"
+	"github.com/eskpil/rockferry/pkg/rockferry/spec"
+	jsonpatch "github.com/evanphx/json-patch/v5"
+)
+
+func create(t *testing.T, r *runtime.Runtime, resource *rockferry.Generic) {
+	t.Helper()
+
+	if err := r.CreateResource(context.Background(), resource); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func raw(value string) *json.RawMessage {
+	message := json.RawMessage(value)
+	return &message
+}
+
+func nodeRef(id string) *rockferry.OwnerRef {
+	return &rockferry.OwnerRef{Kind: rockferry.ResourceKindNode, Id: id}
+}
+
+// A machine may only be moved to another node by the node migrating it there.
+func TestAuthorizePatchOwner(t *testing.T) {
+	s := store.NewMemory()
+	t.Cleanup(func() { s.Close() })
+
+	r := runtime.New(s)
+
+	for _, id := range []string{"a", "b", "c"} {
+		node := new(rockferry.Node)
+		node.Id = id
+		node.Kind = rockferry.ResourceKindNode
+		node.Status.Conditions.Set(spec.NewCondition(spec.NodeConditionReady, spec.ConditionStatusTrue, "Ready", ""))
+		create(t, r, node.Generic())
+	}
+
+	machine := new(rockferry.Machine)
+	machine.Id = "machine"
+	machine.Kind = rockferry.ResourceKindMachine
+	machine.Owner = nodeRef("a")
+	create(t, r, machine.Generic())
+
+	identity := new(auth.Identity)
+	identity.Name = "a"
+	identity.Groups = []string{pki.GroupNodes}
+	identity.Certificate = true
+
+	ctx := auth.WithIdentity(context.Background(), identity)
+
+	previous, err := r.Fetch(ctx, rockferry.ResourceKindMachine, machine.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	movedTo := func(node string) *rockferry.Generic {
+		patched := *previous
+		patched.Owner = nodeRef(node)
+		return &patched
+	}
+
+	a := New(r)
+
+	if err := a.AuthorizePatch(ctx, previous, movedTo("a")); err != nil {
+		t.Fatalf("patch within the node: %v", err)
+	}
+
+	if err := a.AuthorizePatch(ctx, previous, movedTo("b")); err != rockferry.ErrorForbidden {
+		t.Fatalf("moved without a migration: got %v, expected %v", err, rockferry.ErrorForbidden)
+	}
+
+	migration := new(rockferry.MachineMigration)
+	migration.Id = "migration"
+	migration.Kind = rockferry.ResourceKindMachineMigration
+	migration.Spec.Machine = machine.Id
+	migration.Spec.TargetNode = "b"
+	create(t, r, migration.Generic())
+
+	if err := a.AuthorizePatch(ctx, previous, movedTo("b")); err != rockferry.ErrorForbidden {
+		t.Fatalf("moved before the migration started: got %v, expected %v", err, rockferry.ErrorForbidden)
+	}
+
+	// NOTE: The node marks the migration as creating once it starts migrating.
+	started := jsonpatch.Patch{{"op": raw(`"replace"`), "path": raw(`"/phase"`), "value": raw(`"creating"`)}}
+	if _, _, err := r.PatchStatus(ctx, rockferry.ResourceKindMachineMigration, migration.Id, started, 0, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := a.AuthorizePatch(ctx, previous, movedTo("b")); err != nil {
+		t.Fatalf("moved to the target node: %v", err)
+	}
+
+	if err := a.AuthorizePatch(ctx, previous, movedTo("c")); err != rockferry.ErrorForbidden {
+		t.Fatalf("moved past the target node: got %v, expected %v", err, rockferry.ErrorForbidden)
+	}
+
+	impostor := new(auth.Identity)
+	impostor.Name = "b"
+	impostor.Groups = []string{pki.GroupNodes}
+
+	// NOTE: Named like the node, but not issued a certificate by the controller.
+	if err := a.AuthorizePatch(auth.WithIdentity(context.Background(), impostor), previous, movedTo("b")); err != rockferry.ErrorForbidden {
+		t.Fatalf("moved by an unverified node: got %v, expected %v", err, rockferry.ErrorForbidden)
+	}
+}
@@ -0,0 +1,297 @@
+package runtime
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/eskpil/rockferry/pkg/rockferry"
+	"github.com/eskpil/rockferry/pkg/rockferry/spec"
+	"github.com/siderolabs/go-pointer"
+)
+
+const (
+	// How often pending requests are looked for, besides when they change.
+	allocateInterval = 10 * time.Second
+	// How long to wait before watching again once the watches ended, doubled
+	// while they keep failing to start.
+	allocateBackoff    = time.Second
+	allocateBackoffMax = 30 * time.Second
+)
+
+// Allocations in progress, by kind and id. Each runs in its own goroutine.
+type allocations struct {
+	mu      sync.Mutex
+	running map[string]bool
+	wg      sync.WaitGroup
+}
+
+// Runs allocate unless the resource is already being allocated.
+func (a *allocations) start(ctx context.Context, resource *rockferry.Generic, allocate func(ctx context.Context) error) {
+	key := fmt.Sprintf("%s/%s", resource.Kind, resource.Id)
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.running[key] {
+		return
+	}
+
+	a.running[key] = true
+	a.wg.Add(1)
+
+	go func() {
+		defer a.wg.Done()
+
+		if err := allocate(ctx); err != nil && ctx.Err() == nil {
+			fmt.Println("failed to allocate", resource.Kind, resource.Id, err)
+		}
+
+		a.mu.Lock()
+		delete(a.running, key)
+		a.mu.Unlock()
+	}()
+}
+
+// Machine requests are allocated once they have a node. A failed allocation is
+// recorded in the status and tried again on the next pass.
+func (r *Runtime) allocateMachineRequest(ctx context.Context, id string) error {
+	// NOTE: The listing which started this allocation may predate the previous
+	// allocation of the same request finishing.
+	generic, err := r.Fetch(ctx, rockferry.ResourceKindMachineRequest, id)
+	if err == rockferry.ErrorNotFound {
+		return nil
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if !allocatable(generic) {
+		return nil
+	}
+
+	req := rockferry.CastFromMap[spec.MachineRequestSpec, spec.MachineRequestStatus](generic)
+
+	err = r.AllocateMachineResources(ctx, req)
+	if err == nil || err == rockferry.ErrorConflict || ctx.Err() != nil {
+		return err
+	}
+
+	// NOTE: Refetched, the failed allocation may have written the request already.
+	generic, ferr := r.Fetch(ctx, rockferry.ResourceKindMachineRequest, id)
+	if ferr != nil || !allocatable(generic) {
+		return err
+	}
+
+	latest := rockferry.CastFromMap[spec.MachineRequestSpec, spec.MachineRequestStatus](generic)
+
+	message := err.Error()
+
+	object := &rockferry.OwnerRef{Kind: rockferry.ResourceKindMachineRequest, Id: id}
+	r.RecordEvent(ctx, object, rockferry.EventTypeWarning, "FailedAllocation", "%s", message)
+
+	condition := spec.NewCondition(spec.MachineRequestConditionAllocated, spec.ConditionStatusFalse, "FailedAllocation", message)
+	if latest.Status.Conditions.Set(condition) {
+		latest.Status.Error = pointer.To(message)
+		if uerr := r.Update(ctx, latest.Generic()); uerr != nil {
+			fmt.Println("failed to record allocation error", id, uerr)
+		}
+	}
+
+	return err
+}
+
+// Machine requests are waiting for allocation once the scheduler has given
+// them a node.
+func allocatable(generic *rockferry.Generic) bool {
+	return generic.Owner != nil && generic.Phase == rockferry.PhasePreProcessing && !generic.Deleting()
+}
+
+// Cluster requests are allocated once, a failure leaves the request errored.
+func (r *Runtime) allocateClusterRequest(ctx context.Context, id string) error {
+	generic, err := r.Fetch(ctx, rockferry.ResourceKindClusterRequest, id)
+	if err == rockferry.ErrorNotFound {
+		return nil
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if !requested(generic) {
+		return nil
+	}
+
+	request := rockferry.CastFromMap[spec.ClusterRequestSpec, rockferry.DefaultStatus](generic)
+
+	err = r.AllocateKubernetesCluster(ctx, request)
+	if ctx.Err() != nil {
+		return err
+	}
+
+	// NOTE: The request may have been written while it was allocated, the
+	// outcome is applied to the latest version.
+	uerr := rockferry.RetryOnConflict(ctx, func() error {
+		generic, ferr := r.Fetch(ctx, rockferry.ResourceKindClusterRequest, id)
+		if ferr == rockferry.ErrorNotFound {
+			return nil
+		}
+
+		if ferr != nil {
+			return ferr
+		}
+
+		latest := rockferry.CastFromMap[spec.ClusterRequestSpec, rockferry.DefaultStatus](generic)
+
+		latest.Phase = rockferry.PhaseCreated
+		latest.Status.Error = nil
+
+		if err != nil {
+			latest.Phase = rockferry.PhaseErrored
+			latest.Status.Error = pointer.To(err.Error())
+		}
+
+		latest.Status.Conditions.Set(spec.OutcomeCondition(spec.ConditionReady, err, "Allocated", "FailedAllocation"))
+		latest.Status.Conditions.SetReconciled(latest.Generation, err)
+		latest.Status.ObservedGeneration = latest.Generation
+
+		return r.Update(ctx, latest.Generic())
+	})
+	if uerr != nil {
+		return uerr
+	}
+
+	return err
+}
+
+// Cluster requests are waiting for allocation until they have been allocated once.
+func requested(generic *rockferry.Generic) bool {
+	return generic.Phase == rockferry.PhaseRequested && !generic.Deleting()
+}
+
+// Starts allocating the request unless it is not waiting for it.
+func (r *Runtime) allocate(ctx context.Context, a *allocations, generic *rockferry.Generic) {
+	id := generic.Id
+
+	switch generic.Kind {
+	case rockferry.ResourceKindMachineRequest:
+		if !allocatable(generic) {
+			return
+		}
+
+		a.start(ctx, generic, func(ctx context.Context) error {
+			return r.allocateMachineRequest(ctx, id)
+		})
+	case rockferry.ResourceKindClusterRequest:
+		if !requested(generic) {
+			return
+		}
+
+		a.start(ctx, generic, func(ctx context.Context) error {
+			return r.allocateClusterRequest(ctx, id)
+		})
+	}
+}
+
+// Starts allocating every request which is waiting for it.
+func (r *Runtime) allocatePending(ctx context.Context, a *allocations) error {
+	for _, kind := range []rockferry.ResourceKind{rockferry.ResourceKindMachineRequest, rockferry.ResourceKindClusterRequest} {
+		requests, err := r.listKind(ctx, kind)
+		if err != nil {
+			return err
+		}
+
+		for _, generic := range requests {
+			r.allocate(ctx, a, generic)
+		}
+	}
+
+	return nil
+}
+
+// RunAllocator allocates the resources of machine and cluster requests. Work is
+// found from the stored state alone, so allocations interrupted by a previous
+// leader are resumed. Requests are allocated as they change, with every pending
+// one looked for on start and every allocateInterval. Blocks until ctx is done
+// and every allocation has stopped.
+func (r *Runtime) RunAllocator(ctx context.Context) {
+	a := new(allocations)
+	a.running = map[string]bool{}
+	defer a.wg.Wait()
+
+	ticker := time.NewTicker(allocateInterval)
+	defer ticker.Stop()
+
+	backoff := allocateBackoff
+
+	for {
+		if err := r.watchAllocations(ctx, a, ticker); err != nil {
+			fmt.Println("failed to watch requests", err)
+		} else {
+			backoff = allocateBackoff
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+			backoff = min(backoff*2, allocateBackoffMax)
+		}
+	}
+}
+
+// Allocates requests as they change until the watches end. Only fails when the
+// watches could not be started.
+func (r *Runtime) watchAllocations(ctx context.Context, a *allocations, ticker *time.Ticker) error {
+	// NOTE: Ending the watches must not cancel the allocations they started.
+	watchCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	machines, machinesCanceled, err := r.Watch(watchCtx, rockferry.WatchActionAll, rockferry.ResourceKindMachineRequest, "", nil, WatchOptions{})
+	if err != nil {
+		return err
+	}
+
+	clusters, clustersCanceled, err := r.Watch(watchCtx, rockferry.WatchActionCreate, rockferry.ResourceKindClusterRequest, "", nil, WatchOptions{})
+	if err != nil {
+		return err
+	}
+
+	if err := r.allocatePending(ctx, a); err != nil {
+		fmt.Println("failed to allocate pending requests", err)
+	}
+
+	for {
+		var event *rockferry.WatchEvent[any, any]
+		ok := true
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-machinesCanceled:
+			return nil
+		case <-clustersCanceled:
+			return nil
+		case event, ok = <-machines:
+		case event, ok = <-clusters:
+		case <-ticker.C:
+			if err := r.allocatePending(ctx, a); err != nil {
+				fmt.Println("failed to allocate pending requests", err)
+			}
+
+			continue
+		}
+
+		if !ok {
+			return nil
+		}
+
+		if event.Action == rockferry.WatchActionDelete || event.Resource == nil {
+			continue
+		}
+
+		r.allocate(ctx, a, event.Resource)
+	}
+}
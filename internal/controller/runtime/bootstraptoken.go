@@ -0,0 +1,111 @@
+package runtime
+
+import (
+	"context"
+	"crypto/subtle"
+	"fmt"
+	"time"
+
+	"github.com/eskpil/rockferry/internal/controller/models"
+	"github.com/eskpil/rockferry/internal/controller/store"
+	"github.com/eskpil/rockferry/pkg/rockferry"
+	"github.com/eskpil/rockferry/pkg/rockferry/spec"
+	"github.com/google/uuid"
+)
+
+// Looks up the bootstrap token, fails with ErrorInvalidToken unless it can be used.
+func (r *Runtime) bootstrapToken(ctx context.Context, token string) (*rockferry.BootstrapToken, error) {
+	id, secret, err := rockferry.ParseBootstrapToken(token)
+	if err != nil {
+		return nil, err
+	}
+
+	generic, err := r.Fetch(ctx, rockferry.ResourceKindBootstrapToken, id)
+	if err == rockferry.ErrorNotFound {
+		return nil, rockferry.ErrorInvalidToken
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	bt := rockferry.CastFromMap[spec.BootstrapTokenSpec, rockferry.DefaultStatus](generic)
+
+	hash := rockferry.HashBootstrapSecret(secret)
+	if subtle.ConstantTimeCompare([]byte(hash), []byte(bt.Spec.SecretHash)) != 1 {
+		return nil, rockferry.ErrorInvalidToken
+	}
+
+	if bt.Deleting() || !time.Now().Before(bt.Spec.Expires) {
+		return nil, rockferry.ErrorInvalidToken
+	}
+
+	return bt, nil
+}
+
+// RegisterNode creates a node with a generated id in exchange for a bootstrap
+// token. Fails with ErrorInvalidToken if the token does not exist, has expired
+// or was used already.
+func (r *Runtime) RegisterNode(ctx context.Context, token string) (*rockferry.Node, error) {
+	bt, err := r.bootstrapToken(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+
+	node := new(rockferry.Node)
+	node.Id = uuid.NewString()
+	node.Kind = rockferry.ResourceKindNode
+	node.Phase = rockferry.PhaseCreated
+	node.Generation = 1
+
+	bytes, err := node.Marshal()
+	if err != nil {
+		return nil, err
+	}
+
+	tokenPath := models.ResourceKey(rockferry.ResourceKindBootstrapToken, bt.Id)
+	nodePath := models.ResourceKey(rockferry.ResourceKindNode, node.Id)
+
+	// NOTE: The token is used up by the very write registering the node, so it
+	// can never register two.
+	conditions := []store.Condition{{Key: tokenPath, ModRevision: bt.ResourceVersion}, {Key: nodePath}}
+
+	succeeded, _, err := r.Store.Txn(ctx, conditions, store.OpDelete(tokenPath), store.OpPut(nodePath, bytes))
+	if err != nil {
+		return nil, err
+	}
+
+	if !succeeded {
+		return nil, rockferry.ErrorInvalidToken
+	}
+
+	object := &rockferry.OwnerRef{Kind: rockferry.ResourceKindNode, Id: node.Id}
+	r.RecordEvent(ctx, object, rockferry.EventTypeNormal, "Registered", "registered with bootstrap token %s", bt.Id)
+
+	return node, nil
+}
+
+// Deletes bootstrap tokens which have expired without being used.
+func (r *Runtime) collectExpiredTokens(ctx context.Context) error {
+	tokens, err := r.listKind(ctx, rockferry.ResourceKindBootstrapToken)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+
+	for _, generic := range tokens {
+		bt := rockferry.CastFromMap[spec.BootstrapTokenSpec, rockferry.DefaultStatus](generic)
+		if now.Before(bt.Spec.Expires) {
+			continue
+		}
+
+		fmt.Println("removing expired bootstrap token", bt.Id)
+
+		if err := r.Delete(ctx, rockferry.ResourceKindBootstrapToken, bt.Id); err != nil && err != rockferry.ErrorNotFound {
+			fmt.Println("failed to remove expired bootstrap token", err)
+		}
+	}
+
+	return nil
+}
@@ -8,7 +8,7 @@ import (
 	"strings"
 	"time"
 
-	"github.com/eskpil/rockferry/internal/controller/models"
+	"github.com/eskpil/rockferry/internal/controller/scheduler"
 	"github.com/eskpil/rockferry/pkg/rockferry"
 	"github.com/eskpil/rockferry/pkg/rockferry/spec"
 	"github.com/eskpil/rockferry/pkg/units"
@@ -18,7 +18,8 @@ import (
 	clientconfig "github.com/siderolabs/talos/pkg/machinery/client/config"
 	"github.com/siderolabs/talos/pkg/machinery/config/generate"
 	"github.com/siderolabs/talos/pkg/machinery/config/machine"
-	clientv3 "go.etcd.io/etcd/client/v3"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/types/known/durationpb"
 )
 
@@ -34,120 +35,91 @@ func (e ClusterAllocationError) Error() string {
 	return string(e)
 }
 
-func (r *Runtime) AccumulateControlPlanes(ctx context.Context, machinerequests []*rockferry.MachineRequest) ([]*rockferry.Machine, error) {
-	stream, canceled, err := r.Watch(ctx, rockferry.WatchActionUpdate, rockferry.ResourceKindMachine, "", nil)
+// The machines of machinerequests which are running and reachable, in the order
+// of the requests.
+func (r *Runtime) runningMachines(ctx context.Context, machinerequests []*rockferry.MachineRequest) ([]*rockferry.Machine, error) {
+	generics, err := r.listKind(ctx, rockferry.ResourceKindMachine)
 	if err != nil {
 		return nil, err
 	}
 
-	machines := []*rockferry.Machine{}
+	byRequest := map[string]*rockferry.Machine{}
+	for _, generic := range generics {
+		machine := rockferry.CastFromMap[spec.MachineSpec, spec.MachineStatus](generic)
 
-	for {
-		select {
-		case <-canceled:
-			{
-				return nil, fmt.Errorf("stream closed")
-			}
-		case e := <-stream:
-			{
-				machine := rockferry.CastFromMap[spec.MachineSpec, spec.MachineStatus](e.Resource)
-
-				if machine.Status.State != spec.MachineStatusStateRunning {
-					continue
-				}
-
-				if 1 > len(machine.Status.ReachableIps) {
-					continue
-				}
-
-				for _, cp_req := range machinerequests {
-					if cp_req.Id == machine.Annotations["machinerequest.id"] {
-						machines = append(machines, machine)
-						break
-					}
-				}
-			}
+		if machine.Status.State != spec.MachineStatusStateRunning {
+			continue
 		}
 
-		// Means we have collected all our machine requests
-		if len(machines) == len(machinerequests) {
-			return machines, nil
+		if 1 > len(machine.Status.ReachableIps) {
+			continue
 		}
-	}
-}
 
-func (r *Runtime) SpreadKubernetesControlPlanes(ctx context.Context, machinerequests []*rockferry.MachineRequest) error {
-	path := fmt.Sprintf("%s/%s", models.RootKey, rockferry.ResourceKindNode)
-	results, err := r.Db.Get(ctx, path, clientv3.WithPrefix())
-	if err != nil {
-		return err
+		byRequest[machine.Annotations["machinerequest.id"]] = machine
 	}
 
-	if len(results.Kvs) == len(machinerequests) {
-		// Easy, one machine per node
-
-		for i, node := range results.Kvs {
-			// what is this vocabulary?
-			fmt.Println("symmetrical node rockferry instance")
-			// TODO: Check if node has enough available resources for
-			// 		 the requested topology. For now, we do it easy
-			parts := strings.Split(string(node.Key), "/")
-			id := parts[len(parts)-1]
-
-			machinerequests[i].Owner = new(rockferry.OwnerRef)
-			machinerequests[i].Owner.Id = id
-			machinerequests[i].Owner.Kind = rockferry.ResourceKindNode
+	machines := []*rockferry.Machine{}
+	for _, cp_req := range machinerequests {
+		if machine, ok := byRequest[cp_req.Id]; ok {
+			machines = append(machines, machine)
 		}
+	}
 
-		return nil
+	return machines, nil
+}
+
+// Blocks until the machines of all machinerequests are running.
+func (r *Runtime) AccumulateControlPlanes(ctx context.Context, machinerequests []*rockferry.MachineRequest) ([]*rockferry.Machine, error) {
+	stream, canceled, err := r.Watch(ctx, rockferry.WatchActionUpdate, rockferry.ResourceKindMachine, "", nil, WatchOptions{})
+	if err != nil {
+		return nil, err
 	}
 
-	if len(results.Kvs) == 1 && len(machinerequests) == 3 {
-		fmt.Println("single node rockferry instance")
-		// Easy, all machines per node
-		node := results.Kvs[0]
-		parts := strings.Split(string(node.Key), "/")
-		id := parts[len(parts)-1]
+	// NOTE: When resuming the machines may already be running, there will be no event for them.
+	for {
+		machines, err := r.runningMachines(ctx, machinerequests)
+		if err != nil {
+			return nil, err
+		}
 
-		for _, machinereq := range machinerequests {
-			machinereq.Owner = new(rockferry.OwnerRef)
-			machinereq.Owner.Id = id
-			machinereq.Owner.Kind = rockferry.ResourceKindNode
+		// Means we have collected all our machine requests
+		if len(machines) == len(machinerequests) {
+			return machines, nil
 		}
 
-		return nil
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-canceled:
+			return nil, fmt.Errorf("stream closed")
+		case <-stream:
+		}
 	}
-
-	panic("unhandled")
 }
 
-func (r *Runtime) AssignKubernetesNodeResources(ctx context.Context, machinereq *rockferry.MachineRequest) error {
-	annotations := map[string]string{
-		"rockferry.default": "yes",
-	}
+// Returns the cluster created for request by an earlier attempt, if any.
+func (r *Runtime) findClusterResource(ctx context.Context, request *rockferry.ClusterRequest) (*rockferry.Cluster, error) {
+	selector := rockferry.Selector{{Key: "clusterrequest.id", Operator: rockferry.SelectorOperatorEquals, Values: []string{request.Id}}}
 
-	storagePool, err := r.Get(ctx, rockferry.ResourceKindStoragePool, "", machinereq.Owner, annotations)
-	if err != nil {
-		return err
+	clusters, err := r.List(ctx, rockferry.ResourceKindCluster, "", nil, selector, nil)
+	if err != nil && err != rockferry.ErrorNotFound {
+		return nil, err
 	}
 
-	disk := new(spec.MachineRequestSpecDisk)
-	disk.Pool = storagePool.Id
-	disk.Capacity = units.Gigabyte * 20
-	machinereq.Spec.Disks = append(machinereq.Spec.Disks, disk)
-
-	network, err := r.Get(ctx, rockferry.ResourceKindNetwork, "", machinereq.Owner, annotations)
-	if err != nil {
-		return err
+	if len(clusters) == 0 {
+		return nil, nil
 	}
 
-	machinereq.Spec.Network = network.Id
-
-	return nil
+	return rockferry.CastFromMap[spec.ClusterSpec, spec.ClusterStatus](clusters[0]), nil
 }
 
 func (r *Runtime) CreateClusterResource(ctx context.Context, request *rockferry.ClusterRequest) (*rockferry.Cluster, error) {
-	cluster := new(rockferry.Cluster)
+	cluster, err := r.findClusterResource(ctx, request)
+	if err != nil || cluster != nil {
+		return cluster, err
+	}
+
+	cluster = new(rockferry.Cluster)
 
 	cluster.Id = uuid.NewString()
 
@@ -159,10 +131,12 @@ func (r *Runtime) CreateClusterResource(ctx context.Context, request *rockferry.
 	cluster.Owner.Kind = rockferry.ResourceKindInstance
 
 	cluster.Kind = rockferry.ResourceKindCluster
+	cluster.Parents = []*rockferry.OwnerRef{{Kind: rockferry.ResourceKindClusterRequest, Id: request.Id}}
 
 	cluster.Spec.Name = request.Spec.Name
 	cluster.Spec.KubernetesVersion = request.Spec.KubernetesVersion
 	cluster.Status.State = spec.ClusterStatusStateCreating
+	cluster.Status.Conditions.Set(spec.NewCondition(spec.ConditionReady, spec.ConditionStatusFalse, "Creating", ""))
 
 	if err := r.CreateResource(ctx, cluster.Generic()); err != nil {
 		return nil, err
@@ -222,7 +196,30 @@ func (r *Runtime) AllocateTalosConfig(ctx context.Context, cluster *rockferry.Cl
 
 	cluster.Spec.TalosConfig = tc_bytes
 
-	return r.Update(ctx, cluster.Generic())
+	return r.updateCluster(ctx, cluster)
+}
+
+// Writes cluster and takes on the version it was written at, the later steps
+// of the allocation write it again.
+func (r *Runtime) updateCluster(ctx context.Context, cluster *rockferry.Cluster) error {
+	generic := cluster.Generic()
+	if err := r.Update(ctx, generic); err != nil {
+		return err
+	}
+
+	cluster.ResourceVersion = generic.ResourceVersion
+	cluster.Generation = generic.Generation
+
+	return nil
+}
+
+// Writes cluster if setting condition changed it.
+func (r *Runtime) setClusterCondition(ctx context.Context, cluster *rockferry.Cluster, condition *spec.Condition) error {
+	if !cluster.Status.Conditions.Set(condition) {
+		return nil
+	}
+
+	return r.updateCluster(ctx, cluster)
 }
 
 func (r *Runtime) ApplyKubernetesMachineConfigurations(ctx context.Context, nodes []string, config []byte) error {
@@ -284,6 +281,11 @@ func (r *Runtime) BootstrapKubernetesCluster(ctx context.Context, cluster *rockf
 			return nil // Bootstrap successful
 		}
 
+		// NOTE: A previous leader got this far before it went away.
+		if status.Code(err) == codes.AlreadyExists {
+			return nil
+		}
+
 		if strings.Contains(err.Error(), "connection refused") || strings.Contains(err.Error(), "authentication handshake failed") {
 			time.Sleep(timeout)
 			continue
@@ -295,32 +297,47 @@ func (r *Runtime) BootstrapKubernetesCluster(ctx context.Context, cluster *rockf
 	return errors.New("bootstrap failed: maximum retry attempts reached")
 }
 
-// NOTE: this function naturally blocks until the cluster is created
-func (r *Runtime) AllocateKubernetesCluster(ctx context.Context, request *rockferry.ClusterRequest) error {
-	// step 1   list through all requested nodes.
-	if len(request.Spec.ControlPlanes)%2 == 0 {
-		return ClusterAllocationErrorEvenControlPlanes
+// The control plane machine requests of cluster, created unless an earlier attempt already did.
+func (r *Runtime) controlPlaneRequests(ctx context.Context, request *rockferry.ClusterRequest, cluster *rockferry.Cluster) ([]*rockferry.MachineRequest, error) {
+	selector := rockferry.Selector{{Key: "cluster.id", Operator: rockferry.SelectorOperatorEquals, Values: []string{cluster.Id}}}
+
+	existing, err := r.List(ctx, rockferry.ResourceKindMachineRequest, "", nil, selector, nil)
+	if err != nil && err != rockferry.ErrorNotFound {
+		return nil, err
 	}
 
-	cluster, err := r.CreateClusterResource(ctx, request)
-	if err != nil {
-		return err
+	byName := map[string]*rockferry.MachineRequest{}
+	for _, generic := range existing {
+		machinereq := rockferry.CastFromMap[spec.MachineRequestSpec, spec.MachineRequestStatus](generic)
+		byName[machinereq.Spec.Name] = machinereq
 	}
 
 	cp_machinerequests := []*rockferry.MachineRequest{}
 	for i, cp := range request.Spec.ControlPlanes {
+		name := fmt.Sprintf("%s-cp%d", request.Spec.Name, i)
+
+		if machinereq, ok := byName[name]; ok {
+			cp_machinerequests = append(cp_machinerequests, machinereq)
+			continue
+		}
+
 		//		1.1 allocate a virtual machine with the correct topology
 		machinereq := new(rockferry.MachineRequest)
 
 		machinereq.Kind = rockferry.ResourceKindMachineRequest
 		machinereq.Id = uuid.NewString()
 		machinereq.Phase = rockferry.PhaseRequested
+		machinereq.Parents = []*rockferry.OwnerRef{{Kind: rockferry.ResourceKindCluster, Id: cluster.Id}}
 
 		machinereq.Annotations = map[string]string{}
 		machinereq.Annotations["clusterrequest.id"] = request.Id
 		machinereq.Annotations["clusterrequest.name"] = request.Spec.Name
 		machinereq.Annotations["cluster.id"] = cluster.Id
 
+		// NOTE: Control planes are spread over the nodes, but may share one if there are not enough.
+		machinereq.Annotations[scheduler.AnnotationAntiAffinity] = fmt.Sprintf("%s/control-plane", cluster.Id)
+		machinereq.Annotations[scheduler.AnnotationAntiAffinityMode] = scheduler.AntiAffinityPreferred
+
 		// TODO: Do not hardcode. Actually talk to a image factory instance and
 		// 		 create a schematic
 		machinereq.Annotations["kernel.download"] = "https://factory.talos.dev/image/ce4c980550dd2ab1b17bbf2b08801c7eb59418eafe8f279833297925d67c7515/v1.9.4/kernel-amd64"
@@ -329,37 +346,59 @@ func (r *Runtime) AllocateKubernetesCluster(ctx context.Context, request *rockfe
 
 		machinereq.Spec.Topology = cp.Topology
 
-		machinereq.Spec.Name = fmt.Sprintf("%s-cp%d", request.Spec.Name, i)
+		machinereq.Spec.Name = name
 
 		machinereq.Spec.Cdrom = new(spec.MachineRequestSpecCdrom)
 
-		machinereq.Spec.Disks = []*spec.MachineRequestSpecDisk{}
+		// NOTE: The pool and network are left to the defaults of the node the scheduler picks.
+		disk := new(spec.MachineRequestSpecDisk)
+		disk.Capacity = units.Gigabyte * 20
+		machinereq.Spec.Disks = []*spec.MachineRequestSpecDisk{disk}
+
+		//	1.1.1 the requests are created without an owner, the scheduler spreads
+		//		the control planes out over the physical nodes managed by the
+		//		rockferry instance.
+		if err := r.CreateResource(ctx, machinereq.Generic()); err != nil {
+			return nil, err
+		}
 
 		cp_machinerequests = append(cp_machinerequests, machinereq)
 	}
-	//	1.1.1 spread controlplanes out over the physical nodes managed
-	//		by the rockferry instance. I.E if the instance manages three
-	//		physical hosts, one control plane per host can be managed
-	//		if only two physical nodes is present, chose one of the nodes
-	//		to have dominance. If one physical node, spin out all requested
-	//		control planes on that node.
-	if err := r.SpreadKubernetesControlPlanes(ctx, cp_machinerequests); err != nil {
+
+	return cp_machinerequests, nil
+}
+
+// NOTE: this function naturally blocks until the cluster is created. Every step
+// picks up what an earlier attempt left behind, so an allocation interrupted by a
+// change of leader is resumed rather than started over.
+func (r *Runtime) AllocateKubernetesCluster(ctx context.Context, request *rockferry.ClusterRequest) error {
+	// step 1   list through all requested nodes.
+	if len(request.Spec.ControlPlanes)%2 == 0 {
+		return ClusterAllocationErrorEvenControlPlanes
+	}
+
+	cluster, err := r.CreateClusterResource(ctx, request)
+	if err != nil {
 		return err
 	}
 
-	for _, cp := range cp_machinerequests {
-		if err := r.AssignKubernetesNodeResources(ctx, cp); err != nil {
-			return err
-		}
+	if cluster.Status.State == spec.ClusterStatusStateHealthy {
+		return nil
+	}
+
+	cp_machinerequests, err := r.controlPlaneRequests(ctx, request, cluster)
+	if err != nil {
+		return err
 	}
 
-	for _, cp := range cp_machinerequests {
-		if err := r.CreateResource(ctx, cp.Generic()); err != nil {
+	//		1.2 wait for all control plane node status to be marked as running
+	if !cluster.Status.Conditions.IsTrue(spec.ClusterConditionControlPlaneReady) {
+		condition := spec.NewCondition(spec.ClusterConditionControlPlaneReady, spec.ConditionStatusFalse, "Waiting", "waiting for the control planes to run")
+		if err := r.setClusterCondition(ctx, cluster, condition); err != nil {
 			return err
 		}
 	}
 
-	//		1.2 wait for all control plane node status to be marked as running
 	machines, err := r.AccumulateControlPlanes(ctx, cp_machinerequests)
 	if err != nil {
 		return err
@@ -371,37 +410,56 @@ func (r *Runtime) AllocateKubernetesCluster(ctx context.Context, request *rockfe
 
 	cps := []string{}
 	for _, machine := range machines {
-		node := new(spec.ClusterNodeSpec)
-		node.Kind = spec.ClusterNodeKindControlPlane
-		node.MachineId = machine.Id
+		cps = append(cps, machine.Status.ReachableIps[0].Ip)
+	}
 
-		cluster.Spec.Nodes = append(cluster.Spec.Nodes, node)
+	if len(cluster.Spec.Nodes) == 0 {
+		for _, machine := range machines {
+			node := new(spec.ClusterNodeSpec)
+			node.Kind = spec.ClusterNodeKindControlPlane
+			node.MachineId = machine.Id
 
-		cps = append(cps, machine.Status.ReachableIps[0].Ip)
+			cluster.Spec.Nodes = append(cluster.Spec.Nodes, node)
+		}
 	}
 
-	if err := r.Update(ctx, cluster.Generic()); err != nil {
+	condition := spec.NewCondition(spec.ClusterConditionControlPlaneReady, spec.ConditionStatusTrue, "Running", fmt.Sprintf("%d control planes running", len(machines)))
+	if err := r.setClusterCondition(ctx, cluster, condition); err != nil {
 		return err
 	}
 
 	// step 2   create a talos config with the nodes
-	if err := r.AllocateTalosConfig(ctx, cluster, request, cps); err != nil {
-		return err
+	if len(cluster.Spec.TalosConfig) == 0 {
+		if err := r.AllocateTalosConfig(ctx, cluster, request, cps); err != nil {
+			return err
+		}
 	}
 
 	// 2.1 apply the configuration to all nodes
-	if err := r.ApplyKubernetesMachineConfigurations(ctx, cps, cluster.Spec.ControlPlaneConfig); err != nil {
-		return err
-	}
+	err = r.ApplyKubernetesMachineConfigurations(ctx, cps, cluster.Spec.ControlPlaneConfig)
 
 	// 2.2 bootstrap the controlplane
-	if err := r.BootstrapKubernetesCluster(ctx, cluster, cps); err != nil {
+	if err == nil {
+		err = r.BootstrapKubernetesCluster(ctx, cluster, cps)
+	}
+
+	if cerr := r.setClusterCondition(ctx, cluster, spec.OutcomeCondition(spec.ClusterConditionBootstrapped, err, "Bootstrapped", "FailedBootstrap")); cerr != nil {
+		return cerr
+	}
+
+	if err != nil {
 		return err
 	}
 
 	// TODO: 2.3 add worker nodes
+	workers := spec.NewCondition(spec.ClusterConditionWorkersReady, spec.ConditionStatusTrue, "NoWorkers", "")
+	if len(request.Spec.Workers) > 0 {
+		workers = spec.NewCondition(spec.ClusterConditionWorkersReady, spec.ConditionStatusFalse, "NotImplemented", "worker nodes are not created yet")
+	}
 
 	cluster.Status.State = spec.ClusterStatusStateHealthy
+	cluster.Status.Conditions.Set(workers)
+	cluster.Status.Conditions.Set(spec.NewCondition(spec.ConditionReady, spec.ConditionStatusTrue, "Healthy", ""))
 
-	return r.Update(ctx, cluster.Generic())
+	return r.updateCluster(ctx, cluster)
 }
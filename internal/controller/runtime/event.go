@@ -0,0 +1,72 @@
+package runtime
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/eskpil/rockferry/pkg/rockferry"
+	"github.com/eskpil/rockferry/pkg/rockferry/spec"
+)
+
+// Events are of little use once whatever they describe is long over.
+const defaultEventTTL = time.Hour
+
+func (r *Runtime) recordEvent(ctx context.Context, event *rockferry.Event) error {
+	generic, err := r.Fetch(ctx, rockferry.ResourceKindEvent, event.Id)
+	if err == rockferry.ErrorNotFound {
+		return r.Update(ctx, event.Generic())
+	}
+
+	if err != nil {
+		return err
+	}
+
+	stored := rockferry.CastFromMap[spec.EventSpec, rockferry.DefaultStatus](generic)
+	stored.Spec.Count++
+	stored.Spec.LastSeen = event.Spec.LastSeen
+
+	// NOTE: Guarded by the version it was read at, see Update.
+	return r.Update(ctx, stored.Generic())
+}
+
+// RecordEvent stores an event about object reported by this controller, or counts
+// it if it was already stored. Failing to do so is only reported.
+func (r *Runtime) RecordEvent(ctx context.Context, object *rockferry.OwnerRef, eventType string, reason string, format string, args ...any) {
+	message := fmt.Sprintf(format, args...)
+	fmt.Println(object.Kind, object.Id, reason, message)
+
+	event := rockferry.NewEvent(r.Identity, object, eventType, reason, message)
+	event.Phase = rockferry.PhaseCreated
+
+	err := rockferry.RetryOnConflict(ctx, func() error {
+		return r.recordEvent(ctx, event)
+	})
+
+	if err != nil {
+		fmt.Println("failed to record event", reason, "about", object.Kind, object.Id, err)
+	}
+}
+
+// Deletes events which have not been seen for longer than the event ttl.
+func (r *Runtime) collectExpiredEvents(ctx context.Context) error {
+	events, err := r.listKind(ctx, rockferry.ResourceKindEvent)
+	if err != nil {
+		return err
+	}
+
+	cutoff := time.Now().Add(-r.EventTTL)
+
+	for _, generic := range events {
+		event := rockferry.CastFromMap[spec.EventSpec, rockferry.DefaultStatus](generic)
+		if event.Spec.LastSeen.After(cutoff) {
+			continue
+		}
+
+		if err := r.Delete(ctx, rockferry.ResourceKindEvent, event.Id); err != nil && err != rockferry.ErrorNotFound {
+			fmt.Println("failed to remove expired event", err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,204 @@
+package runtime
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/eskpil/rockferry/internal/controller/scheduler"
+	"github.com/eskpil/rockferry/pkg/rockferry"
+	"github.com/eskpil/rockferry/pkg/rockferry/spec"
+)
+
+// Set to "yes" on a node which is not ready once it is confirmed powered off,
+// for example by an operator or a fencing agent. Machines are only restarted
+// elsewhere when they can not still be running on the node. Cleared when the
+// node becomes ready again.
+const AnnotationFenced = "ha.rockferry/fenced"
+
+const (
+	defaultFailoverGracePeriod = 2 * time.Minute
+	// How often nodes which are not ready are checked for machines to fail over.
+	failoverInterval = 10 * time.Second
+)
+
+// Why the machine can never run on another node, or an empty string if it can.
+func failoverRefusal(machine *rockferry.Machine, node string) string {
+	for i, disk := range machine.Spec.Disks {
+		if disk.Type != "network" {
+			return fmt.Sprintf("disk %d is a %s disk only reachable from node %s, only network disks can be failed over", i, disk.Type, node)
+		}
+	}
+
+	if machine.Spec.Boot.Kernel != nil || machine.Spec.Boot.Initramfs != nil {
+		return fmt.Sprintf("boots a kernel downloaded to node %s", node)
+	}
+
+	return ""
+}
+
+// Decides what happens to a machine on a node which is not ready, and hands it
+// to another node when possible. Returns the node the machine was handed to.
+func (r *Runtime) failoverMachine(ctx context.Context, node *scheduler.NodeInfo, machine *rockferry.Machine, nodes []*scheduler.NodeInfo) (string, error) {
+	condition := node.Node.Status.Conditions.Get(spec.NodeConditionReady)
+
+	failover := new(spec.MachineStatusFailover)
+	failover.SourceNode = node.Node.Id
+	failover.State = spec.MachineStatusFailoverStateWaiting
+	failover.Time = time.Now().UTC()
+
+	if reason := failoverRefusal(machine, node.Node.Id); reason != "" {
+		failover.State = spec.MachineStatusFailoverStateRefused
+		failover.Reason = reason
+	} else if time.Since(condition.LastTransitionTime) < r.FailoverGracePeriod {
+		failover.Reason = fmt.Sprintf("node %s is not ready, failing over once it has been for %s", node.Node.Id, r.FailoverGracePeriod)
+	} else if node.Node.Annotations[AnnotationFenced] != "yes" {
+		failover.Reason = fmt.Sprintf("node %s has not been fenced, annotate it with %s=yes once it is powered off", node.Node.Id, AnnotationFenced)
+	} else if decision := scheduler.Place(machine, nodes); decision.Node == "" {
+		failover.Reason = decision.Error()
+	} else {
+		failover.State = spec.MachineStatusFailoverStatePending
+		failover.TargetNode = decision.Node
+	}
+
+	previous := machine.Status.Failover
+
+	// NOTE: Avoid rewriting the machine every pass while nothing changes.
+	if failover.State != spec.MachineStatusFailoverStatePending && previous != nil && previous.SourceNode == failover.SourceNode && previous.State == failover.State && previous.Reason == failover.Reason {
+		return "", nil
+	}
+
+	machine.Status.Failover = failover
+
+	if failover.State == spec.MachineStatusFailoverStatePending {
+		machine.Owner = new(rockferry.OwnerRef)
+		machine.Owner.Kind = rockferry.ResourceKindNode
+		machine.Owner.Id = failover.TargetNode
+	}
+
+	if err := r.Update(ctx, machine.Generic()); err != nil {
+		return "", err
+	}
+
+	// NOTE: Recorded once the decision is stored, a conflicting write is retried on the next pass.
+	object := &rockferry.OwnerRef{Kind: rockferry.ResourceKindMachine, Id: machine.Id}
+
+	switch failover.State {
+	case spec.MachineStatusFailoverStatePending:
+		r.RecordEvent(ctx, object, rockferry.EventTypeNormal, "FailingOver", "failing over from node %s to node %s", failover.SourceNode, failover.TargetNode)
+	case spec.MachineStatusFailoverStateRefused:
+		r.RecordEvent(ctx, object, rockferry.EventTypeWarning, "FailoverRefused", "%s", failover.Reason)
+	default:
+		r.RecordEvent(ctx, object, rockferry.EventTypeNormal, "FailoverWaiting", "%s", failover.Reason)
+	}
+
+	if failover.State == spec.MachineStatusFailoverStatePending {
+		if err := r.moveMachineRequest(ctx, machine, failover); err != nil {
+			fmt.Println("failed to move machine request", machine.Id, err)
+		}
+	}
+
+	return failover.TargetNode, nil
+}
+
+// The request follows its machine, otherwise deleting the dead node would take
+// the machine with it.
+func (r *Runtime) moveMachineRequest(ctx context.Context, machine *rockferry.Machine, failover *spec.MachineStatusFailover) error {
+	id := machine.Annotations["machinerequest.id"]
+	if id == "" {
+		return nil
+	}
+
+	req, err := r.Fetch(ctx, rockferry.ResourceKindMachineRequest, id)
+	if err == rockferry.ErrorNotFound {
+		return nil
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if node, ok := ownedByNode(req); !ok || node != failover.SourceNode {
+		return nil
+	}
+
+	req.Owner = new(rockferry.OwnerRef)
+	req.Owner.Kind = rockferry.ResourceKindNode
+	req.Owner.Id = failover.TargetNode
+
+	return r.Update(ctx, req)
+}
+
+func (r *Runtime) failoverMachines(ctx context.Context) error {
+	nodes, err := r.nodeInfos(ctx)
+	if err != nil {
+		return err
+	}
+
+	infos := map[string]*scheduler.NodeInfo{}
+	for _, node := range nodes {
+		infos[node.Node.Id] = node
+	}
+
+	for _, node := range nodes {
+		condition := node.Node.Status.Conditions.Get(spec.NodeConditionReady)
+		if condition == nil || condition.Status != spec.ConditionStatusFalse {
+			continue
+		}
+
+		owner := new(rockferry.OwnerRef)
+		owner.Kind = rockferry.ResourceKindNode
+		owner.Id = node.Node.Id
+
+		machines, err := r.ListOwned(ctx, rockferry.ResourceKindMachine, owner)
+		if err != nil {
+			return err
+		}
+
+		for _, generic := range machines {
+			if generic.Deleting() {
+				continue
+			}
+
+			machine := rockferry.CastFromMap[spec.MachineSpec, spec.MachineStatus](generic)
+
+			target, err := r.failoverMachine(ctx, node, machine, nodes)
+			if err != nil {
+				object := &rockferry.OwnerRef{Kind: rockferry.ResourceKindMachine, Id: machine.Id}
+				r.RecordEvent(ctx, object, rockferry.EventTypeWarning, "FailedFailover", "%s", err)
+				continue
+			}
+
+			// NOTE: The next machine has to see this one on the target.
+			if target != "" {
+				infos[target].Machines = append(infos[target].Machines, machine)
+			}
+		}
+	}
+
+	return nil
+}
+
+// RunFailover restarts machines of nodes which went silent on healthy nodes.
+// This only happens once a node has been not ready for the grace period and has
+// been fenced, and only for machines whose disks are reachable from every node.
+// Machines which can not be failed over have the reason in their status. Blocks
+// until ctx is done.
+//
+// TODO: The domains left behind on a fenced node are not undefined once it returns.
+func (r *Runtime) RunFailover(ctx context.Context) {
+	ticker := time.NewTicker(failoverInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := r.failoverMachines(ctx); err != nil {
+			fmt.Println("failed to fail over machines", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package runtime
+
+import (
+	"context"
+	"testing"
+
+	"github.com/eskpil/rockferry/internal/controller/scheduler"
+	"github.com/eskpil/rockferry/internal/controller/store"
+	"github.com/eskpil/rockferry/pkg/rockferry"
+	"github.com/eskpil/rockferry/pkg/rockferry/spec"
+)
+
+// A decision which could not be stored did not happen, nothing may be said about it.
+func TestFailoverConflictRecordsNothing(t *testing.T) {
+	ctx := context.Background()
+
+	s := store.NewMemory()
+	t.Cleanup(func() { s.Close() })
+
+	r := New(s)
+
+	node := new(rockferry.Node)
+	node.Id = "a"
+	node.Kind = rockferry.ResourceKindNode
+	node.Status.Conditions.Set(spec.NewCondition(spec.NodeConditionReady, spec.ConditionStatusFalse, "NodeLost", ""))
+
+	machine := new(rockferry.Machine)
+	machine.Id = "m"
+	machine.Kind = rockferry.ResourceKindMachine
+	machine.Owner = &rockferry.OwnerRef{Kind: rockferry.ResourceKindNode, Id: node.Id}
+	machine.Spec.Disks = []*spec.MachineSpecDisk{{Type: "file"}}
+
+	if err := r.Update(ctx, machine.Generic()); err != nil {
+		t.Fatal(err)
+	}
+
+	generic, err := r.Fetch(ctx, rockferry.ResourceKindMachine, machine.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stale := rockferry.CastFromMap[spec.MachineSpec, spec.MachineStatus](generic)
+
+	machine.Annotations = map[string]string{"changed": "yes"}
+	if err := r.Update(ctx, machine.Generic()); err != nil {
+		t.Fatal(err)
+	}
+
+	info := &scheduler.NodeInfo{Node: node}
+
+	if _, err := r.failoverMachine(ctx, info, stale, nil); err != rockferry.ErrorConflict {
+		t.Fatalf("got %v, expected %v", err, rockferry.ErrorConflict)
+	}
+
+	events, err := r.listKind(ctx, rockferry.ResourceKindEvent)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(events) != 0 {
+		t.Fatalf("recorded %d events about a failover which was not stored", len(events))
+	}
+}
@@ -0,0 +1,258 @@
+package runtime
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/eskpil/rockferry/internal/controller/models"
+	"github.com/eskpil/rockferry/pkg/rockferry"
+)
+
+// How often every resource is checked for owners which no longer exist. This
+// catches deletions which happened while the controller was not watching.
+const gcInterval = 5 * time.Minute
+
+// Deletions which follow each other within gcBatchWindow are collected
+// together, up to gcBatchSize of them, with a single listing.
+const (
+	gcBatchWindow = 100 * time.Millisecond
+	gcBatchSize   = 1000
+)
+
+// Kinds which are never the owner or a parent of another resource, deleting
+// them leaves nothing to collect.
+var ownerlessKinds = []rockferry.ResourceKind{rockferry.ResourceKindEvent}
+
+func refKey(kind rockferry.ResourceKind, id string) string {
+	return fmt.Sprintf("%s/%s", kind, id)
+}
+
+// The owner and parents of a resource. Instances are not stored as resources,
+// so they are left out.
+func ownerRefs(resource *rockferry.Generic) []*rockferry.OwnerRef {
+	refs := []*rockferry.OwnerRef{}
+
+	if resource.Owner != nil && resource.Owner.Kind != rockferry.ResourceKindInstance {
+		refs = append(refs, resource.Owner)
+	}
+
+	for _, parent := range resource.Parents {
+		if parent.Kind != rockferry.ResourceKindInstance {
+			refs = append(refs, parent)
+		}
+	}
+
+	return refs
+}
+
+func (r *Runtime) listAll(ctx context.Context) ([]*rockferry.Generic, error) {
+	kvs, _, err := r.Store.List(ctx, models.KindPrefix(rockferry.ResourceKindAll))
+	if err != nil {
+		return nil, err
+	}
+
+	resources := []*rockferry.Generic{}
+
+	for _, kv := range kvs {
+		resource, err := decodeResource(kv.Value, kv.ModRevision)
+		if err != nil {
+			fmt.Println("failed to decode resource", kv.Key, err)
+			continue
+		}
+
+		resources = append(resources, resource)
+	}
+
+	return resources, nil
+}
+
+func (r *Runtime) deleteDependent(ctx context.Context, resource *rockferry.Generic) {
+	if resource.Deleting() {
+		return
+	}
+
+	object := &rockferry.OwnerRef{Kind: resource.Kind, Id: resource.Id}
+	r.RecordEvent(ctx, object, rockferry.EventTypeNormal, "GarbageCollected", "its owner or a parent no longer exists")
+
+	if err := r.Delete(ctx, resource.Kind, resource.Id); err != nil && err != rockferry.ErrorNotFound {
+		fmt.Println("failed to garbage collect resource", err)
+	}
+}
+
+// Deletes every resource owned by, or a child of, one of the given resources.
+func (r *Runtime) collectDependents(ctx context.Context, owners []*rockferry.OwnerRef) error {
+	owners = slices.DeleteFunc(owners, func(owner *rockferry.OwnerRef) bool {
+		return slices.Contains(ownerlessKinds, owner.Kind)
+	})
+
+	if len(owners) == 0 {
+		return nil
+	}
+
+	resources, err := r.listAll(ctx)
+	if err != nil {
+		return err
+	}
+
+	dependents := map[rockferry.OwnerRef][]*rockferry.Generic{}
+	for _, resource := range resources {
+		for _, ref := range ownerRefs(resource) {
+			dependents[*ref] = append(dependents[*ref], resource)
+		}
+	}
+
+	// NOTE: A resource may depend on several of the owners, or on one twice.
+	collected := map[string]bool{}
+
+	for _, owner := range owners {
+		for _, resource := range dependents[*owner] {
+			key := refKey(resource.Kind, resource.Id)
+			if collected[key] {
+				continue
+			}
+
+			collected[key] = true
+			r.deleteDependent(ctx, resource)
+		}
+	}
+
+	return nil
+}
+
+// Appends the deletions which closely follow the ones in batch to it, until the
+// stream stays quiet for gcBatchWindow or the batch is full. Returns false once
+// the stream is closed.
+func gatherDeletions(ctx context.Context, stream chan *rockferry.WatchEvent[any, any], batch []*rockferry.OwnerRef) ([]*rockferry.OwnerRef, bool) {
+	for len(batch) < gcBatchSize {
+		select {
+		case <-ctx.Done():
+			return batch, true
+		case <-time.After(gcBatchWindow):
+			return batch, true
+		case e, ok := <-stream:
+			if !ok {
+				return batch, false
+			}
+
+			batch = append(batch, &rockferry.OwnerRef{Kind: e.Resource.Kind, Id: e.Resource.Id})
+		}
+	}
+
+	return batch, true
+}
+
+// Deletes resources which reference an owner which does not exist. Resources are
+// only deleted if they were already orphaned during the previous pass, owners
+// may be created shortly after the resources referencing them.
+func (r *Runtime) collectOrphans(ctx context.Context, suspects map[string]bool) (map[string]bool, error) {
+	resources, err := r.listAll(ctx)
+	if err != nil {
+		return suspects, err
+	}
+
+	existing := map[string]bool{}
+	for _, resource := range resources {
+		existing[refKey(resource.Kind, resource.Id)] = true
+	}
+
+	orphans := map[string]bool{}
+
+	for _, resource := range resources {
+		key := refKey(resource.Kind, resource.Id)
+
+		for _, ref := range ownerRefs(resource) {
+			if existing[refKey(ref.Kind, ref.Id)] {
+				continue
+			}
+
+			orphans[key] = true
+			if suspects[key] {
+				r.deleteDependent(ctx, resource)
+			}
+
+			break
+		}
+	}
+
+	return orphans, nil
+}
+
+// CollectGarbage cascades deletions along owner references. Once a resource is
+// gone every resource it owns or is a parent of is deleted as well, which may in
+// turn cascade further. Expired bootstrap tokens and events are removed as
+// well. Blocks until ctx is done.
+func (r *Runtime) CollectGarbage(ctx context.Context) {
+	ticker := time.NewTicker(gcInterval)
+	defer ticker.Stop()
+
+	suspects, err := r.collectOrphans(ctx, map[string]bool{})
+	if err != nil {
+		fmt.Println("failed to collect orphans", err)
+	}
+
+	if err := r.collectExpiredTokens(ctx); err != nil {
+		fmt.Println("failed to collect expired bootstrap tokens", err)
+	}
+
+	if err := r.collectExpiredEvents(ctx); err != nil {
+		fmt.Println("failed to collect expired events", err)
+	}
+
+	for {
+		// NOTE: Deletions missed while the watch is restarted are left to the next orphan pass.
+		stream, canceled, err := r.Watch(ctx, rockferry.WatchActionDelete, rockferry.ResourceKindAll, "", nil, WatchOptions{})
+		if err != nil {
+			fmt.Println("failed to watch for deletions", err)
+			return
+		}
+
+	watch:
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-canceled:
+				break watch
+			case <-ticker.C:
+				suspects, err = r.collectOrphans(ctx, suspects)
+				if err != nil {
+					fmt.Println("failed to collect orphans", err)
+				}
+
+				if err := r.collectExpiredTokens(ctx); err != nil {
+					fmt.Println("failed to collect expired bootstrap tokens", err)
+				}
+
+				if err := r.collectExpiredEvents(ctx); err != nil {
+					fmt.Println("failed to collect expired events", err)
+				}
+			case e, ok := <-stream:
+				if !ok {
+					break watch
+				}
+
+				owner := new(rockferry.OwnerRef)
+				owner.Kind = e.Resource.Kind
+				owner.Id = e.Resource.Id
+
+				owners, open := gatherDeletions(ctx, stream, []*rockferry.OwnerRef{owner})
+
+				if err := r.collectDependents(ctx, owners); err != nil {
+					fmt.Println("failed to collect dependents", err)
+				}
+
+				if !open {
+					break watch
+				}
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(time.Second):
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package runtime
+
+import (
+	"context"
+	"testing"
+
+	"github.com/eskpil/rockferry/internal/controller/store"
+	"github.com/eskpil/rockferry/pkg/rockferry"
+)
+
+func TestCollectDependents(t *testing.T) {
+	ctx := context.Background()
+
+	s := store.NewMemory()
+	t.Cleanup(func() { s.Close() })
+
+	r := New(s)
+
+	node := func(id string) *rockferry.OwnerRef {
+		return &rockferry.OwnerRef{Kind: rockferry.ResourceKindNode, Id: id}
+	}
+
+	machines := []struct {
+		id       string
+		owner    string
+		parents  []*rockferry.OwnerRef
+		expected bool
+	}{
+		{id: "owned", owner: "a", expected: false},
+		// NOTE: Depends on both deleted nodes, it is only deleted once.
+		{id: "parented", owner: "b", parents: []*rockferry.OwnerRef{node("a")}, expected: false},
+		{id: "unrelated", owner: "c", expected: true},
+	}
+
+	for _, m := range machines {
+		machine := new(rockferry.Machine)
+		machine.Id = m.id
+		machine.Kind = rockferry.ResourceKindMachine
+		machine.Owner = node(m.owner)
+		machine.Parents = m.parents
+
+		if err := r.Update(ctx, machine.Generic()); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	owners := []*rockferry.OwnerRef{
+		{Kind: rockferry.ResourceKindEvent, Id: "event"},
+		node("a"),
+		node("b"),
+	}
+
+	if err := r.collectDependents(ctx, owners); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, m := range machines {
+		exists, err := r.Exists(ctx, rockferry.ResourceKindMachine, m.id)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if exists != m.expected {
+			t.Errorf("machine %s: exists %v, expected %v", m.id, exists, m.expected)
+		}
+	}
+}
@@ -0,0 +1,154 @@
+package runtime
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/eskpil/rockferry/internal/controller/models"
+	"github.com/eskpil/rockferry/internal/controller/store"
+)
+
+const (
+	// How long the leader keeps leading after it stopped renewing its lease.
+	leaderLeaseTTL = 15 * time.Second
+	// How often the leader renews its lease, and others check whether it is gone.
+	leaderRenewInterval = 5 * time.Second
+	// How long a renewal may take. A renewal failing late still leaves the
+	// leader time to stop before its lease expires and another takes over.
+	leaderRenewTimeout = 4 * time.Second
+	// How long stepping down may take, the lease expires on its own otherwise.
+	leaderStepDownTimeout = 5 * time.Second
+)
+
+// Tries to become the leader, reports the lease the leader key is attached to
+// if it did.
+func (r *Runtime) campaign(ctx context.Context) (store.LeaseID, error) {
+	leader, _, err := r.Store.Get(ctx, models.LeaderKey)
+	if err != nil {
+		return 0, err
+	}
+
+	if leader != nil {
+		return 0, nil
+	}
+
+	lease, err := r.Store.Grant(ctx, leaderLeaseTTL)
+	if err != nil {
+		return 0, err
+	}
+
+	conditions := []store.Condition{{Key: models.LeaderKey, ModRevision: 0}}
+	op := store.OpPut(models.LeaderKey, []byte(r.Identity)).WithLease(lease)
+
+	// NOTE: Someone else won the race, the lease expires on its own.
+	succeeded, _, err := r.Store.Txn(ctx, conditions, op)
+	if err != nil || !succeeded {
+		return 0, err
+	}
+
+	return lease, nil
+}
+
+func (r *Runtime) renew(ctx context.Context, lease store.LeaseID) error {
+	ctx, cancel := context.WithTimeout(ctx, leaderRenewTimeout)
+	defer cancel()
+
+	return r.Store.KeepAlive(ctx, lease)
+}
+
+// Runs run until ctx is done, run returns or the lease can no longer be
+// renewed. Run is canceled on the first failed renewal, as another controller
+// may take over once the lease expires.
+func (r *Runtime) lead(ctx context.Context, lease store.LeaseID, run func(ctx context.Context)) {
+	ctx, cancel := context.WithCancel(ctx)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		run(ctx)
+	}()
+
+	ticker := time.NewTicker(leaderRenewInterval)
+	defer ticker.Stop()
+
+	for renewing := true; renewing; {
+		select {
+		case <-ctx.Done():
+			renewing = false
+		case <-done:
+			renewing = false
+		case <-ticker.C:
+			if err := r.renew(ctx, lease); err != nil {
+				fmt.Println("failed to renew leader lease", err)
+				renewing = false
+			}
+		}
+	}
+
+	cancel()
+	<-done
+
+	// NOTE: Revoking the lease deletes the leader key along with it, sparing the
+	// others waiting for the lease to expire.
+	revokeCtx, cancelRevoke := context.WithTimeout(context.WithoutCancel(ctx), leaderStepDownTimeout)
+	defer cancelRevoke()
+
+	if err := r.Store.Revoke(revokeCtx, lease); err != nil && err != store.ErrLeaseNotFound {
+		fmt.Println("failed to step down as leader", err)
+	}
+}
+
+// RunElected runs run while this controller is the leader, until ctx is done.
+// Only one controller leads at a time, the others take over once it steps down
+// or its lease expires. The context given to run is canceled when leadership is
+// lost.
+func (r *Runtime) RunElected(ctx context.Context, run func(ctx context.Context)) {
+	ticker := time.NewTicker(leaderRenewInterval)
+	defer ticker.Stop()
+
+	for ctx.Err() == nil {
+		lease, err := r.campaign(ctx)
+		if err != nil {
+			fmt.Println("failed to campaign for leadership", err)
+		}
+
+		if lease != 0 {
+			fmt.Println(r.Identity, "is now the leader")
+			r.lead(ctx, lease, run)
+			fmt.Println(r.Identity, "is no longer the leader")
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+type LeaderStatus struct {
+	// The controller answering.
+	Identity string `json:"identity"`
+	// Empty while there is no leader, like right after the last one went away.
+	Leader   string `json:"leader"`
+	IsLeader bool   `json:"is_leader"`
+}
+
+func (r *Runtime) Leader(ctx context.Context) (*LeaderStatus, error) {
+	kv, _, err := r.Store.Get(ctx, models.LeaderKey)
+	if err != nil {
+		return nil, err
+	}
+
+	status := new(LeaderStatus)
+	status.Identity = r.Identity
+
+	if kv != nil {
+		status.Leader = string(kv.Value)
+	}
+
+	status.IsLeader = status.Leader == r.Identity
+
+	return status, nil
+}
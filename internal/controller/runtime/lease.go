@@ -0,0 +1,182 @@
+package runtime
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/eskpil/rockferry/internal/controller/models"
+	"github.com/eskpil/rockferry/internal/controller/store"
+	"github.com/eskpil/rockferry/pkg/rockferry"
+	"github.com/eskpil/rockferry/pkg/rockferry/spec"
+)
+
+const (
+	// How long a node is considered alive after renewing its lease.
+	nodeLeaseTTL = 40 * time.Second
+	// How often the leases of all nodes are checked.
+	nodeMonitorInterval = 5 * time.Second
+)
+
+// RenewNodeLease extends the lease of the node, granting a new one if it has
+// expired. The lease key is removed by the store once the ttl runs out.
+func (r *Runtime) RenewNodeLease(ctx context.Context, id string) error {
+	exists, err := r.Exists(ctx, rockferry.ResourceKindNode, id)
+	if err != nil {
+		return err
+	}
+
+	if !exists {
+		return rockferry.ErrorNotFound
+	}
+
+	key := models.NodeLeaseKey(id)
+
+	kv, _, err := r.Store.Get(ctx, key)
+	if err != nil {
+		return err
+	}
+
+	if kv != nil {
+		lease, err := strconv.ParseInt(string(kv.Value), 10, 64)
+		if err != nil {
+			return err
+		}
+
+		err = r.Store.KeepAlive(ctx, store.LeaseID(lease))
+		if err != store.ErrLeaseNotFound {
+			return err
+		}
+	}
+
+	lease, err := r.Store.Grant(ctx, nodeLeaseTTL)
+	if err != nil {
+		return err
+	}
+
+	_, _, err = r.Store.Txn(ctx, nil, store.OpPut(key, []byte(strconv.FormatInt(int64(lease), 10))).WithLease(lease))
+	return err
+}
+
+// The ready condition a node should have, given whether its lease is alive.
+func readyCondition(previous *spec.Condition, alive bool) *spec.Condition {
+	condition := new(spec.Condition)
+	condition.Type = spec.NodeConditionReady
+
+	switch {
+	case alive:
+		condition.Status = spec.ConditionStatusTrue
+		condition.Reason = "LeaseRenewed"
+		condition.Message = "node agent is renewing its lease"
+	case previous == nil || previous.Status == spec.ConditionStatusUnknown:
+		// NOTE: Never having seen the node is different from losing it.
+		condition.Status = spec.ConditionStatusUnknown
+		condition.Reason = "NoLease"
+		condition.Message = "node agent has not renewed its lease yet"
+	default:
+		condition.Status = spec.ConditionStatusFalse
+		condition.Reason = "LeaseExpired"
+		condition.Message = fmt.Sprintf("node agent has not renewed its lease in %s", nodeLeaseTTL)
+	}
+
+	return condition
+}
+
+// The machines on a node which went silent may or may not still be running.
+func (r *Runtime) markMachinesUnknown(ctx context.Context, node string) error {
+	owner := new(rockferry.OwnerRef)
+	owner.Kind = rockferry.ResourceKindNode
+	owner.Id = node
+
+	machines, err := r.ListOwned(ctx, rockferry.ResourceKindMachine, owner)
+	if err != nil {
+		return err
+	}
+
+	for _, generic := range machines {
+		machine := rockferry.CastFromMap[spec.MachineSpec, spec.MachineStatus](generic)
+		if machine.Status.State == spec.MachineStatusStateUnknown {
+			continue
+		}
+
+		machine.Status.State = spec.MachineStatusStateUnknown
+		machine.Status.Conditions.Set(spec.NewCondition(spec.ConditionReady, spec.ConditionStatusUnknown, "NodeLost", fmt.Sprintf("node %s went silent", node)))
+
+		// NOTE: A conflict means the node is still writing, it is not silent after all.
+		if err := r.Update(ctx, machine.Generic()); err != nil {
+			fmt.Println("failed to mark machine unknown", machine.Id, err)
+			continue
+		}
+
+		object := &rockferry.OwnerRef{Kind: rockferry.ResourceKindMachine, Id: machine.Id}
+		r.RecordEvent(ctx, object, rockferry.EventTypeWarning, "NodeLost", "node %s went silent, the machine is in an unknown state", node)
+	}
+
+	return nil
+}
+
+func (r *Runtime) monitorNode(ctx context.Context, node *rockferry.Node) error {
+	lease, _, err := r.Store.Get(ctx, models.NodeLeaseKey(node.Id))
+	if err != nil {
+		return err
+	}
+
+	condition := readyCondition(node.Status.Conditions.Get(spec.NodeConditionReady), lease != nil)
+	if !node.Status.Conditions.Set(condition) {
+		return nil
+	}
+
+	// NOTE: A fence only vouches for the outage it was set for.
+	if condition.Status == spec.ConditionStatusTrue {
+		delete(node.Annotations, AnnotationFenced)
+	}
+
+	if err := r.Update(ctx, node.Generic()); err != nil {
+		return err
+	}
+
+	// NOTE: Recorded once the transition is stored, a conflicting write is retried on the next pass.
+	eventType := rockferry.EventTypeWarning
+	if condition.Status == spec.ConditionStatusTrue {
+		eventType = rockferry.EventTypeNormal
+	}
+
+	object := &rockferry.OwnerRef{Kind: rockferry.ResourceKindNode, Id: node.Id}
+	r.RecordEvent(ctx, object, eventType, condition.Reason, "%s", condition.Message)
+
+	if condition.Status == spec.ConditionStatusFalse {
+		return r.markMachinesUnknown(ctx, node.Id)
+	}
+
+	return nil
+}
+
+// MonitorNodes keeps the ready condition of every node in line with its lease.
+// Nodes which stop renewing their lease become not ready and their machines
+// unknown. Blocks until ctx is done.
+func (r *Runtime) MonitorNodes(ctx context.Context) {
+	ticker := time.NewTicker(nodeMonitorInterval)
+	defer ticker.Stop()
+
+	for {
+		nodes, err := r.listKind(ctx, rockferry.ResourceKindNode)
+		if err != nil {
+			fmt.Println("failed to list nodes", err)
+		}
+
+		for _, generic := range nodes {
+			node := rockferry.CastFromMap[spec.NodeSpec, spec.NodeStatus](generic)
+
+			if err := r.monitorNode(ctx, node); err != nil {
+				fmt.Println("failed to monitor node", node.Id, err)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -3,96 +3,198 @@ package runtime
 import (
 	"context"
 	"fmt"
+	"strings"
 
+	"github.com/eskpil/rockferry/internal/controller/scheduler"
 	"github.com/eskpil/rockferry/pkg/rockferry"
 	"github.com/eskpil/rockferry/pkg/rockferry/spec"
 	"github.com/google/uuid"
 )
 
+// Fills in the key of every disk whose volume the node has created, reports
+// whether all of them have one.
+func (r *Runtime) collectVolumeKeys(ctx context.Context, req *rockferry.MachineRequest) (bool, error) {
+	filled := true
+
+	for _, d := range req.Spec.Disks {
+		if d.Key != "" {
+			continue
+		}
+
+		generic, err := r.Fetch(ctx, rockferry.ResourceKindStorageVolume, d.Volume)
+		if err != nil && err != rockferry.ErrorNotFound {
+			return false, err
+		}
+
+		if generic != nil {
+			volume := rockferry.CastFromMap[spec.StorageVolumeSpec, rockferry.DefaultStatus](generic)
+			d.Key = volume.Spec.Key
+		}
+
+		if d.Key == "" {
+			filled = false
+		}
+	}
+
+	return filled, nil
+}
+
 func (r *Runtime) allocateMachineVolumes(ctx context.Context, req *rockferry.MachineRequest) error {
 	if len(req.Spec.Disks) == 0 {
 		return nil
 	}
 
-	volumes := []*rockferry.StorageVolume{}
+	// NOTE: The volume ids are stored before the volumes are created, so a leader
+	// taking over an interrupted allocation creates the same volumes.
+	assigned := false
+	for _, d := range req.Spec.Disks {
+		if d.Volume == "" {
+			d.Volume = fmt.Sprintf("%s/%s", d.Pool, uuid.NewString())
+			assigned = true
+		}
+	}
 
-	for i, d := range req.Spec.Disks {
-		volume := new(rockferry.StorageVolume)
+	if assigned {
+		generic := req.Generic()
+		if err := r.Update(ctx, generic); err != nil {
+			return err
+		}
+
+		req.ResourceVersion = generic.ResourceVersion
+	}
+
+	for _, d := range req.Spec.Disks {
+		exists, err := r.Exists(ctx, rockferry.ResourceKindStorageVolume, d.Volume)
+		if err != nil {
+			return err
+		}
 
-		name := uuid.NewString()
+		if exists {
+			continue
+		}
 
-		id := fmt.Sprintf("%s/%s", d.Pool, name)
+		volume := new(rockferry.StorageVolume)
 
 		volume.Owner = new(rockferry.OwnerRef)
 		volume.Owner.Id = d.Pool
 		volume.Owner.Kind = rockferry.ResourceKindStoragePool
 
+		volume.Parents = []*rockferry.OwnerRef{{Kind: rockferry.ResourceKindMachineRequest, Id: req.Id}}
+		// NOTE: The node has to remove the volume from its pool before it is gone.
+		volume.Finalizers = []string{rockferry.FinalizerNode}
+
 		volume.Annotations = map[string]string{}
 		volume.Annotations["machinereq.id"] = req.Id
 		volume.Annotations["machinereq.name"] = req.Spec.Name
 
 		volume.Kind = rockferry.ResourceKindStorageVolume
-		volume.Id = id
-		volume.Spec.Name = name
+		volume.Id = d.Volume
+		volume.Spec.Name = strings.TrimPrefix(d.Volume, d.Pool+"/")
 		volume.Spec.Allocation = d.Allocation
 		volume.Spec.Capacity = d.Capacity
 
-		req.Spec.Disks[i].Volume = id
-
 		if err := r.CreateResource(ctx, volume.Generic()); err != nil {
 			return err
 		}
-
-		volumes = append(volumes, volume)
 	}
 
-	stream, cancel, err := r.Watch(context.Background(), rockferry.WatchActionUpdate, rockferry.ResourceKindStorageVolume, "", nil)
+	stream, cancel, err := r.Watch(ctx, rockferry.WatchActionUpdate, rockferry.ResourceKindStorageVolume, "", nil, WatchOptions{})
 	if err != nil {
 		return err
 	}
 
+	// NOTE: The volumes may have been created before the watch started, when resuming.
 	for {
+		filled, err := r.collectVolumeKeys(ctx, req)
+		if err != nil {
+			return err
+		}
+
+		if filled {
+			return nil
+		}
+
 		select {
+		case <-ctx.Done():
+			return ctx.Err()
 		case <-cancel:
-			{
-				return rockferry.ErrorStreamClosed
-			}
-		case e := <-stream:
-			{
-				volume := rockferry.CastFromMap[spec.StorageVolumeSpec, rockferry.DefaultStatus](e.Resource)
-
-				for _, o := range volumes {
-					if volume.Id == o.Id && volume.Phase == rockferry.PhaseRequested {
-						for i, d := range req.Spec.Disks {
-							if d.Volume == volume.Id {
-								req.Spec.Disks[i].Key = volume.Spec.Key
-							}
-						}
-					}
-				}
-
-				filled := true // Assume all disks have a key initially
-				for _, d := range req.Spec.Disks {
-					if d.Key == "" {
-						filled = false // Found a disk without a key
-						break          // Exit loop early
-					}
-				}
-
-				if filled {
-					return nil
-				}
-			}
+			return rockferry.ErrorStreamClosed
+		case <-stream:
+		}
+	}
+}
+
+// Pools and networks marked with this selector are used when a request leaves them empty.
+var defaultSelector = rockferry.Selector{{Key: scheduler.AnnotationDefault, Operator: rockferry.SelectorOperatorEquals, Values: []string{"yes"}}}
+
+// Fills in the default pool and network of the node owning req where they were left empty.
+func (r *Runtime) fillNodeDefaults(ctx context.Context, req *rockferry.MachineRequest) error {
+	for _, disk := range req.Spec.Disks {
+		if disk.Pool != "" || disk.Volume != "" {
+			continue
+		}
+
+		pool, err := r.Get(ctx, rockferry.ResourceKindStoragePool, "", req.Owner, defaultSelector, nil)
+		if err != nil {
+			return fmt.Errorf("%s: %w", ClusterAllocationErrorNoDefaultStoragePool, err)
 		}
+
+		disk.Pool = pool.Id
+	}
+
+	if req.Spec.Network == "" {
+		network, err := r.Get(ctx, rockferry.ResourceKindNetwork, "", req.Owner, defaultSelector, nil)
+		if err != nil {
+			return fmt.Errorf("%s: %w", ClusterAllocationErrorNoDefaultNetwork, err)
+		}
+
+		req.Spec.Network = network.Id
 	}
+
+	return nil
 }
 
+// Allocates the resources of req and marks it requested. Every write is guarded
+// by the version the allocation read, a request changed meanwhile is read again
+// and allocated from scratch. Volumes which were already created are reused.
 func (r *Runtime) AllocateMachineResources(ctx context.Context, req *rockferry.MachineRequest) error {
-	req.Phase = rockferry.PhaseRequested
+	return rockferry.RetryOnConflict(ctx, func() error {
+		if err := r.allocateMachineRequestResources(ctx, req); err != rockferry.ErrorConflict {
+			return err
+		}
+
+		generic, err := r.Fetch(ctx, rockferry.ResourceKindMachineRequest, req.Id)
+		if err == rockferry.ErrorNotFound {
+			return nil
+		}
+
+		if err != nil {
+			return err
+		}
+
+		if !allocatable(generic) {
+			return nil
+		}
+
+		req = rockferry.CastFromMap[spec.MachineRequestSpec, spec.MachineRequestStatus](generic)
+		return rockferry.ErrorConflict
+	})
+}
+
+func (r *Runtime) allocateMachineRequestResources(ctx context.Context, req *rockferry.MachineRequest) error {
+	if err := r.fillNodeDefaults(ctx, req); err != nil {
+		return err
+	}
 
 	if err := r.allocateMachineVolumes(ctx, req); err != nil {
 		return err
 	}
 
+	// NOTE: The node creates the machine once the request is requested, so this
+	// comes last.
+	req.Phase = rockferry.PhaseRequested
+	req.Status.Error = nil
+	req.Status.Conditions.Set(spec.NewCondition(spec.MachineRequestConditionAllocated, spec.ConditionStatusTrue, "Allocated", ""))
+
 	return r.Update(ctx, req.Generic())
 }
@@ -0,0 +1,78 @@
+package runtime
+
+import (
+	"context"
+	"testing"
+
+	"github.com/eskpil/rockferry/internal/controller/scheduler"
+	"github.com/eskpil/rockferry/internal/controller/store"
+	"github.com/eskpil/rockferry/pkg/rockferry"
+	"github.com/eskpil/rockferry/pkg/rockferry/spec"
+)
+
+func TestAllocateChangedMachineRequest(t *testing.T) {
+	ctx := context.Background()
+
+	s := store.NewMemory()
+	t.Cleanup(func() { s.Close() })
+
+	r := New(s)
+
+	node := &rockferry.OwnerRef{Kind: rockferry.ResourceKindNode, Id: "node"}
+
+	network := new(rockferry.Network)
+	network.Id = "default"
+	network.Kind = rockferry.ResourceKindNetwork
+	network.Owner = node
+	network.Annotations = map[string]string{scheduler.AnnotationDefault: "yes"}
+
+	if err := r.Update(ctx, network.Generic()); err != nil {
+		t.Fatal(err)
+	}
+
+	req := new(rockferry.MachineRequest)
+	req.Id = "request"
+	req.Kind = rockferry.ResourceKindMachineRequest
+	req.Owner = node
+	req.Phase = rockferry.PhasePreProcessing
+
+	if err := r.Update(ctx, req.Generic()); err != nil {
+		t.Fatal(err)
+	}
+
+	generic, err := r.Fetch(ctx, rockferry.ResourceKindMachineRequest, req.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stale := rockferry.CastFromMap[spec.MachineRequestSpec, spec.MachineRequestStatus](generic)
+
+	// NOTE: Changed after the allocation read it, the allocation has to start
+	// over from the change rather than overwrite it.
+	changed := rockferry.CastFromMap[spec.MachineRequestSpec, spec.MachineRequestStatus](generic)
+	changed.Annotations = map[string]string{"changed": "yes"}
+	changed.Spec.Network = "other"
+
+	if err := r.Update(ctx, changed.Generic()); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := r.AllocateMachineResources(ctx, stale); err != nil {
+		t.Fatal(err)
+	}
+
+	generic, err = r.Fetch(ctx, rockferry.ResourceKindMachineRequest, req.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	allocated := rockferry.CastFromMap[spec.MachineRequestSpec, spec.MachineRequestStatus](generic)
+
+	if allocated.Phase != rockferry.PhaseRequested || allocated.Spec.Network != "other" {
+		t.Fatalf("request is %s on network %q after allocating", allocated.Phase, allocated.Spec.Network)
+	}
+
+	if allocated.Annotations["changed"] != "yes" {
+		t.Fatal("allocating overwrote the annotations set meanwhile")
+	}
+}
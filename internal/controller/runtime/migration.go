@@ -0,0 +1,34 @@
+package runtime
+
+import (
+	"context"
+
+	"github.com/eskpil/rockferry/pkg/rockferry"
+	"github.com/eskpil/rockferry/pkg/rockferry/spec"
+)
+
+// Hands the migration to the node the machine is on, which performs it.
+func (r *Runtime) prepareMachineMigration(ctx context.Context, resource *rockferry.Generic) error {
+	migration := rockferry.CastFromMap[spec.MachineMigrationSpec, spec.MachineMigrationStatus](resource)
+
+	machine, err := r.Fetch(ctx, rockferry.ResourceKindMachine, migration.Spec.Machine)
+	if err != nil {
+		return err
+	}
+
+	resource.Owner = new(rockferry.OwnerRef)
+	*resource.Owner = *machine.Owner
+
+	// NOTE: A migration is meaningless once the machine is gone.
+	resource.Parents = []*rockferry.OwnerRef{{Kind: rockferry.ResourceKindMachine, Id: machine.Id}}
+
+	status := new(spec.MachineMigrationStatus)
+	status.State = spec.MachineMigrationStatusStatePending
+	status.SourceNode = machine.Owner.Id
+	status.Conditions.Set(spec.NewCondition(spec.ConditionReady, spec.ConditionStatusFalse, "Pending", ""))
+
+	resource.Status = status
+	resource.Phase = rockferry.PhaseRequested
+
+	return nil
+}
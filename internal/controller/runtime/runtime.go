@@ -4,25 +4,55 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"os"
+	"time"
 
 	"github.com/eskpil/rockferry/internal/controller/models"
+	"github.com/eskpil/rockferry/internal/controller/scheduler"
+	"github.com/eskpil/rockferry/internal/controller/store"
+	"github.com/eskpil/rockferry/internal/controller/validation"
 	"github.com/eskpil/rockferry/pkg/rockferry"
 	"github.com/eskpil/rockferry/pkg/rockferry/spec"
 	jsonpatch "github.com/evanphx/json-patch/v5"
 	"github.com/google/uuid"
-	clientv3 "go.etcd.io/etcd/client/v3"
 )
 
 type Runtime struct {
-	Db *clientv3.Client
+	Store store.Store
+
+	// Tells controllers sharing the store apart.
+	Identity string
+
+	// How long a node has to be not ready before its machines are failed over.
+	FailoverGracePeriod time.Duration
+
+	// How long events are kept after they were last seen.
+	EventTTL time.Duration
+
+	// How many vcpus are handed out for every thread of a node.
+	CpuOvercommit uint64
 }
 
-func New(db *clientv3.Client) *Runtime {
+func New(s store.Store) *Runtime {
 	r := new(Runtime)
-	r.Db = db
+	r.Store = s
+	r.Identity = identity()
+	r.FailoverGracePeriod = defaultFailoverGracePeriod
+	r.EventTTL = defaultEventTTL
+	r.CpuOvercommit = scheduler.DefaultCpuOvercommit
 	return r
 }
 
+// The hostname, made unique in case several controllers run on the same host.
+func identity() string {
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "controller"
+	}
+
+	return fmt.Sprintf("%s-%s", hostname, uuid.NewString()[:8])
+}
+
 func (r *Runtime) resourcePreCreate(ctx context.Context, resource *rockferry.Generic) error {
 	switch resource.Kind {
 	case rockferry.ResourceKindMachineRequest:
@@ -34,10 +64,14 @@ func (r *Runtime) resourcePreCreate(ctx context.Context, resource *rockferry.Gen
 	case rockferry.ResourceKindMachine:
 		resource.Phase = rockferry.PhaseCreated
 		break
+	case rockferry.ResourceKindBootstrapToken, rockferry.ResourceKindRole, rockferry.ResourceKindRoleBinding, rockferry.ResourceKindEvent:
+		resource.Phase = rockferry.PhaseCreated
 	case rockferry.ResourceKindStorageVolume:
 		volume := rockferry.CastFromMap[spec.StorageVolumeSpec, rockferry.DefaultStatus](resource)
 		resource.Id = fmt.Sprintf("%s/%s", volume.Owner.Id, volume.Spec.Name)
 		resource.Phase = rockferry.PhaseRequested
+	case rockferry.ResourceKindMachineMigration:
+		return r.prepareMachineMigration(ctx, resource)
 	default:
 		resource.Phase = rockferry.PhaseRequested
 	}
@@ -45,27 +79,34 @@ func (r *Runtime) resourcePreCreate(ctx context.Context, resource *rockferry.Gen
 	return nil
 }
 
-func (r *Runtime) resourcePostCreate(ctx context.Context, resource *rockferry.Generic) {
-	var err error
+// The amount of times a patch without an expected resource version is
+// reapplied when a concurrent write sneaks in between reading and writing.
+const patchMaxAttempts = 5
 
-	switch resource.Kind {
-	case rockferry.ResourceKindClusterRequest:
-		err = r.AllocateKubernetesCluster(context.WithoutCancel(ctx), rockferry.CastFromMap[spec.ClusterRequestSpec, rockferry.DefaultStatus](resource))
-		break
-	case rockferry.ResourceKindMachineRequest:
-		req := rockferry.CastFromMap[spec.MachineRequestSpec, rockferry.DefaultStatus](resource)
-		err = r.AllocateMachineResources(context.WithoutCancel(ctx), req)
-		break
-	default:
-		break
+func decodeResource(value []byte, revision int64) (*rockferry.Generic, error) {
+	resource := new(rockferry.Generic)
+	if err := json.Unmarshal(value, resource); err != nil {
+		return nil, err
 	}
 
-	if err != nil {
-		fmt.Println("failed to process resource", err)
-		panic(err)
+	resource.ResourceVersion = revision
+
+	return resource, nil
+}
+
+// The resource stored at path, nil if there is none.
+func (r *Runtime) stored(ctx context.Context, path string) (*rockferry.Generic, error) {
+	kv, _, err := r.Store.Get(ctx, path)
+	if err != nil || kv == nil {
+		return nil, err
 	}
+
+	return decodeResource(kv.Value, kv.ModRevision)
 }
 
+// Update replaces the stored resource. If the resource carries a resource
+// version the write only succeeds if the stored resource is still at that
+// version, otherwise ErrorConflict is returned.
 func (r *Runtime) Update(ctx context.Context, resource *rockferry.Generic) error {
 	if resource == nil {
 		return rockferry.ErrorBadArguments
@@ -75,51 +116,226 @@ func (r *Runtime) Update(ctx context.Context, resource *rockferry.Generic) error
 		return rockferry.ErrorBadArguments
 	}
 
-	path := fmt.Sprintf("%s/%s/%s", models.RootKey, resource.Kind, resource.Id)
-	bytes, err := resource.Marshal()
+	path := models.ResourceKey(resource.Kind, resource.Id)
+
+	previous, err := r.stored(ctx, path)
 	if err != nil {
 		return err
 	}
 
-	_, err = r.Db.Put(ctx, path, string(bytes))
-	return err
-}
+	// NOTE: Checked by the write as well, this only saves bumping the generation over a stale spec.
+	if resource.ResourceVersion != 0 && (previous == nil || previous.ResourceVersion != resource.ResourceVersion) {
+		return rockferry.ErrorConflict
+	}
+
+	resource.Generation = nextGeneration(previous, resource)
 
-func (r *Runtime) Patch(ctx context.Context, kind rockferry.ResourceKind, id string, patch jsonpatch.Patch) error {
-	path := fmt.Sprintf("%s/%s/%s", models.RootKey, kind, id)
+	// NOTE: The version is derived from the store, there is no point in storing it.
+	stored := *resource
+	stored.ResourceVersion = 0
 
-	res, err := r.Db.Get(ctx, path)
+	bytes, err := stored.Marshal()
 	if err != nil {
-		fmt.Println("failed to fetch resource", err)
-		return rockferry.ErrorInternalServerError
+		return err
+	}
+
+	if resource.ResourceVersion == 0 {
+		_, err = r.Store.Put(ctx, path, bytes)
+		return err
 	}
 
-	if 0 >= len(res.Kvs) {
-		return rockferry.ErrorNotFound
+	conditions := []store.Condition{{Key: path, ModRevision: resource.ResourceVersion}}
+
+	succeeded, revision, err := r.Store.Txn(ctx, conditions, store.OpPut(path, bytes))
+	if err != nil {
+		return err
 	}
 
-	if 2 <= len(res.Kvs) {
-		return rockferry.ErrorUnexpectedResults
+	if !succeeded {
+		return rockferry.ErrorConflict
 	}
 
-	original := res.Kvs[0].Value
+	resource.ResourceVersion = revision
 
-	modified, err := patch.Apply(original)
-	if err != nil {
-		panic(err)
+	return nil
+}
+
+// PatchCheck is called with the stored and the patched resource before a patch
+// is written, the patch is refused with its error.
+type PatchCheck func(ctx context.Context, previous *rockferry.Generic, patched *rockferry.Generic) error
+
+// Patch applies patch to the spec of the stored resource and returns the new resource
+// version and generation. The write is guarded by the revision the patch was applied to. If
+// resourceVersion is non zero the patch is only applied to that exact version,
+// otherwise the patch is reapplied to the latest version when a concurrent write
+// is detected. The patched resource has to pass validation and check, if any.
+func (r *Runtime) Patch(ctx context.Context, kind rockferry.ResourceKind, id string, patch jsonpatch.Patch, resourceVersion int64, check PatchCheck) (int64, int64, error) {
+	if err := validatePatchPaths(patch, false); err != nil {
+		return 0, 0, err
 	}
 
-	generic := new(rockferry.Generic)
-	if err := json.Unmarshal(modified, generic); err != nil {
-		return rockferry.ErrorInternalServerError
+	return r.patch(ctx, kind, id, patch, resourceVersion, true, check)
+}
+
+// PatchStatus is like Patch, but the patch may only touch the status and phase.
+func (r *Runtime) PatchStatus(ctx context.Context, kind rockferry.ResourceKind, id string, patch jsonpatch.Patch, resourceVersion int64, check PatchCheck) (int64, int64, error) {
+	if err := validatePatchPaths(patch, true); err != nil {
+		return 0, 0, err
 	}
 
-	_, err = r.Db.Put(ctx, path, string(modified))
-	if err != nil {
-		return rockferry.ErrorInternalServerError
+	return r.patch(ctx, kind, id, patch, resourceVersion, false, check)
+}
+
+func (r *Runtime) patch(ctx context.Context, kind rockferry.ResourceKind, id string, patch jsonpatch.Patch, resourceVersion int64, validate bool, check PatchCheck) (int64, int64, error) {
+	path := models.ResourceKey(kind, id)
+
+	for range patchMaxAttempts {
+		original, _, err := r.Store.Get(ctx, path)
+		if err != nil {
+			fmt.Println("failed to fetch resource", err)
+			return 0, 0, rockferry.ErrorInternalServerError
+		}
+
+		if original == nil {
+			return 0, 0, rockferry.ErrorNotFound
+		}
+		if resourceVersion != 0 && original.ModRevision != resourceVersion {
+			return 0, 0, rockferry.ErrorConflict
+		}
+
+		modified, err := patch.Apply(original.Value)
+		if err != nil {
+			return 0, 0, rockferry.ErrorBadArguments
+		}
+
+		generic := new(rockferry.Generic)
+		if err := json.Unmarshal(modified, generic); err != nil {
+			return 0, 0, rockferry.ErrorInternalServerError
+		}
+
+		previous, err := decodeResource(original.Value, original.ModRevision)
+		if err != nil {
+			return 0, 0, rockferry.ErrorInternalServerError
+		}
+
+		if validate {
+			if err := validation.Validate(ctx, r, generic, previous); err != nil {
+				return 0, 0, err
+			}
+		}
+
+		if check != nil {
+			if err := check(ctx, previous, generic); err != nil {
+				return 0, 0, err
+			}
+		}
+
+		// NOTE: The generation is the controller's to keep, whatever the patch did to it.
+		if generation := nextGeneration(previous, generic); generation != generic.Generation {
+			generic.Generation = generation
+
+			modified, err = withGeneration(modified, generation)
+			if err != nil {
+				return 0, 0, rockferry.ErrorInternalServerError
+			}
+		}
+
+		if !sameTimestamp(previous.DeletionTimestamp, generic.DeletionTimestamp) {
+			return 0, 0, rockferry.ErrorDeletionTimestamp
+		}
+
+		// NOTE: Whoever created the resource may be granted access to it because of it.
+		if previous.Annotations[rockferry.AnnotationCreator] != generic.Annotations[rockferry.AnnotationCreator] {
+			return 0, 0, rockferry.ErrorCreatorAnnotation
+		}
+
+		op := store.OpPut(path, modified)
+
+		// NOTE: The last finalizer is gone, nothing is holding the deletion back anymore.
+		if generic.Deleting() && len(generic.Finalizers) == 0 {
+			op = store.OpDelete(path)
+		}
+
+		conditions := []store.Condition{{Key: path, ModRevision: original.ModRevision}}
+
+		succeeded, revision, err := r.Store.Txn(ctx, conditions, op)
+		if err != nil {
+			return 0, 0, rockferry.ErrorInternalServerError
+		}
+
+		if succeeded {
+			return revision, generic.Generation, nil
+		}
+
+		if resourceVersion != 0 {
+			return 0, 0, rockferry.ErrorConflict
+		}
 	}
 
-	return nil
+	return 0, 0, rockferry.ErrorConflict
+}
+
+func sameTimestamp(a *time.Time, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+
+	return a.Equal(*b)
+}
+
+// Delete removes the resource right away if it has no finalizers. Otherwise the
+// deletion timestamp is set and the resource is removed by the patch which clears
+// the last finalizer. Deleting a resource which is already being deleted does nothing.
+func (r *Runtime) Delete(ctx context.Context, kind rockferry.ResourceKind, id string) error {
+	path := models.ResourceKey(kind, id)
+
+	for range patchMaxAttempts {
+		kv, _, err := r.Store.Get(ctx, path)
+		if err != nil {
+			return err
+		}
+
+		if kv == nil {
+			return rockferry.ErrorNotFound
+		}
+
+		resource, err := decodeResource(kv.Value, kv.ModRevision)
+		if err != nil {
+			return err
+		}
+
+		if resource.Deleting() {
+			return nil
+		}
+
+		op := store.OpDelete(path)
+
+		if len(resource.Finalizers) > 0 {
+			now := time.Now().UTC()
+			resource.DeletionTimestamp = &now
+			resource.ResourceVersion = 0
+
+			bytes, err := resource.Marshal()
+			if err != nil {
+				return err
+			}
+
+			op = store.OpPut(path, bytes)
+		}
+
+		conditions := []store.Condition{{Key: path, ModRevision: kv.ModRevision}}
+
+		succeeded, _, err := r.Store.Txn(ctx, conditions, op)
+		if err != nil {
+			return err
+		}
+
+		if succeeded {
+			return nil
+		}
+	}
+
+	return rockferry.ErrorConflict
 }
 
 func (r *Runtime) CreateResource(ctx context.Context, resource *rockferry.Generic) error {
@@ -127,124 +343,247 @@ func (r *Runtime) CreateResource(ctx context.Context, resource *rockferry.Generi
 		resource.Id = uuid.NewString()
 	}
 
+	// NOTE: Only Delete may mark a resource for deletion.
+	resource.DeletionTimestamp = nil
+
+	if err := validation.Validate(ctx, r, resource, nil); err != nil {
+		return err
+	}
+
 	// Code run before the resource is created. This can be used for validating
 	// the request. Creating some required sources.
 	if err := r.resourcePreCreate(context.Background(), resource); err != nil {
 		return err
 	}
 
-	path := fmt.Sprintf("%s/%s/%s", models.RootKey, resource.Kind, resource.Id)
+	path := models.ResourceKey(resource.Kind, resource.Id)
+
+	resource.Generation = 1
+	resource.ResourceVersion = 0
+
 	bytes, err := resource.Marshal()
 	if err != nil {
 		return err
 	}
 
-	_, err = r.Db.Put(ctx, path, string(bytes))
+	// NOTE: Creating is only authorized against the new resource, so it must
+	// never replace one which exists. Existing resources are patched instead.
+	conditions := []store.Condition{{Key: path}}
+
+	succeeded, revision, err := r.Store.Txn(ctx, conditions, store.OpPut(path, bytes))
 	if err != nil {
 		return err
 	}
 
-	// Code ran after the resource has been created,
-	// suitable for tasks which needs to be run in a
-	// asyncrnous fashion.
-	go r.resourcePostCreate(context.Background(), resource)
+	if !succeeded {
+		return rockferry.ErrorAlreadyExists
+	}
+
+	resource.ResourceVersion = revision
+
+	// NOTE: Requests are allocated by the leader, see RunAllocator. Whichever
+	// controller received the request may go away before the allocation is done.
 
 	return nil
 }
 
-func (r *Runtime) Watch(ctx context.Context, action rockferry.WatchAction, kind rockferry.ResourceKind, id string, owner *rockferry.OwnerRef) (chan *rockferry.WatchEvent[any, any], chan interface{}, error) {
+// Reports whether a resource of kind with the given id is stored.
+func (r *Runtime) Exists(ctx context.Context, kind rockferry.ResourceKind, id string) (bool, error) {
+	kv, _, err := r.Store.Get(ctx, models.ResourceKey(kind, id))
+	if err != nil {
+		return false, err
+	}
+
+	return kv != nil, nil
+}
+
+func (r *Runtime) Fetch(ctx context.Context, kind rockferry.ResourceKind, id string) (*rockferry.Generic, error) {
+	return r.Get(ctx, kind, id, nil, nil, nil)
+}
+
+// Lists the resources of kind owned by owner, which may be none.
+func (r *Runtime) ListOwned(ctx context.Context, kind rockferry.ResourceKind, owner *rockferry.OwnerRef) ([]*rockferry.Generic, error) {
+	resources, err := r.List(ctx, kind, "", owner, nil, nil)
+	if err == rockferry.ErrorNotFound {
+		return nil, nil
+	}
+
+	return resources, err
+}
+
+// How often watches which asked for bookmarks are told about the current revision.
+const bookmarkInterval = 30 * time.Second
+
+type WatchOptions struct {
+	Labels rockferry.Selector
+	Fields rockferry.Selector
+
+	// Only changes made after this revision are returned, zero starts at the
+	// current revision. Fails with ErrorCompacted if the revision is no longer
+	// available.
+	Revision int64
+
+	// Periodically emit events without a resource, carrying the revision the
+	// watch has caught up to. Allows resuming a watch which rarely sees events.
+	Bookmarks bool
+}
+
+// Events are filtered on the resource after the change. A resource which no
+// longer matches the owner or selectors is reported as deleted, one which only
+// now matches them as created. The reason the watch ended is sent on the
+// returned error channel.
+func (r *Runtime) Watch(ctx context.Context, action rockferry.WatchAction, kind rockferry.ResourceKind, id string, owner *rockferry.OwnerRef, options WatchOptions) (chan *rockferry.WatchEvent[any, any], chan error, error) {
 	out := make(chan *rockferry.WatchEvent[any, any])
-	cancel := make(chan interface{})
-	var opts []clientv3.OpOption
+	cancel := make(chan error, 1)
 
 	// Build watch path
-	path := fmt.Sprintf("%s/%s/%s", models.RootKey, kind, id)
-	if id == "" {
-		opts = append(opts, clientv3.WithPrefix())
-		path = fmt.Sprintf("%s/%s", models.RootKey, kind)
+	path := models.ResourceKey(kind, id)
+	prefix := id == ""
+	if prefix {
+		path = models.KindPrefix(kind)
 	} else if kind == rockferry.ResourceKindStorageVolume && owner != nil {
 		// Use owner ID instead of resource ID for StorageVolume (refactor to avoid special case if possible)
-		path = fmt.Sprintf("%s/%s/%s", models.RootKey, kind, owner.Id)
+		path = models.ResourceKey(kind, owner.Id)
 	}
 
-	if action == rockferry.WatchActionDelete || action == rockferry.WatchActionUpdate {
-		opts = append(opts, clientv3.WithPrevKV())
+	watchChannel := r.Store.Watch(ctx, path, prefix, options.Revision)
+
+	if options.Bookmarks {
+		go func() {
+			ticker := time.NewTicker(bookmarkInterval)
+			defer ticker.Stop()
+
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case <-ticker.C:
+					// NOTE: Answered with a progress notification on every watch sharing this context.
+					if err := r.Store.RequestProgress(ctx); err != nil {
+						fmt.Println("failed to request watch progress", err)
+					}
+				}
+			}
+		}()
 	}
 
-	watchChannel := r.Db.Watch(ctx, path, opts...)
+	send := func(e *rockferry.WatchEvent[any, any]) bool {
+		select {
+		case out <- e:
+			return true
+		case <-ctx.Done():
+			return false
+		}
+	}
 
 	go func() {
 		defer close(out)
 		defer close(cancel)
 
 		for w := range watchChannel {
-			if w.Canceled {
-				cancel <- new(interface{})
+			if w.Err == store.ErrCompacted {
+				cancel <- rockferry.ErrorCompacted
 				return
 			}
 
-			if err := w.Err(); err != nil {
-				fmt.Println("Watch error:", err)
-				cancel <- new(interface{})
+			if w.Err != nil {
+				fmt.Println("Watch error:", w.Err)
+				cancel <- rockferry.ErrorStreamClosed
 				return
 			}
 
+			if w.Progress {
+				if !options.Bookmarks {
+					continue
+				}
+
+				bookmark := new(rockferry.WatchEvent[any, any])
+				bookmark.Bookmark = true
+				bookmark.Revision = w.Revision
+
+				if !send(bookmark) {
+					return
+				}
+
+				continue
+			}
+
 			for _, event := range w.Events {
 				var usedAction rockferry.WatchAction
 				switch {
 				case event.IsCreate():
 					usedAction = rockferry.WatchActionCreate
-				case event.IsModify():
+				case event.Type == store.EventPut:
 					usedAction = rockferry.WatchActionUpdate
 				default:
 					usedAction = rockferry.WatchActionDelete
 				}
 
-				// Allow fallback on WatchActionAll
-				if action != rockferry.WatchActionAll {
-					if (usedAction == rockferry.WatchActionCreate && action != rockferry.WatchActionCreate) ||
-						(usedAction == rockferry.WatchActionUpdate && action != rockferry.WatchActionUpdate) ||
-						(usedAction == rockferry.WatchActionDelete && action != rockferry.WatchActionDelete) {
-						continue
-					}
+				if event.Kv == nil {
+					continue
 				}
 
-				if event.PrevKv != nil && action == rockferry.WatchActionDelete {
-					event.Kv = event.PrevKv
-				}
+				revision := event.Kv.ModRevision
 
-				if event.Kv == nil {
-					continue
+				// NOTE: Deletions carry no value, the resource is decoded from the previous one.
+				// Events are shared between watches, so they must not be modified.
+				kv := event.Kv
+				if event.Prev != nil && usedAction == rockferry.WatchActionDelete {
+					kv = event.Prev
 				}
 
-				resource := new(rockferry.Generic)
-				if err := json.Unmarshal(event.Kv.Value, resource); err != nil {
+				resource, err := decodeResource(kv.Value, kv.ModRevision)
+				if err != nil {
 					fmt.Println("JSON unmarshal error:", err)
 					continue
 				}
 
-				if owner != nil && owner.Id != "" && owner.Kind != "" && resource.Owner != nil {
-					if owner.Id != resource.Owner.Id && owner.Kind != resource.Owner.Kind {
+				var prev *rockferry.Generic
+				if event.Prev != nil && usedAction != rockferry.WatchActionDelete {
+					if prev, err = decodeResource(event.Prev.Value, event.Prev.ModRevision); err != nil {
+						fmt.Println("JSON unmarshal error:", err)
 						continue
 					}
 				}
 
+				matched := matches(resource, owner, options.Labels, options.Fields)
+				matchedPrev := prev != nil && matches(prev, owner, options.Labels, options.Fields)
+
+				// NOTE: Watchers only know of resources matching their filter. A
+				// resource entering the filter is new to them and one leaving it
+				// is gone, even though it was only updated.
+				switch {
+				case !matched && matchedPrev:
+					usedAction = rockferry.WatchActionDelete
+					prev = nil
+				case matched && prev != nil && !matchedPrev:
+					usedAction = rockferry.WatchActionCreate
+					prev = nil
+				case !matched:
+					continue
+				}
+
+				// Allow fallback on WatchActionAll
+				if action != rockferry.WatchActionAll && usedAction != action {
+					continue
+				}
+
 				ret := new(rockferry.WatchEvent[any, any])
 
 				ret.Action = usedAction
 				ret.Resource = resource
+				ret.Prev = prev
+				ret.Revision = revision
+				ret.SpecChanged = true
+				ret.StatusChanged = true
 
-				if event.PrevKv != nil && action != rockferry.WatchActionDelete {
-					prev := new(rockferry.Generic)
-					if err := json.Unmarshal(event.PrevKv.Value, prev); err != nil {
-						fmt.Println("JSON unmarshal error:", err)
-						continue
-					}
-
-					ret.Prev = prev
+				if prev != nil {
+					ret.SpecChanged, ret.StatusChanged = changedSubresources(prev, resource)
 				}
 
-				out <- ret
+				if !send(ret) {
+					return
+				}
 			}
 		}
 	}()
@@ -252,13 +591,17 @@ func (r *Runtime) Watch(ctx context.Context, action rockferry.WatchAction, kind
 	return out, cancel, nil
 }
 
-// Caller can provide a set of annotations which much match.
-func (r *Runtime) Get(ctx context.Context, kind rockferry.ResourceKind, id string, owner *rockferry.OwnerRef, annotations map[string]string) (*rockferry.Generic, error) {
-	resources, err := r.List(ctx, kind, id, owner, annotations)
+// Caller can provide label and field selectors which must match.
+func (r *Runtime) Get(ctx context.Context, kind rockferry.ResourceKind, id string, owner *rockferry.OwnerRef, labels rockferry.Selector, fields rockferry.Selector) (*rockferry.Generic, error) {
+	resources, err := r.List(ctx, kind, id, owner, labels, fields)
 	if err != nil {
 		return nil, err
 	}
 
+	if len(resources) == 0 {
+		return nil, rockferry.ErrorNotFound
+	}
+
 	if len(resources) > 1 {
 		return nil, rockferry.ErrorUnexpectedResults
 	}
@@ -266,52 +609,56 @@ func (r *Runtime) Get(ctx context.Context, kind rockferry.ResourceKind, id strin
 	return resources[0], nil
 }
 
-func (r *Runtime) List(ctx context.Context, kind rockferry.ResourceKind, id string, owner *rockferry.OwnerRef, annotations map[string]string) ([]*rockferry.Generic, error) {
-	opts := []clientv3.OpOption{}
+func (r *Runtime) List(ctx context.Context, kind rockferry.ResourceKind, id string, owner *rockferry.OwnerRef, labels rockferry.Selector, fields rockferry.Selector) ([]*rockferry.Generic, error) {
+	var kvs []*store.KeyValue
+
 	if id == "" {
-		opts = append(opts, clientv3.WithPrefix())
-	}
+		results, _, err := r.Store.List(ctx, models.KindPrefix(kind))
+		if err != nil {
+			return nil, err
+		}
 
-	path := fmt.Sprintf("%s/%s/%s", models.RootKey, kind, id)
+		kvs = results
+	} else {
+		kv, _, err := r.Store.Get(ctx, models.ResourceKey(kind, id))
+		if err != nil {
+			return nil, err
+		}
 
-	results, err := r.Db.Get(ctx, path, opts...)
-	if err != nil {
-		return nil, err
+		if kv != nil {
+			kvs = append(kvs, kv)
+		}
 	}
 
-	if 0 >= len(results.Kvs) {
+	if 0 >= len(kvs) {
 		return nil, rockferry.ErrorNotFound
 	}
 
 	var output []*rockferry.Generic
 
-	for _, kv := range results.Kvs {
-		resource := new(rockferry.Generic)
-		if err := json.Unmarshal(kv.Value, resource); err != nil {
+	for _, kv := range kvs {
+		resource, err := decodeResource(kv.Value, kv.ModRevision)
+		if err != nil {
 			panic(err)
 		}
 
-		if owner != nil && resource.Owner != nil && *owner != *resource.Owner {
+		if !matches(resource, owner, labels, fields) {
 			continue
 		}
 
-		if len(annotations) > 0 {
-			match := false
-			for k, o := range annotations {
-				v, ok := resource.Annotations[k]
-				if ok && v == o {
-					match = true
-				}
-			}
-
-			if !match {
-				continue
-			}
-		}
-
 		output = append(output, resource)
-
 	}
 
 	return output, nil
 }
+
+// An owner without both kind and id set matches every resource.
+func matches(resource *rockferry.Generic, owner *rockferry.OwnerRef, labels rockferry.Selector, fields rockferry.Selector) bool {
+	if owner != nil && owner.Id != "" && owner.Kind != "" {
+		if resource.Owner == nil || *resource.Owner != *owner {
+			return false
+		}
+	}
+
+	return labels.MatchesLabels(resource.Annotations) && fields.MatchesFields(resource)
+}
@@ -0,0 +1,250 @@
+package runtime
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/eskpil/rockferry/internal/controller/scheduler"
+	"github.com/eskpil/rockferry/pkg/rockferry"
+	"github.com/eskpil/rockferry/pkg/rockferry/spec"
+	"github.com/siderolabs/go-pointer"
+)
+
+// How often requests which could not be placed are tried again.
+const scheduleInterval = 30 * time.Second
+
+// Like List, but a kind without any resources is not an error.
+func (r *Runtime) listKind(ctx context.Context, kind rockferry.ResourceKind) ([]*rockferry.Generic, error) {
+	resources, err := r.List(ctx, kind, "", nil, nil, nil)
+	if err == rockferry.ErrorNotFound {
+		return nil, nil
+	}
+
+	return resources, err
+}
+
+func ownedByNode(resource *rockferry.Generic) (string, bool) {
+	if resource.Owner == nil || resource.Owner.Kind != rockferry.ResourceKindNode {
+		return "", false
+	}
+
+	return resource.Owner.Id, true
+}
+
+// Collects what is placed on every node.
+func (r *Runtime) nodeInfos(ctx context.Context) ([]*scheduler.NodeInfo, error) {
+	infos := map[string]*scheduler.NodeInfo{}
+	list := []*scheduler.NodeInfo{}
+
+	nodes, err := r.listKind(ctx, rockferry.ResourceKindNode)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, node := range nodes {
+		info := new(scheduler.NodeInfo)
+		info.CpuOvercommit = r.CpuOvercommit
+		info.Node = rockferry.CastFromMap[spec.NodeSpec, spec.NodeStatus](node)
+
+		infos[node.Id] = info
+		list = append(list, info)
+	}
+
+	pools, err := r.listKind(ctx, rockferry.ResourceKindStoragePool)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, pool := range pools {
+		if id, ok := ownedByNode(pool); ok && infos[id] != nil {
+			infos[id].Pools = append(infos[id].Pools, rockferry.CastFromMap[spec.StoragePoolSpec, rockferry.DefaultStatus](pool))
+		}
+	}
+
+	networks, err := r.listKind(ctx, rockferry.ResourceKindNetwork)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, network := range networks {
+		if id, ok := ownedByNode(network); ok && infos[id] != nil {
+			infos[id].Networks = append(infos[id].Networks, rockferry.CastFromMap[spec.NetworkSpec, rockferry.DefaultStatus](network))
+		}
+	}
+
+	requests, err := r.listKind(ctx, rockferry.ResourceKindMachineRequest)
+	if err != nil {
+		return nil, err
+	}
+
+	// The node every request was placed on.
+	placed := map[string]string{}
+
+	for _, req := range requests {
+		if id, ok := ownedByNode(req); ok && infos[id] != nil && !req.Deleting() {
+			infos[id].Requests = append(infos[id].Requests, rockferry.CastFromMap[spec.MachineRequestSpec, spec.MachineRequestStatus](req))
+			placed[req.Id] = id
+		}
+	}
+
+	machines, err := r.listKind(ctx, rockferry.ResourceKindMachine)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, machine := range machines {
+		id, ok := ownedByNode(machine)
+		if !ok || infos[id] == nil || machine.Deleting() {
+			continue
+		}
+
+		// NOTE: Machines still on the node of their request are already accounted for by the request.
+		if placed[machine.Annotations["machinerequest.id"]] == id {
+			continue
+		}
+
+		infos[id].Machines = append(infos[id].Machines, rockferry.CastFromMap[spec.MachineSpec, spec.MachineStatus](machine))
+	}
+
+	return list, nil
+}
+
+// Picks a node for a request without an owner. Once placed the resources of the
+// request are allocated on the node, otherwise the reasons are recorded in the
+// status and the request is tried again later.
+func (r *Runtime) scheduleMachineRequest(ctx context.Context, req *rockferry.MachineRequest) error {
+	nodes, err := r.nodeInfos(ctx)
+	if err != nil {
+		return err
+	}
+
+	decision := scheduler.Schedule(req, nodes)
+
+	req.Status.Scheduling = decision.Status()
+	req.Status.Error = nil
+
+	object := &rockferry.OwnerRef{Kind: rockferry.ResourceKindMachineRequest, Id: req.Id}
+
+	if decision.Node == "" {
+		req.Status.Error = pointer.To(decision.Error())
+
+		condition := spec.NewCondition(spec.MachineRequestConditionScheduled, spec.ConditionStatusFalse, "FailedScheduling", *req.Status.Error)
+
+		// NOTE: Avoid rewriting the request every pass while nothing changes.
+		if !req.Status.Conditions.Set(condition) {
+			return nil
+		}
+
+		r.RecordEvent(ctx, object, rockferry.EventTypeWarning, "FailedScheduling", "%s", *req.Status.Error)
+
+		return r.Update(ctx, req.Generic())
+	}
+
+	r.RecordEvent(ctx, object, rockferry.EventTypeNormal, "Scheduled", "scheduled on node %s", decision.Node)
+
+	req.Status.Conditions.Set(spec.NewCondition(spec.MachineRequestConditionScheduled, spec.ConditionStatusTrue, "Scheduled", "scheduled on node "+decision.Node))
+
+	req.Owner = new(rockferry.OwnerRef)
+	req.Owner.Kind = rockferry.ResourceKindNode
+	req.Owner.Id = decision.Node
+
+	// NOTE: Now that it has a node, the request is picked up by the allocator.
+	return r.Update(ctx, req.Generic())
+}
+
+// Schedules every machine request which is waiting for a node.
+func (r *Runtime) scheduleMachineRequests(ctx context.Context) error {
+	requests, err := r.listKind(ctx, rockferry.ResourceKindMachineRequest)
+	if err != nil {
+		return err
+	}
+
+	for _, generic := range requests {
+		if !schedulable(generic) {
+			continue
+		}
+
+		// NOTE: The request is written at the version it was read at. One which
+		// changed meanwhile is read and scheduled again.
+		err := rockferry.RetryOnConflict(ctx, func() error {
+			if err := r.scheduleMachineRequest(ctx, rockferry.CastFromMap[spec.MachineRequestSpec, spec.MachineRequestStatus](generic)); err != rockferry.ErrorConflict {
+				return err
+			}
+
+			latest, err := r.Fetch(ctx, rockferry.ResourceKindMachineRequest, generic.Id)
+			if err == rockferry.ErrorNotFound {
+				return nil
+			}
+
+			if err != nil {
+				return err
+			}
+
+			if !schedulable(latest) {
+				return nil
+			}
+
+			generic = latest
+			return rockferry.ErrorConflict
+		})
+		if err != nil {
+			fmt.Println("failed to schedule machine request", generic.Id, err)
+		}
+	}
+
+	return nil
+}
+
+// Machine requests are waiting for a node until the scheduler gives them one.
+func schedulable(generic *rockferry.Generic) bool {
+	return generic.Owner == nil && generic.Phase == rockferry.PhasePreProcessing && !generic.Deleting()
+}
+
+// RunScheduler places machine requests created without an owner on a node.
+// Requests are scheduled one at a time, so every decision sees the previous
+// ones. Blocks until ctx is done.
+func (r *Runtime) RunScheduler(ctx context.Context) {
+	ticker := time.NewTicker(scheduleInterval)
+	defer ticker.Stop()
+
+	for {
+		stream, canceled, err := r.Watch(ctx, rockferry.WatchActionCreate, rockferry.ResourceKindMachineRequest, "", nil, WatchOptions{})
+		if err != nil {
+			fmt.Println("failed to watch machine requests", err)
+			return
+		}
+
+		if err := r.scheduleMachineRequests(ctx); err != nil {
+			fmt.Println("failed to schedule machine requests", err)
+		}
+
+	watch:
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-canceled:
+				break watch
+			case _, ok := <-stream:
+				if !ok {
+					break watch
+				}
+
+				if err := r.scheduleMachineRequests(ctx); err != nil {
+					fmt.Println("failed to schedule machine requests", err)
+				}
+			case <-ticker.C:
+				if err := r.scheduleMachineRequests(ctx); err != nil {
+					fmt.Println("failed to schedule machine requests", err)
+				}
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(time.Second):
+		}
+	}
+}
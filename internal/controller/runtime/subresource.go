@@ -0,0 +1,138 @@
+package runtime
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+
+	"github.com/eskpil/rockferry/pkg/rockferry"
+	jsonpatch "github.com/evanphx/json-patch/v5"
+)
+
+// Status and phase are written by whoever reconciles the resource, the rest of
+// the resource is considered the spec.
+func isStatusPath(path string) bool {
+	return path == "/status" || strings.HasPrefix(path, "/status/") || path == "/phase"
+}
+
+// Makes sure every operation either only touches the status or only the spec.
+func validatePatchPaths(patch jsonpatch.Patch, status bool) error {
+	for _, op := range patch {
+		paths := []string{}
+
+		if path, err := op.Path(); err == nil {
+			paths = append(paths, path)
+		}
+
+		if from, err := op.From(); err == nil {
+			paths = append(paths, from)
+		}
+
+		for _, path := range paths {
+			if status && !isStatusPath(path) {
+				return rockferry.ErrorSpecPatch
+			}
+
+			if !status && isStatusPath(path) {
+				return rockferry.ErrorStatusPatch
+			}
+		}
+	}
+
+	return nil
+}
+
+func marshalSubresources(resource *rockferry.Generic) ([]byte, []byte, error) {
+	copy := *resource
+	copy.ResourceVersion = 0
+
+	status, err := json.Marshal([]any{copy.Status, copy.Phase})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	copy.Status = nil
+	copy.Phase = ""
+
+	spec, err := json.Marshal(copy)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return spec, status, nil
+}
+
+// Reports which parts of the resource differ between prev and current.
+func changedSubresources(prev *rockferry.Generic, current *rockferry.Generic) (bool, bool) {
+	prevSpec, prevStatus, err := marshalSubresources(prev)
+	if err != nil {
+		return true, true
+	}
+
+	currentSpec, currentStatus, err := marshalSubresources(current)
+	if err != nil {
+		return true, true
+	}
+
+	return !bytes.Equal(prevSpec, currentSpec), !bytes.Equal(prevStatus, currentStatus)
+}
+
+// The spec as it compares after a round trip through the store, typed specs
+// and specs decoded from the store marshal differently.
+func normalizedSpec(resource *rockferry.Generic) ([]byte, error) {
+	raw, err := json.Marshal(resource.Spec)
+	if err != nil {
+		return nil, err
+	}
+
+	var spec any
+	if err := json.Unmarshal(raw, &spec); err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(spec)
+}
+
+// The generation resource is stored with when it replaces previous, nil if
+// there is none. It only moves on when the spec changed.
+func nextGeneration(previous *rockferry.Generic, resource *rockferry.Generic) int64 {
+	if previous == nil {
+		return 1
+	}
+
+	// NOTE: Resources stored before generations were tracked have none.
+	generation := max(previous.Generation, 1)
+
+	prevSpec, err := normalizedSpec(previous)
+	if err != nil {
+		return generation + 1
+	}
+
+	currentSpec, err := normalizedSpec(resource)
+	if err != nil {
+		return generation + 1
+	}
+
+	if !bytes.Equal(prevSpec, currentSpec) {
+		generation++
+	}
+
+	return generation
+}
+
+// Sets the generation of an encoded resource, leaving the rest of it as it was.
+func withGeneration(value []byte, generation int64) ([]byte, error) {
+	fields := map[string]json.RawMessage{}
+	if err := json.Unmarshal(value, &fields); err != nil {
+		return nil, err
+	}
+
+	encoded, err := json.Marshal(generation)
+	if err != nil {
+		return nil, err
+	}
+
+	fields["generation"] = encoded
+
+	return json.Marshal(fields)
+}
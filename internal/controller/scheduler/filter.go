@@ -0,0 +1,128 @@
+package scheduler
+
+import (
+	"fmt"
+
+	"github.com/eskpil/rockferry/pkg/rockferry"
+	"github.com/eskpil/rockferry/pkg/rockferry/spec"
+)
+
+// Returns why req can not be placed on node, or an empty string if it can.
+func filter(req *rockferry.MachineRequest, node *NodeInfo) string {
+	filters := []func(*rockferry.MachineRequest, *NodeInfo) string{
+		filterReady,
+		filterResources,
+		filterPools,
+		filterNetwork,
+		filterAntiAffinity,
+	}
+
+	for _, f := range filters {
+		if reason := f(req, node); reason != "" {
+			return reason
+		}
+	}
+
+	return ""
+}
+
+func filterReady(_ *rockferry.MachineRequest, node *NodeInfo) string {
+	return ready(node)
+}
+
+func filterResources(req *rockferry.MachineRequest, node *NodeInfo) string {
+	return fits(node, req.Spec.Topology)
+}
+
+func ready(node *NodeInfo) string {
+	if !node.Node.Status.Ready() {
+		return "node is not ready"
+	}
+
+	return ""
+}
+
+func fits(node *NodeInfo, topology spec.Topology) string {
+	totalCpus, totalMemory := node.capacity()
+	usedCpus, usedMemory := node.used()
+
+	if cpus := vcpus(topology); usedCpus+cpus > totalCpus {
+		return fmt.Sprintf("not enough cpu, %d of %d vcpus free but %d requested", max(totalCpus, usedCpus)-usedCpus, totalCpus, cpus)
+	}
+
+	if memory := topology.Memory; usedMemory+memory > totalMemory {
+		return fmt.Sprintf("not enough memory, %d of %d bytes free but %d requested", max(totalMemory, usedMemory)-usedMemory, totalMemory, memory)
+	}
+
+	return ""
+}
+
+func filterPools(req *rockferry.MachineRequest, node *NodeInfo) string {
+	requested := map[string]uint64{}
+
+	for _, disk := range req.Spec.Disks {
+		// NOTE: Disks which already have a volume do not take up more space.
+		if disk.Volume != "" {
+			continue
+		}
+
+		pool := node.pool(disk.Pool)
+		if pool == nil && disk.Pool == "" {
+			return "no default storage pool"
+		}
+
+		if pool == nil {
+			return fmt.Sprintf("storage pool %s is not on this node", disk.Pool)
+		}
+
+		requested[pool.Id] += disk.Capacity
+
+		if requested[pool.Id] > pool.Spec.Available {
+			return fmt.Sprintf("not enough space in storage pool %s, %d bytes available but %d requested", pool.Spec.Name, pool.Spec.Available, requested[pool.Id])
+		}
+	}
+
+	return ""
+}
+
+func filterNetwork(req *rockferry.MachineRequest, node *NodeInfo) string {
+	if node.network(req.Spec.Network) != nil {
+		return ""
+	}
+
+	if req.Spec.Network == "" {
+		return "no default network"
+	}
+
+	return fmt.Sprintf("network %s is not on this node", req.Spec.Network)
+}
+
+func filterAntiAffinity(req *rockferry.MachineRequest, node *NodeInfo) string {
+	group := req.Annotations[AnnotationAntiAffinity]
+	if group == "" || req.Annotations[AnnotationAntiAffinityMode] == AntiAffinityPreferred {
+		return ""
+	}
+
+	if conflicts(req, node) > 0 {
+		return fmt.Sprintf("already hosts a request of anti-affinity group %s", group)
+	}
+
+	return ""
+}
+
+// The amount of requests on node sharing the anti-affinity group of req.
+func conflicts(req *rockferry.MachineRequest, node *NodeInfo) int {
+	group := req.Annotations[AnnotationAntiAffinity]
+	if group == "" {
+		return 0
+	}
+
+	count := 0
+	for _, other := range node.Requests {
+		if other.Id != req.Id && other.Annotations[AnnotationAntiAffinity] == group {
+			count++
+		}
+	}
+
+	return count
+}
@@ -0,0 +1,39 @@
+package scheduler
+
+import (
+	"testing"
+
+	"github.com/eskpil/rockferry/pkg/rockferry"
+	"github.com/eskpil/rockferry/pkg/rockferry/spec"
+)
+
+func TestFitsOvercommit(t *testing.T) {
+	node := new(rockferry.Node)
+	node.Spec.Topology = spec.Topology{Threads: 2, Memory: 1 << 30}
+
+	tests := []struct {
+		name       string
+		overcommit uint64
+		vcpus      uint64
+		fits       bool
+	}{
+		{"default", 0, 8, true},
+		{"past the default", 0, 9, false},
+		{"configured", 2, 4, true},
+		{"past the configured", 2, 5, false},
+		{"without overcommit", 1, 3, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			info := new(NodeInfo)
+			info.Node = node
+			info.CpuOvercommit = test.overcommit
+
+			reason := fits(info, spec.Topology{Cores: test.vcpus, Threads: 1, Memory: 1 << 20})
+			if (reason == "") != test.fits {
+				t.Fatalf("fitting %d vcpus gave %q", test.vcpus, reason)
+			}
+		})
+	}
+}
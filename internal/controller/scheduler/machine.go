@@ -0,0 +1,43 @@
+package scheduler
+
+import (
+	"fmt"
+
+	"github.com/eskpil/rockferry/pkg/rockferry"
+)
+
+// Picks a node to run an existing machine on, for example when the node it ran
+// on is gone. Its disks are expected to be reachable from every node, so only
+// resources and networks are considered.
+func Place(machine *rockferry.Machine, nodes []*NodeInfo) *Decision {
+	strategy := strategy(machine.Annotations)
+
+	return decide(nodes, strategy, func(node *NodeInfo) string {
+		return filterMachine(machine, node)
+	}, func(node *NodeInfo) int64 {
+		return utilizationScore(node, machine.Spec.Topology, strategy)
+	})
+}
+
+func filterMachine(machine *rockferry.Machine, node *NodeInfo) string {
+	if reason := ready(node); reason != "" {
+		return reason
+	}
+
+	if reason := fits(node, machine.Spec.Topology); reason != "" {
+		return reason
+	}
+
+	names := map[string]bool{}
+	for _, network := range node.Networks {
+		names[network.Spec.Name] = true
+	}
+
+	for _, iface := range machine.Spec.Interfaces {
+		if iface.Network != nil && !names[*iface.Network] {
+			return fmt.Sprintf("network %s is not on this node", *iface.Network)
+		}
+	}
+
+	return ""
+}
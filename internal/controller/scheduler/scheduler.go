@@ -0,0 +1,204 @@
+package scheduler
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/eskpil/rockferry/pkg/rockferry"
+	"github.com/eskpil/rockferry/pkg/rockferry/spec"
+)
+
+type Strategy string
+
+const (
+	// Prefer the node with the most free resources left.
+	StrategySpread Strategy = "spread"
+	// Prefer the node with the least free resources left, keeping other nodes empty.
+	StrategyBinpack = "binpack"
+)
+
+// Annotations on machine requests which influence scheduling.
+const (
+	AnnotationStrategy = "scheduler.rockferry/strategy"
+
+	// Requests sharing a value are placed on different nodes.
+	AnnotationAntiAffinity = "scheduler.rockferry/anti-affinity"
+	// Either "required", the default, or "preferred". A preferred anti-affinity
+	// only lowers the score of nodes already running a request of the group.
+	AnnotationAntiAffinityMode = "scheduler.rockferry/anti-affinity-mode"
+)
+
+const (
+	AntiAffinityRequired  = "required"
+	AntiAffinityPreferred = "preferred"
+)
+
+// The annotation marking the pool and network used when a request leaves them empty.
+const AnnotationDefault = "rockferry.default"
+
+// Machines rarely use all of their vcpus, so more are handed out than the node
+// has. By default four for every thread of the node.
+const DefaultCpuOvercommit = 4
+
+// What is known about a node while scheduling.
+type NodeInfo struct {
+	Node     *rockferry.Node
+	Pools    []*rockferry.StoragePool
+	Networks []*rockferry.Network
+
+	// Requests placed on the node.
+	Requests []*rockferry.MachineRequest
+	// Machines on the node which were not created from one of Requests, such as
+	// machines which have been moved from another node.
+	Machines []*rockferry.Machine
+
+	// How many vcpus are handed out for every thread of the node,
+	// DefaultCpuOvercommit if zero.
+	CpuOvercommit uint64
+}
+
+func (n *NodeInfo) capacity() (uint64, uint64) {
+	overcommit := n.CpuOvercommit
+	if overcommit == 0 {
+		overcommit = DefaultCpuOvercommit
+	}
+
+	return n.Node.Spec.Topology.Threads * overcommit, n.Node.Spec.Topology.Memory
+}
+
+func (n *NodeInfo) used() (uint64, uint64) {
+	var cpus, memory uint64
+
+	for _, req := range n.Requests {
+		cpus += vcpus(req.Spec.Topology)
+		memory += req.Spec.Topology.Memory
+	}
+
+	for _, machine := range n.Machines {
+		cpus += vcpus(machine.Spec.Topology)
+		memory += machine.Spec.Topology.Memory
+	}
+
+	return cpus, memory
+}
+
+func (n *NodeInfo) pool(id string) *rockferry.StoragePool {
+	for _, pool := range n.Pools {
+		if id == "" && pool.Annotations[AnnotationDefault] == "yes" {
+			return pool
+		}
+
+		if id != "" && pool.Id == id {
+			return pool
+		}
+	}
+
+	return nil
+}
+
+func (n *NodeInfo) network(id string) *rockferry.Network {
+	for _, network := range n.Networks {
+		if id == "" && network.Annotations[AnnotationDefault] == "yes" {
+			return network
+		}
+
+		if id != "" && network.Id == id {
+			return network
+		}
+	}
+
+	return nil
+}
+
+// The same as the domain the node creates for the machine.
+func vcpus(topology spec.Topology) uint64 {
+	return topology.Cores * topology.Threads
+}
+
+// The outcome of scheduling a request. Node is empty if no node fits.
+type Decision struct {
+	Node     string
+	Strategy Strategy
+	Nodes    []*spec.MachineRequestStatusSchedulingNode
+}
+
+// Records the decision in the status of a request.
+func (d *Decision) Status() *spec.MachineRequestStatusScheduling {
+	status := new(spec.MachineRequestStatusScheduling)
+	status.Node = d.Node
+	status.Strategy = string(d.Strategy)
+	status.Nodes = d.Nodes
+	return status
+}
+
+// Summarizes why no node could be picked.
+func (d *Decision) Error() string {
+	reasons := []string{}
+	for _, node := range d.Nodes {
+		reasons = append(reasons, fmt.Sprintf("%s: %s", node.Node, node.Reason))
+	}
+
+	if len(reasons) == 0 {
+		return "no nodes available"
+	}
+
+	return fmt.Sprintf("no node fits the request (%s)", strings.Join(reasons, "; "))
+}
+
+// Picks a node for req. Nodes which can not fit the request are filtered out and
+// the remaining ones are scored, the highest score wins and ties go to the lowest
+// node id.
+func Schedule(req *rockferry.MachineRequest, nodes []*NodeInfo) *Decision {
+	strategy := strategy(req.Annotations)
+
+	return decide(nodes, strategy, func(node *NodeInfo) string {
+		return filter(req, node)
+	}, func(node *NodeInfo) int64 {
+		return score(req, node, strategy)
+	})
+}
+
+func decide(nodes []*NodeInfo, strategy Strategy, filter func(*NodeInfo) string, score func(*NodeInfo) int64) *Decision {
+	decision := new(Decision)
+	decision.Strategy = strategy
+
+	var best *spec.MachineRequestStatusSchedulingNode
+
+	nodes = slices.Clone(nodes)
+	slices.SortFunc(nodes, func(a *NodeInfo, b *NodeInfo) int {
+		return strings.Compare(a.Node.Id, b.Node.Id)
+	})
+
+	for _, node := range nodes {
+		result := new(spec.MachineRequestStatusSchedulingNode)
+		result.Node = node.Node.Id
+
+		if reason := filter(node); reason != "" {
+			result.Reason = reason
+		} else {
+			result.Feasible = true
+			result.Score = score(node)
+
+			if best == nil || result.Score > best.Score {
+				best = result
+			}
+		}
+
+		decision.Nodes = append(decision.Nodes, result)
+	}
+
+	if best != nil {
+		decision.Node = best.Node
+	}
+
+	return decision
+}
+
+func strategy(annotations map[string]string) Strategy {
+	if Strategy(annotations[AnnotationStrategy]) == StrategyBinpack {
+		return StrategyBinpack
+	}
+
+	return StrategySpread
+}
@@ -0,0 +1,43 @@
+package scheduler
+
+import (
+	"github.com/eskpil/rockferry/pkg/rockferry"
+	"github.com/eskpil/rockferry/pkg/rockferry/spec"
+)
+
+const (
+	maxScore = 100
+
+	// Subtracted for every request of the same preferred anti-affinity group,
+	// more than the resource score can make up for.
+	antiAffinityPenalty = maxScore + 1
+)
+
+// Scores a node which passed the filters, higher is better.
+func score(req *rockferry.MachineRequest, node *NodeInfo, strategy Strategy) int64 {
+	return utilizationScore(node, req.Spec.Topology, strategy) - int64(conflicts(req, node))*antiAffinityPenalty
+}
+
+func utilizationScore(node *NodeInfo, topology spec.Topology, strategy Strategy) int64 {
+	totalCpus, totalMemory := node.capacity()
+	usedCpus, usedMemory := node.used()
+
+	// How much of the node is in use once the workload is placed, from 0 to maxScore.
+	cpu := fraction(usedCpus+vcpus(topology), totalCpus)
+	memory := fraction(usedMemory+topology.Memory, totalMemory)
+	utilization := (cpu + memory) / 2
+
+	if strategy == StrategyBinpack {
+		return utilization
+	}
+
+	return maxScore - utilization
+}
+
+func fraction(used uint64, total uint64) int64 {
+	if total == 0 {
+		return maxScore
+	}
+
+	return int64(min(used, total) * maxScore / total)
+}
@@ -0,0 +1,348 @@
+package controller
+
+import (
+	"cmp"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"log"
+	"maps"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/eskpil/rockferry/internal/controller/config"
+	"github.com/eskpil/rockferry/internal/controller/store"
+	"github.com/eskpil/rockferry/pkg/rockferry"
+	clientv3 "go.etcd.io/etcd/client/v3"
+	"go.etcd.io/etcd/server/v3/embed"
+	"go.etcd.io/etcd/server/v3/etcdserver/api/v3client"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// How long the embedded etcd gets to become ready.
+const embeddedStartTimeout = 60 * time.Second
+
+const (
+	// How long joining or leaving the cluster may take.
+	membershipTimeout = 60 * time.Second
+	joinRetryInterval = 2 * time.Second
+)
+
+// An etcd running inside of the controller, closed together with the store.
+type embeddedStore struct {
+	*store.Etcd
+	server *embed.Etcd
+
+	dataDir string
+	leave   bool
+	// Set once the member is on its way out, the server stopping is expected then.
+	closing atomic.Bool
+}
+
+// Removes this member from the cluster, unless it is the last one. A removed
+// member can never start again with the same data, so it is removed as well.
+func (s *embeddedStore) leaveCluster() error {
+	ctx, cancel := context.WithTimeout(context.Background(), membershipTimeout)
+	defer cancel()
+
+	members, err := s.Members(ctx)
+	if err != nil {
+		return err
+	}
+
+	if len(members) <= 1 {
+		fmt.Println("not leaving the cluster, this is the last member")
+		return nil
+	}
+
+	if err := s.RemoveMember(ctx, uint64(s.server.Server.ID())); err != nil {
+		return err
+	}
+
+	fmt.Println("left the cluster")
+
+	return os.RemoveAll(s.dataDir)
+}
+
+func (s *embeddedStore) Close() error {
+	s.closing.Store(true)
+
+	var err error
+	if s.leave {
+		err = s.leaveCluster()
+	}
+
+	if cerr := s.Etcd.Close(); err == nil {
+		err = cerr
+	}
+
+	s.server.Close()
+	return err
+}
+
+func clientTLS(c *config.TLS) (*tls.Config, error) {
+	conf := new(tls.Config)
+
+	if c.CA != "" {
+		contents, err := os.ReadFile(c.CA)
+		if err != nil {
+			return nil, err
+		}
+
+		conf.RootCAs = x509.NewCertPool()
+		if !conf.RootCAs.AppendCertsFromPEM(contents) {
+			return nil, fmt.Errorf("no certificates found in %s", c.CA)
+		}
+	}
+
+	if c.Cert != "" {
+		cert, err := tls.LoadX509KeyPair(c.Cert, c.Key)
+		if err != nil {
+			return nil, err
+		}
+
+		conf.Certificates = []tls.Certificate{cert}
+	}
+
+	return conf, nil
+}
+
+func parseUrls(urls ...string) ([]url.URL, error) {
+	parsed := make([]url.URL, len(urls))
+	for i, raw := range urls {
+		u, err := url.Parse(raw)
+		if err != nil {
+			return nil, err
+		}
+
+		parsed[i] = *u
+	}
+
+	return parsed, nil
+}
+
+// The url on localhost with the scheme and port of raw, keeps etcd clients on
+// other machines out unless asked for.
+func localUrl(raw string) (string, error) {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return "", err
+	}
+
+	port := u.Port()
+
+	u.Host = "localhost"
+	if port != "" {
+		u.Host = net.JoinHostPort(u.Host, port)
+	}
+
+	return u.String(), nil
+}
+
+func initialCluster(members map[string]string) string {
+	names := slices.Sorted(maps.Keys(members))
+
+	parts := make([]string, len(names))
+	for i, name := range names {
+		parts[i] = fmt.Sprintf("%s=%s", name, members[name])
+	}
+
+	return strings.Join(parts, ",")
+}
+
+// Adds this member to the cluster through the controller at c.Join, returns the
+// members the cluster is made up of.
+func join(c *config.Cluster, opts []rockferry.ClientOption) (map[string]string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), membershipTimeout)
+	defer cancel()
+
+	client, err := rockferry.New(c.Join, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	var added *rockferry.Member
+	var members []*rockferry.Member
+
+	// NOTE: The cluster refuses new members while another is still starting.
+	for {
+		added, members, err = client.AddMember(ctx, []string{c.PeerUrl}, c.JoinToken)
+		if status.Code(err) != codes.Unavailable {
+			break
+		}
+
+		fmt.Println("cluster is busy, retrying to join")
+
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("failed to join the cluster through %s: %w", c.Join, err)
+		case <-time.After(joinRetryInterval):
+		}
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to join the cluster through %s: %w", c.Join, err)
+	}
+
+	initial := map[string]string{}
+	for _, member := range members {
+		name := member.Name
+
+		// NOTE: The member being added has not started yet, it has no name.
+		if member.Id == added.Id {
+			name = c.Name
+		}
+
+		for _, peer := range member.PeerUrls {
+			initial[name] = peer
+		}
+	}
+
+	return initial, nil
+}
+
+func embeddedConfig(c *config.Store, opts []rockferry.ClientOption) (*embed.Config, error) {
+	cluster := &c.Cluster
+
+	cfg := embed.NewConfig()
+	cfg.Dir = c.DataDir
+	cfg.Name = cluster.Name
+	cfg.InitialClusterToken = cluster.Token
+
+	listenPeer := cmp.Or(cluster.ListenPeerUrl, cluster.PeerUrl)
+	listenClient, err := localUrl(cluster.ClientUrl)
+	if err != nil {
+		return nil, err
+	}
+
+	listenClient = cmp.Or(cluster.ListenClientUrl, listenClient)
+
+	if cfg.ListenPeerUrls, err = parseUrls(listenPeer); err != nil {
+		return nil, err
+	}
+
+	if cfg.AdvertisePeerUrls, err = parseUrls(cluster.PeerUrl); err != nil {
+		return nil, err
+	}
+
+	if cfg.ListenClientUrls, err = parseUrls(listenClient); err != nil {
+		return nil, err
+	}
+
+	if cfg.AdvertiseClientUrls, err = parseUrls(cluster.ClientUrl); err != nil {
+		return nil, err
+	}
+
+	initial := cluster.InitialCluster
+	if len(initial) == 0 {
+		initial = map[string]string{cluster.Name: cluster.PeerUrl}
+	}
+
+	// NOTE: Once started the member finds its cluster in its data dir, joining
+	// again would add it a second time.
+	_, err = os.Stat(filepath.Join(c.DataDir, "member"))
+	if cluster.Join != "" && os.IsNotExist(err) {
+		if initial, err = join(cluster, opts); err != nil {
+			return nil, err
+		}
+
+		cfg.ClusterState = embed.ClusterStateFlagExisting
+	}
+
+	cfg.InitialCluster = initialCluster(initial)
+
+	return cfg, nil
+}
+
+func openEmbedded(c *config.Store, opts []rockferry.ClientOption) (store.Store, error) {
+	cfg, err := embeddedConfig(c, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	e, err := embed.StartEtcd(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	select {
+	case <-e.Server.ReadyNotify():
+		log.Printf("Database is running")
+	case <-time.After(embeddedStartTimeout):
+		e.Server.Stop() // trigger a shutdown
+		return nil, fmt.Errorf("embedded etcd took longer than %s to start", embeddedStartTimeout)
+	}
+
+	s := new(embeddedStore)
+	s.Etcd = store.NewEtcd(v3client.New(e.Server))
+	s.server = e
+	s.dataDir = c.DataDir
+	s.leave = c.Cluster.LeaveOnShutdown
+
+	go func() {
+		if err := <-e.Err(); err != nil && !s.closing.Load() {
+			log.Fatal(err)
+		}
+	}()
+
+	return s, nil
+}
+
+func openExternal(c *config.Store) (store.Store, error) {
+	cfg := clientv3.Config{
+		Endpoints:   c.Endpoints,
+		DialTimeout: c.DialTimeout,
+	}
+
+	if c.TLS != nil {
+		conf, err := clientTLS(c.TLS)
+		if err != nil {
+			return nil, err
+		}
+
+		cfg.TLS = conf
+	}
+
+	cli, err := clientv3.New(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return store.NewEtcd(cli), nil
+}
+
+// OpenStore opens the store described by the config. The controller shares the
+// returned store everywhere, it should only be opened once.
+func OpenStore(c *config.Config) (store.Store, error) {
+	switch c.Store.Backend {
+	case config.StoreBackendEmbedded:
+		var opts []rockferry.ClientOption
+
+		// NOTE: The joining controller has no certificate yet, it only verifies
+		// the controller it joins through.
+		if c.Store.Cluster.Join != "" && !c.Pki.Insecure {
+			conf, err := rockferry.LoadTLS(c.Store.Cluster.JoinCA, "", "")
+			if err != nil {
+				return nil, err
+			}
+
+			opts = append(opts, rockferry.WithTLS(conf))
+		}
+
+		return openEmbedded(&c.Store, opts)
+	case config.StoreBackendExternal:
+		return openExternal(&c.Store)
+	case config.StoreBackendMemory:
+		return store.NewMemory(), nil
+	}
+
+	return nil, fmt.Errorf("%w: unknown store backend %q", config.ErrInvalidConfig, c.Store.Backend)
+}
@@ -0,0 +1,269 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	"go.etcd.io/etcd/api/v3/etcdserverpb"
+	"go.etcd.io/etcd/api/v3/mvccpb"
+	"go.etcd.io/etcd/api/v3/v3rpc/rpctypes"
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+type Etcd struct {
+	c *clientv3.Client
+}
+
+var _ Store = (*Etcd)(nil)
+var _ Cluster = (*Etcd)(nil)
+
+func NewEtcd(c *clientv3.Client) *Etcd {
+	s := new(Etcd)
+	s.c = c
+	return s
+}
+
+// The underlying client, for what the store does not cover.
+func (s *Etcd) Client() *clientv3.Client {
+	return s.c
+}
+
+func mapKeyValue(kv *mvccpb.KeyValue) *KeyValue {
+	if kv == nil {
+		return nil
+	}
+
+	out := new(KeyValue)
+	out.Key = string(kv.Key)
+	out.Value = kv.Value
+	out.ModRevision = kv.ModRevision
+	return out
+}
+
+func (s *Etcd) Get(ctx context.Context, key string) (*KeyValue, int64, error) {
+	res, err := s.c.Get(ctx, key)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if len(res.Kvs) == 0 {
+		return nil, res.Header.Revision, nil
+	}
+
+	return mapKeyValue(res.Kvs[0]), res.Header.Revision, nil
+}
+
+func (s *Etcd) List(ctx context.Context, prefix string) ([]*KeyValue, int64, error) {
+	res, err := s.c.Get(ctx, prefix, clientv3.WithPrefix())
+	if err != nil {
+		return nil, 0, err
+	}
+
+	kvs := make([]*KeyValue, len(res.Kvs))
+	for i, kv := range res.Kvs {
+		kvs[i] = mapKeyValue(kv)
+	}
+
+	return kvs, res.Header.Revision, nil
+}
+
+func (s *Etcd) Put(ctx context.Context, key string, value []byte) (int64, error) {
+	res, err := s.c.Put(ctx, key, string(value))
+	if err != nil {
+		return 0, err
+	}
+
+	return res.Header.Revision, nil
+}
+
+func (s *Etcd) Delete(ctx context.Context, key string) (int64, error) {
+	res, err := s.c.Delete(ctx, key)
+	if err != nil {
+		return 0, err
+	}
+
+	return res.Header.Revision, nil
+}
+
+func (s *Etcd) Txn(ctx context.Context, conditions []Condition, ops ...Op) (bool, int64, error) {
+	cmps := make([]clientv3.Cmp, len(conditions))
+	for i, condition := range conditions {
+		cmps[i] = clientv3.Compare(clientv3.ModRevision(condition.Key), "=", condition.ModRevision)
+	}
+
+	then := make([]clientv3.Op, len(ops))
+	for i, op := range ops {
+		switch op.Type {
+		case OpTypeDelete:
+			then[i] = clientv3.OpDelete(op.Key)
+		default:
+			then[i] = clientv3.OpPut(op.Key, string(op.Value), clientv3.WithLease(clientv3.LeaseID(op.Lease)))
+		}
+	}
+
+	res, err := s.c.Txn(ctx).If(cmps...).Then(then...).Commit()
+	if err == rpctypes.ErrLeaseNotFound {
+		return false, 0, ErrLeaseNotFound
+	}
+
+	if err != nil {
+		return false, 0, err
+	}
+
+	return res.Succeeded, res.Header.Revision, nil
+}
+
+func (s *Etcd) Watch(ctx context.Context, key string, prefix bool, revision int64) <-chan *WatchResponse {
+	out := make(chan *WatchResponse)
+
+	// NOTE: Previous values are needed to tell what a change touched.
+	opts := []clientv3.OpOption{clientv3.WithPrevKV()}
+	if prefix {
+		opts = append(opts, clientv3.WithPrefix())
+	}
+
+	if revision != 0 {
+		opts = append(opts, clientv3.WithRev(revision+1))
+	}
+
+	watchChannel := s.c.Watch(ctx, key, opts...)
+
+	send := func(res *WatchResponse) bool {
+		select {
+		case out <- res:
+			return true
+		case <-ctx.Done():
+			return false
+		}
+	}
+
+	go func() {
+		defer close(out)
+
+		for w := range watchChannel {
+			res := new(WatchResponse)
+			res.Revision = w.Header.Revision
+
+			switch {
+			case w.CompactRevision != 0:
+				res.Err = ErrCompacted
+			case w.Canceled:
+				res.Err = ErrWatchCanceled
+			case w.Err() != nil:
+				res.Err = w.Err()
+			}
+
+			if res.Err != nil {
+				send(res)
+				return
+			}
+
+			res.Progress = w.IsProgressNotify()
+
+			for _, e := range w.Events {
+				event := new(Event)
+				event.Type = EventPut
+				if e.Type == mvccpb.DELETE {
+					event.Type = EventDelete
+				}
+
+				event.Kv = mapKeyValue(e.Kv)
+				event.Prev = mapKeyValue(e.PrevKv)
+				event.created = e.IsCreate()
+
+				res.Events = append(res.Events, event)
+			}
+
+			if !send(res) {
+				return
+			}
+		}
+	}()
+
+	return out
+}
+
+// Answered with a progress notification on every watch sharing ctx.
+func (s *Etcd) RequestProgress(ctx context.Context) error {
+	return s.c.RequestProgress(ctx)
+}
+
+func (s *Etcd) Grant(ctx context.Context, ttl time.Duration) (LeaseID, error) {
+	res, err := s.c.Grant(ctx, int64(ttl.Seconds()))
+	if err != nil {
+		return 0, err
+	}
+
+	return LeaseID(res.ID), nil
+}
+
+func (s *Etcd) KeepAlive(ctx context.Context, lease LeaseID) error {
+	_, err := s.c.KeepAliveOnce(ctx, clientv3.LeaseID(lease))
+	if err == rpctypes.ErrLeaseNotFound {
+		return ErrLeaseNotFound
+	}
+
+	return err
+}
+
+func (s *Etcd) Revoke(ctx context.Context, lease LeaseID) error {
+	_, err := s.c.Revoke(ctx, clientv3.LeaseID(lease))
+	if err == rpctypes.ErrLeaseNotFound {
+		return ErrLeaseNotFound
+	}
+
+	return err
+}
+
+func (s *Etcd) Close() error {
+	return s.c.Close()
+}
+
+func mapMember(m *etcdserverpb.Member) *Member {
+	out := new(Member)
+	out.Id = m.ID
+	out.Name = m.Name
+	out.PeerUrls = m.PeerURLs
+	out.ClientUrls = m.ClientURLs
+	return out
+}
+
+func mapMembers(members []*etcdserverpb.Member) []*Member {
+	out := make([]*Member, len(members))
+	for i, m := range members {
+		out[i] = mapMember(m)
+	}
+
+	return out
+}
+
+func (s *Etcd) Members(ctx context.Context) ([]*Member, error) {
+	res, err := s.c.MemberList(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return mapMembers(res.Members), nil
+}
+
+func (s *Etcd) AddMember(ctx context.Context, peerUrls []string) (*Member, []*Member, error) {
+	res, err := s.c.MemberAdd(ctx, peerUrls)
+	if err == rpctypes.ErrMemberNotEnoughStarted || err == rpctypes.ErrUnhealthy {
+		return nil, nil, ErrClusterUnavailable
+	}
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return mapMember(res.Member), mapMembers(res.Members), nil
+}
+
+func (s *Etcd) RemoveMember(ctx context.Context, id uint64) error {
+	_, err := s.c.MemberRemove(ctx, id)
+	if err == rpctypes.ErrMemberNotFound {
+		return ErrMemberNotFound
+	}
+
+	return err
+}
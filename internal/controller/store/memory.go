@@ -0,0 +1,449 @@
+package store
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// How many revisions are kept for watches to resume from, older ones are compacted.
+	memoryHistory = 10000
+	// How often leases are checked for expiry.
+	leaseCheckInterval = 500 * time.Millisecond
+)
+
+type memoryRevision struct {
+	revision int64
+	events   []*Event
+}
+
+type memoryLease struct {
+	ttl     time.Duration
+	expires time.Time
+	keys    map[string]bool
+}
+
+type memoryWatch struct {
+	key    string
+	prefix bool
+
+	mu     sync.Mutex
+	queue  []*WatchResponse
+	notify chan struct{}
+}
+
+func (w *memoryWatch) matches(key string) bool {
+	if w.prefix {
+		return strings.HasPrefix(key, w.key)
+	}
+
+	return key == w.key
+}
+
+// Never blocks, the watch catches up in its own goroutine.
+func (w *memoryWatch) push(res *WatchResponse) {
+	w.mu.Lock()
+	w.queue = append(w.queue, res)
+	w.mu.Unlock()
+
+	select {
+	case w.notify <- struct{}{}:
+	default:
+	}
+}
+
+func (w *memoryWatch) pop() []*WatchResponse {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	queue := w.queue
+	w.queue = nil
+	return queue
+}
+
+func (w *memoryWatch) filter(r *memoryRevision) *WatchResponse {
+	res := new(WatchResponse)
+	res.Revision = r.revision
+
+	for _, event := range r.events {
+		if w.matches(event.Kv.Key) {
+			res.Events = append(res.Events, event)
+		}
+	}
+
+	if len(res.Events) == 0 {
+		return nil
+	}
+
+	return res
+}
+
+// Memory keeps everything in memory, with the same revision and watch semantics
+// as etcd. Nothing survives the process.
+type Memory struct {
+	mu sync.Mutex
+
+	revision int64
+	data     map[string]*KeyValue
+
+	// Revisions after compacted, oldest first.
+	history   []*memoryRevision
+	compacted int64
+
+	leases    map[LeaseID]*memoryLease
+	keyLeases map[string]LeaseID
+	nextLease LeaseID
+
+	watches map[*memoryWatch]bool
+
+	done chan struct{}
+}
+
+var _ Store = (*Memory)(nil)
+
+func NewMemory() *Memory {
+	s := new(Memory)
+	s.data = map[string]*KeyValue{}
+	s.leases = map[LeaseID]*memoryLease{}
+	s.keyLeases = map[string]LeaseID{}
+	s.watches = map[*memoryWatch]bool{}
+	s.done = make(chan struct{})
+
+	go s.expireLeases()
+
+	return s
+}
+
+func copyKeyValue(kv *KeyValue) *KeyValue {
+	if kv == nil {
+		return nil
+	}
+
+	out := new(KeyValue)
+	*out = *kv
+	out.Value = slices.Clone(kv.Value)
+	return out
+}
+
+func (s *Memory) Get(_ context.Context, key string) (*KeyValue, int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return copyKeyValue(s.data[key]), s.revision, nil
+}
+
+func (s *Memory) List(_ context.Context, prefix string) ([]*KeyValue, int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	kvs := []*KeyValue{}
+	for key, kv := range s.data {
+		if strings.HasPrefix(key, prefix) {
+			kvs = append(kvs, copyKeyValue(kv))
+		}
+	}
+
+	slices.SortFunc(kvs, func(a *KeyValue, b *KeyValue) int {
+		return strings.Compare(a.Key, b.Key)
+	})
+
+	return kvs, s.revision, nil
+}
+
+func (s *Memory) Put(ctx context.Context, key string, value []byte) (int64, error) {
+	_, revision, err := s.Txn(ctx, nil, OpPut(key, value))
+	return revision, err
+}
+
+func (s *Memory) Delete(ctx context.Context, key string) (int64, error) {
+	_, revision, err := s.Txn(ctx, nil, OpDelete(key))
+	return revision, err
+}
+
+func (s *Memory) Txn(_ context.Context, conditions []Condition, ops ...Op) (bool, int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, condition := range conditions {
+		var revision int64
+		if kv := s.data[condition.Key]; kv != nil {
+			revision = kv.ModRevision
+		}
+
+		if revision != condition.ModRevision {
+			return false, s.revision, nil
+		}
+	}
+
+	for _, op := range ops {
+		if op.Lease != 0 && s.leases[op.Lease] == nil {
+			return false, s.revision, ErrLeaseNotFound
+		}
+	}
+
+	s.apply(ops)
+
+	return true, s.revision, nil
+}
+
+// Applies ops at the next revision and tells watches about it. Must be called
+// with the lock held.
+func (s *Memory) apply(ops []Op) {
+	revision := s.revision + 1
+	events := []*Event{}
+
+	for _, op := range ops {
+		prev := s.data[op.Key]
+
+		if lease, ok := s.keyLeases[op.Key]; ok {
+			delete(s.leases[lease].keys, op.Key)
+			delete(s.keyLeases, op.Key)
+		}
+
+		event := new(Event)
+		event.Prev = prev
+
+		switch op.Type {
+		case OpTypeDelete:
+			// NOTE: Deleting a key which does not exist is not a change.
+			if prev == nil {
+				continue
+			}
+
+			delete(s.data, op.Key)
+
+			event.Type = EventDelete
+			event.Kv = &KeyValue{Key: op.Key, ModRevision: revision}
+		default:
+			kv := &KeyValue{Key: op.Key, Value: slices.Clone(op.Value), ModRevision: revision}
+			s.data[op.Key] = kv
+
+			if op.Lease != 0 {
+				s.leases[op.Lease].keys[op.Key] = true
+				s.keyLeases[op.Key] = op.Lease
+			}
+
+			event.Type = EventPut
+			event.Kv = kv
+			event.created = prev == nil
+		}
+
+		events = append(events, event)
+	}
+
+	if len(events) == 0 {
+		return
+	}
+
+	s.revision = revision
+
+	r := &memoryRevision{revision: revision, events: events}
+
+	s.history = append(s.history, r)
+	if len(s.history) > memoryHistory {
+		s.compacted = s.history[0].revision
+		s.history = s.history[1:]
+	}
+
+	for w := range s.watches {
+		if res := w.filter(r); res != nil {
+			w.push(res)
+		}
+	}
+}
+
+func (s *Memory) Watch(ctx context.Context, key string, prefix bool, revision int64) <-chan *WatchResponse {
+	out := make(chan *WatchResponse)
+
+	w := new(memoryWatch)
+	w.key = key
+	w.prefix = prefix
+	w.notify = make(chan struct{}, 1)
+
+	s.mu.Lock()
+
+	if revision != 0 && revision < s.compacted {
+		s.mu.Unlock()
+
+		go func() {
+			defer close(out)
+
+			select {
+			case out <- &WatchResponse{Err: ErrCompacted}:
+			case <-ctx.Done():
+			}
+		}()
+
+		return out
+	}
+
+	// NOTE: Catch up on what happened since revision before anything new.
+	if revision != 0 {
+		for _, r := range s.history {
+			if r.revision <= revision {
+				continue
+			}
+
+			if res := w.filter(r); res != nil {
+				w.push(res)
+			}
+		}
+	}
+
+	s.watches[w] = true
+	s.mu.Unlock()
+
+	go func() {
+		defer close(out)
+
+		defer func() {
+			s.mu.Lock()
+			delete(s.watches, w)
+			s.mu.Unlock()
+		}()
+
+		for {
+			for _, res := range w.pop() {
+				select {
+				case out <- res:
+				case <-ctx.Done():
+					return
+				}
+
+				if res.Err != nil {
+					return
+				}
+			}
+
+			select {
+			case <-w.notify:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return out
+}
+
+// Every watch reports the current revision.
+func (s *Memory) RequestProgress(_ context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for w := range s.watches {
+		w.push(&WatchResponse{Revision: s.revision, Progress: true})
+	}
+
+	return nil
+}
+
+func (s *Memory) Grant(_ context.Context, ttl time.Duration) (LeaseID, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.nextLease++
+
+	lease := new(memoryLease)
+	lease.ttl = ttl
+	lease.expires = time.Now().Add(ttl)
+	lease.keys = map[string]bool{}
+
+	s.leases[s.nextLease] = lease
+
+	return s.nextLease, nil
+}
+
+func (s *Memory) KeepAlive(_ context.Context, id LeaseID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	lease, ok := s.leases[id]
+	if !ok {
+		return ErrLeaseNotFound
+	}
+
+	lease.expires = time.Now().Add(lease.ttl)
+
+	return nil
+}
+
+func (s *Memory) Revoke(_ context.Context, id LeaseID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	lease, ok := s.leases[id]
+	if !ok {
+		return ErrLeaseNotFound
+	}
+
+	ops := []Op{}
+	for key := range lease.keys {
+		ops = append(ops, OpDelete(key))
+	}
+
+	s.apply(ops)
+	delete(s.leases, id)
+
+	return nil
+}
+
+func (s *Memory) expireLeases() {
+	ticker := time.NewTicker(leaseCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.done:
+			return
+		case now := <-ticker.C:
+			s.mu.Lock()
+
+			ops := []Op{}
+			expired := []LeaseID{}
+
+			for id, lease := range s.leases {
+				if now.Before(lease.expires) {
+					continue
+				}
+
+				for key := range lease.keys {
+					ops = append(ops, OpDelete(key))
+				}
+
+				expired = append(expired, id)
+			}
+
+			s.apply(ops)
+
+			for _, id := range expired {
+				delete(s.leases, id)
+			}
+
+			s.mu.Unlock()
+		}
+	}
+}
+
+// Ends every watch.
+func (s *Memory) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	select {
+	case <-s.done:
+		return nil
+	default:
+	}
+
+	close(s.done)
+
+	for w := range s.watches {
+		w.push(&WatchResponse{Revision: s.revision, Err: ErrWatchCanceled})
+	}
+
+	return nil
+}
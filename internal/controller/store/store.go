@@ -0,0 +1,147 @@
+// Package store is the storage the controller keeps resources in. Every write
+// bumps a single revision shared by all keys, which resource versions and
+// resumable watches are built on.
+package store
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+var (
+	// The revision a watch was asked to start from is no longer kept.
+	ErrCompacted = errors.New("revision has been compacted")
+	// The watch was ended by the store.
+	ErrWatchCanceled  = errors.New("watch canceled")
+	ErrLeaseNotFound  = errors.New("lease not found")
+	ErrMemberNotFound = errors.New("member not found")
+	// The store is not made up of several members.
+	ErrNotClustered = errors.New("store is not clustered")
+	// Members can not be added right now, usually because another is still starting.
+	ErrClusterUnavailable = errors.New("cluster can not be reconfigured right now")
+)
+
+type KeyValue struct {
+	Key   string
+	Value []byte
+	// The revision of the last write to the key.
+	ModRevision int64
+}
+
+type EventType int
+
+const (
+	EventPut EventType = iota
+	EventDelete
+)
+
+type Event struct {
+	Type EventType
+	// For deletions only the key and revision are set.
+	Kv *KeyValue
+	// The key before the change, nil if it was created.
+	Prev *KeyValue
+
+	created bool
+}
+
+func (e *Event) IsCreate() bool {
+	return e.created
+}
+
+type WatchResponse struct {
+	Events []*Event
+	// The revision the watch has caught up to.
+	Revision int64
+	// Only reports progress, see RequestProgress.
+	Progress bool
+	// Why the watch ended, the channel is closed right after.
+	Err error
+}
+
+type LeaseID int64
+
+// Holds when the key was last written at ModRevision, zero requires the key to
+// not exist.
+type Condition struct {
+	Key         string
+	ModRevision int64
+}
+
+type OpType int
+
+const (
+	OpTypePut OpType = iota
+	OpTypeDelete
+)
+
+type Op struct {
+	Type  OpType
+	Key   string
+	Value []byte
+	// The key is deleted once the lease expires.
+	Lease LeaseID
+}
+
+func OpPut(key string, value []byte) Op {
+	return Op{Type: OpTypePut, Key: key, Value: value}
+}
+
+func OpDelete(key string) Op {
+	return Op{Type: OpTypeDelete, Key: key}
+}
+
+func (o Op) WithLease(lease LeaseID) Op {
+	o.Lease = lease
+	return o
+}
+
+type Store interface {
+	// Get returns the key, or nil if it does not exist, and the revision it was read at.
+	Get(ctx context.Context, key string) (*KeyValue, int64, error)
+	// List returns every key starting with prefix ordered by key, and the revision
+	// they were read at.
+	List(ctx context.Context, prefix string) ([]*KeyValue, int64, error)
+	// Put writes the key and returns the revision of the write.
+	Put(ctx context.Context, key string, value []byte) (int64, error)
+	// Delete removes the key if it exists and returns the current revision.
+	Delete(ctx context.Context, key string) (int64, error)
+	// Txn applies every op at a single revision if all conditions hold. Reports
+	// whether it did and the revision after.
+	Txn(ctx context.Context, conditions []Condition, ops ...Op) (bool, int64, error)
+
+	// Watch reports changes to key, or to every key starting with it if prefix is
+	// set, made after revision. Zero watches from the current revision. The
+	// channel is closed once ctx is done or after a response carrying an error.
+	Watch(ctx context.Context, key string, prefix bool, revision int64) <-chan *WatchResponse
+	// Makes watches report the revision they have caught up to.
+	RequestProgress(ctx context.Context) error
+
+	// Grant creates a lease which expires after ttl unless kept alive.
+	Grant(ctx context.Context, ttl time.Duration) (LeaseID, error)
+	// KeepAlive restarts the ttl of the lease, fails with ErrLeaseNotFound once it
+	// has expired.
+	KeepAlive(ctx context.Context, lease LeaseID) error
+	// Revoke ends the lease right away, deleting the keys attached to it.
+	Revoke(ctx context.Context, lease LeaseID) error
+
+	Close() error
+}
+
+type Member struct {
+	Id uint64
+	// Empty until the member has started.
+	Name       string
+	PeerUrls   []string
+	ClientUrls []string
+}
+
+// Cluster is implemented by stores made up of several members.
+type Cluster interface {
+	Members(ctx context.Context) ([]*Member, error)
+	// AddMember announces a member which is about to join. Returns it along with
+	// every member of the cluster, which it needs to start.
+	AddMember(ctx context.Context, peerUrls []string) (*Member, []*Member, error)
+	RemoveMember(ctx context.Context, id uint64) error
+}
@@ -0,0 +1,317 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/url"
+	"testing"
+	"time"
+
+	"go.etcd.io/etcd/server/v3/embed"
+	"go.etcd.io/etcd/server/v3/etcdserver/api/v3client"
+)
+
+func freeUrl(t *testing.T) url.URL {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer listener.Close()
+
+	return url.URL{Scheme: "http", Host: listener.Addr().String()}
+}
+
+// Starts a single member etcd in a temporary dir.
+func newEtcd(t *testing.T) Store {
+	t.Helper()
+
+	cfg := embed.NewConfig()
+	cfg.Dir = t.TempDir()
+	cfg.LogLevel = "error"
+
+	peer := freeUrl(t)
+	client := freeUrl(t)
+
+	cfg.ListenPeerUrls = []url.URL{peer}
+	cfg.AdvertisePeerUrls = []url.URL{peer}
+	cfg.ListenClientUrls = []url.URL{client}
+	cfg.AdvertiseClientUrls = []url.URL{client}
+	cfg.InitialCluster = fmt.Sprintf("%s=%s", cfg.Name, peer.String())
+
+	e, err := embed.StartEtcd(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(e.Close)
+
+	select {
+	case <-e.Server.ReadyNotify():
+	case <-time.After(10 * time.Second):
+		t.Fatal("etcd did not start")
+	}
+
+	s := NewEtcd(v3client.New(e.Server))
+	t.Cleanup(func() { s.Close() })
+
+	return s
+}
+
+func newMemory(t *testing.T) Store {
+	s := NewMemory()
+	t.Cleanup(func() { s.Close() })
+	return s
+}
+
+// Runs fn against every store, they have to behave the same.
+func eachStore(t *testing.T, fn func(t *testing.T, s Store)) {
+	stores := []struct {
+		name string
+		open func(t *testing.T) Store
+	}{
+		{"memory", newMemory},
+		{"etcd", newEtcd},
+	}
+
+	for _, store := range stores {
+		t.Run(store.name, func(t *testing.T) {
+			fn(t, store.open(t))
+		})
+	}
+}
+
+// Reads the next response off a watch carrying events.
+func nextEvents(t *testing.T, watch <-chan *WatchResponse) []*Event {
+	t.Helper()
+
+	for {
+		select {
+		case res, ok := <-watch:
+			if !ok {
+				t.Fatal("watch ended")
+			}
+
+			if res.Err != nil {
+				t.Fatal(res.Err)
+			}
+
+			if len(res.Events) > 0 {
+				return res.Events
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("no events were reported")
+		}
+	}
+}
+
+func TestGetPutDelete(t *testing.T) {
+	eachStore(t, func(t *testing.T, s Store) {
+		ctx := context.Background()
+
+		kv, _, err := s.Get(ctx, "/a")
+		if err != nil || kv != nil {
+			t.Fatalf("missing key gave %v, %v", kv, err)
+		}
+
+		revision, err := s.Put(ctx, "/a", []byte("1"))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		kv, read, err := s.Get(ctx, "/a")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if string(kv.Value) != "1" || kv.ModRevision != revision || read != revision {
+			t.Fatalf("got %s at %d read at %d, want 1 at %d", kv.Value, kv.ModRevision, read, revision)
+		}
+
+		deleted, err := s.Delete(ctx, "/a")
+		if err != nil || deleted <= revision {
+			t.Fatalf("delete gave revision %d after %d, %v", deleted, revision, err)
+		}
+
+		// NOTE: Deleting what does not exist is not a write.
+		again, err := s.Delete(ctx, "/a")
+		if err != nil || again != deleted {
+			t.Fatalf("second delete gave revision %d, want %d, %v", again, deleted, err)
+		}
+
+		if kv, _, _ := s.Get(ctx, "/a"); kv != nil {
+			t.Fatal("deleted key still exists")
+		}
+	})
+}
+
+func TestList(t *testing.T) {
+	eachStore(t, func(t *testing.T, s Store) {
+		ctx := context.Background()
+
+		for _, key := range []string{"/b/2", "/a/1", "/b/1", "/c"} {
+			if _, err := s.Put(ctx, key, []byte(key)); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		kvs, _, err := s.List(ctx, "/b/")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(kvs) != 2 || kvs[0].Key != "/b/1" || kvs[1].Key != "/b/2" {
+			t.Fatalf("listed %v, want /b/1 and /b/2 in order", kvs)
+		}
+	})
+}
+
+func TestTxn(t *testing.T) {
+	tests := []struct {
+		name string
+		// Given the revision /a was written at, zero if it was not.
+		conditions func(revision int64) []Condition
+		exists     bool
+		succeeded  bool
+	}{
+		{"create missing", func(int64) []Condition { return []Condition{{Key: "/a"}} }, false, true},
+		{"create existing", func(int64) []Condition { return []Condition{{Key: "/a"}} }, true, false},
+		{"current revision", func(r int64) []Condition { return []Condition{{Key: "/a", ModRevision: r}} }, true, true},
+		{"stale revision", func(r int64) []Condition { return []Condition{{Key: "/a", ModRevision: r - 1}} }, true, false},
+		{"one of two fails", func(r int64) []Condition {
+			return []Condition{{Key: "/a", ModRevision: r}, {Key: "/b", ModRevision: 1}}
+		}, true, false},
+		{"unconditional", func(int64) []Condition { return nil }, true, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			eachStore(t, func(t *testing.T, s Store) {
+				ctx := context.Background()
+
+				// NOTE: Leaves room for a stale revision.
+				if _, err := s.Put(ctx, "/other", nil); err != nil {
+					t.Fatal(err)
+				}
+
+				var revision int64
+				if test.exists {
+					var err error
+					if revision, err = s.Put(ctx, "/a", []byte("old")); err != nil {
+						t.Fatal(err)
+					}
+				}
+
+				succeeded, after, err := s.Txn(ctx, test.conditions(revision), OpPut("/a", []byte("new")), OpPut("/c", []byte("new")))
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				if succeeded != test.succeeded {
+					t.Fatalf("txn succeeded %v, want %v", succeeded, test.succeeded)
+				}
+
+				a, _, _ := s.Get(ctx, "/a")
+				c, _, _ := s.Get(ctx, "/c")
+
+				if !succeeded {
+					if c != nil {
+						t.Fatal("failed txn wrote /c")
+					}
+
+					return
+				}
+
+				// NOTE: Every op of a txn is applied at the same revision.
+				if string(a.Value) != "new" || a.ModRevision != after || c.ModRevision != after {
+					t.Fatalf("txn wrote /a at %d and /c at %d, want both at %d", a.ModRevision, c.ModRevision, after)
+				}
+			})
+		})
+	}
+}
+
+func TestWatch(t *testing.T) {
+	eachStore(t, func(t *testing.T, s Store) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		start, err := s.Put(ctx, "/other", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		watch := s.Watch(ctx, "/w/", true, 0)
+
+		created, _ := s.Put(ctx, "/w/a", []byte("1"))
+		updated, _ := s.Put(ctx, "/w/a", []byte("2"))
+		s.Put(ctx, "/x", nil)
+		deleted, _ := s.Delete(ctx, "/w/a")
+
+		events := []*Event{}
+		for len(events) < 3 {
+			events = append(events, nextEvents(t, watch)...)
+		}
+
+		if e := events[0]; e.Type != EventPut || !e.IsCreate() || e.Prev != nil || e.Kv.ModRevision != created {
+			t.Fatalf("first event is %+v, want the creation", e)
+		}
+
+		if e := events[1]; e.Type != EventPut || e.IsCreate() || string(e.Prev.Value) != "1" || string(e.Kv.Value) != "2" || e.Kv.ModRevision != updated {
+			t.Fatalf("second event is %+v, want the update", e)
+		}
+
+		if e := events[2]; e.Type != EventDelete || string(e.Prev.Value) != "2" || e.Kv.Key != "/w/a" || e.Kv.ModRevision != deleted {
+			t.Fatalf("third event is %+v, want the deletion", e)
+		}
+
+		// NOTE: Watching from a revision replays what came after it.
+		replay := s.Watch(ctx, "/w/a", false, start)
+		if events := nextEvents(t, replay); events[0].Kv.ModRevision != created {
+			t.Fatalf("replay started at %d, want %d", events[0].Kv.ModRevision, created)
+		}
+	})
+}
+
+func TestLease(t *testing.T) {
+	eachStore(t, func(t *testing.T, s Store) {
+		ctx := context.Background()
+
+		lease, err := s.Grant(ctx, 10*time.Second)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		succeeded, _, err := s.Txn(ctx, nil, OpPut("/leased", []byte("1")).WithLease(lease))
+		if err != nil || !succeeded {
+			t.Fatalf("put with a lease gave %v, %v", succeeded, err)
+		}
+
+		if err := s.KeepAlive(ctx, lease); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := s.Revoke(ctx, lease); err != nil {
+			t.Fatal(err)
+		}
+
+		if kv, _, _ := s.Get(ctx, "/leased"); kv != nil {
+			t.Fatal("key outlived its revoked lease")
+		}
+
+		if err := s.KeepAlive(ctx, lease); err != ErrLeaseNotFound {
+			t.Fatalf("keeping a revoked lease alive gave %v, want %v", err, ErrLeaseNotFound)
+		}
+
+		if err := s.Revoke(ctx, lease); err != ErrLeaseNotFound {
+			t.Fatalf("revoking a revoked lease gave %v, want %v", err, ErrLeaseNotFound)
+		}
+
+		if _, _, err := s.Txn(ctx, nil, OpPut("/leased", nil).WithLease(lease)); err != ErrLeaseNotFound {
+			t.Fatalf("put with a revoked lease gave %v, want %v", err, ErrLeaseNotFound)
+		}
+	})
+}
@@ -0,0 +1,87 @@
+package controller
+
+import (
+	"cmp"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/eskpil/rockferry/internal/controller/config"
+	"github.com/eskpil/rockferry/internal/controller/pki"
+	"github.com/eskpil/rockferry/internal/controller/store"
+)
+
+// The names and addresses put in the serving certificate of the controller.
+func servingHosts(c *config.Config) []string {
+	hosts := []string{"localhost", "127.0.0.1", "::1"}
+
+	if hostname, err := os.Hostname(); err == nil {
+		hosts = append(hosts, hostname)
+	}
+
+	// NOTE: Listening on every address tells nothing about how the controller is reached.
+	for _, address := range []string{c.Http.Address, c.Grpc.Address} {
+		host, _, err := net.SplitHostPort(address)
+		if err != nil || host == "" {
+			continue
+		}
+
+		if ip := net.ParseIP(host); ip != nil && ip.IsUnspecified() {
+			continue
+		}
+
+		hosts = append(hosts, host)
+	}
+
+	hosts = append(hosts, c.Pki.Hosts...)
+
+	slices.Sort(hosts)
+	return slices.Compact(hosts)
+}
+
+// ServingTLS loads the ca and returns the config both apis are served with.
+// Clients may present a certificate from the ca, whether they have to is up to
+// the apis. The ca and an admin certificate are written to the pki dir.
+func ServingTLS(ctx context.Context, c *config.Config, s store.Store) (*pki.CA, *tls.Config, error) {
+	path := cmp.Or(c.Pki.SecretFile, filepath.Join(c.Pki.Dir, "ca.secret"))
+
+	// NOTE: A controller joining a cluster has to use the secret of the cluster,
+	// one of its own would not unseal the ca.
+	secret, err := pki.LoadSecret(path, c.Store.Cluster.Join == "")
+	if err != nil {
+		return nil, nil, err
+	}
+
+	ca, err := pki.Load(ctx, s, secret)
+	if errors.Is(err, pki.ErrWrongSecret) {
+		return nil, nil, fmt.Errorf("%w, %s has to match the secret of the other controllers", err, path)
+	}
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// TODO: The serving certificate is only renewed when the controller restarts.
+	cert, err := ca.IssueServer(servingHosts(c), c.Pki.CertificateLifetime)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := ca.WriteAdmin(c.Pki.Dir, c.Pki.CertificateLifetime); err != nil {
+		return nil, nil, err
+	}
+
+	conf := new(tls.Config)
+	conf.MinVersion = tls.VersionTLS12
+	conf.Certificates = []tls.Certificate{*cert}
+	conf.ClientCAs = ca.Pool()
+	// NOTE: Node agents asking for their first certificate have none to present.
+	conf.ClientAuth = tls.VerifyClientCertIfGiven
+
+	return ca, conf, nil
+}
@@ -0,0 +1,29 @@
+package validation
+
+import (
+	"context"
+	"encoding/hex"
+	"time"
+
+	"github.com/eskpil/rockferry/pkg/rockferry"
+	"github.com/eskpil/rockferry/pkg/rockferry/spec"
+)
+
+func init() {
+	register(rockferry.ResourceKindBootstrapToken, validateBootstrapToken)
+}
+
+func validateBootstrapToken(ctx context.Context, lookup Lookup, token *spec.BootstrapTokenSpec, errs *Errors) error {
+	// NOTE: The secret itself is never stored, only its sha256.
+	if decoded, err := hex.DecodeString(token.SecretHash); err != nil || len(decoded) != 32 {
+		errs.add("spec.secret_hash", "must be a hex encoded sha256")
+	}
+
+	if token.Expires.IsZero() {
+		errs.add("spec.expires", "is required")
+	} else if !token.Expires.After(time.Now()) {
+		errs.add("spec.expires", "must be in the future")
+	}
+
+	return nil
+}
@@ -0,0 +1,43 @@
+package validation
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/eskpil/rockferry/pkg/rockferry"
+	"github.com/eskpil/rockferry/pkg/rockferry/spec"
+)
+
+func init() {
+	register(rockferry.ResourceKindClusterRequest, validateClusterRequest)
+}
+
+func validateClusterRequest(ctx context.Context, lookup Lookup, req *spec.ClusterRequestSpec, errs *Errors) error {
+	if req.Name == "" {
+		errs.add("spec.name", "is required")
+	}
+
+	// NOTE: etcd needs a majority of the control planes to make progress, an even
+	// amount adds a member without making the cluster tolerate more failures.
+	if len(req.ControlPlanes) == 0 {
+		errs.add("spec.control_planes", "at least one control plane is required")
+	} else if len(req.ControlPlanes)%2 == 0 {
+		errs.add("spec.control_planes", "must contain an odd number of control planes, got %d", len(req.ControlPlanes))
+	}
+
+	validateNodes("spec.control_planes", req.ControlPlanes, errs)
+	validateNodes("spec.workers", req.Workers, errs)
+
+	return nil
+}
+
+func validateNodes(field string, nodes []*spec.ClusterRequestNodeSpec, errs *Errors) {
+	for i, node := range nodes {
+		if node == nil {
+			errs.add(fmt.Sprintf("%s[%d]", field, i), "must not be null")
+			continue
+		}
+
+		validateTopology(fmt.Sprintf("%s[%d].topology", field, i), node.Topology, errs)
+	}
+}
@@ -0,0 +1,39 @@
+package validation
+
+import (
+	"context"
+
+	"github.com/eskpil/rockferry/pkg/rockferry"
+	"github.com/eskpil/rockferry/pkg/rockferry/spec"
+)
+
+func init() {
+	register(rockferry.ResourceKindEvent, validateEvent)
+}
+
+// NOTE: The object is not required to exist, events outlive what they are about.
+func validateEvent(ctx context.Context, lookup Lookup, event *spec.EventSpec, errs *Errors) error {
+	if event.Object.Kind == "" {
+		errs.add("spec.object.kind", "is required")
+	}
+
+	if event.Object.Id == "" {
+		errs.add("spec.object.id", "is required")
+	}
+
+	switch event.Type {
+	case rockferry.EventTypeNormal, rockferry.EventTypeWarning:
+	default:
+		errs.add("spec.type", "must be %s or %s", rockferry.EventTypeNormal, rockferry.EventTypeWarning)
+	}
+
+	if event.Reason == "" {
+		errs.add("spec.reason", "is required")
+	}
+
+	if event.Count < 1 {
+		errs.add("spec.count", "must be at least 1")
+	}
+
+	return nil
+}
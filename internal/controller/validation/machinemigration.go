@@ -0,0 +1,100 @@
+package validation
+
+import (
+	"context"
+
+	"github.com/eskpil/rockferry/pkg/rockferry"
+	"github.com/eskpil/rockferry/pkg/rockferry/spec"
+)
+
+func init() {
+	registerUpdate(rockferry.ResourceKindMachineMigration, validateMachineMigration)
+}
+
+// The machine and the target node are checked when the migration is created or
+// pointed elsewhere. Once it has run the machine is on the target node, later
+// changes would be rejected otherwise.
+func validateMachineMigration(ctx context.Context, lookup Lookup, migration *spec.MachineMigrationSpec, previous *spec.MachineMigrationSpec, errs *Errors) error {
+	if previous != nil && previous.Machine == migration.Machine && previous.TargetNode == migration.TargetNode {
+		if migration.Machine == "" {
+			errs.add("spec.machine", "is required")
+		}
+
+		if migration.TargetNode == "" {
+			errs.add("spec.target_node", "is required")
+		}
+
+		return nil
+	}
+
+	if err := validateReference(ctx, lookup, "spec.target_node", rockferry.ResourceKindNode, migration.TargetNode, errs); err != nil {
+		return err
+	}
+
+	if migration.TargetNode != "" {
+		generic, err := lookup.Fetch(ctx, rockferry.ResourceKindNode, migration.TargetNode)
+		if err != nil && err != rockferry.ErrorNotFound {
+			return err
+		}
+
+		if err == nil && !rockferry.CastFromMap[spec.NodeSpec, spec.NodeStatus](generic).Status.Ready() {
+			errs.add("spec.target_node", "node %s is not ready", migration.TargetNode)
+		}
+	}
+
+	if migration.Machine == "" {
+		errs.add("spec.machine", "is required")
+		return nil
+	}
+
+	generic, err := lookup.Fetch(ctx, rockferry.ResourceKindMachine, migration.Machine)
+	if err == rockferry.ErrorNotFound {
+		errs.add("spec.machine", "%s %s does not exist", rockferry.ResourceKindMachine, migration.Machine)
+		return nil
+	}
+
+	if err != nil {
+		return err
+	}
+
+	machine := rockferry.CastFromMap[spec.MachineSpec, spec.MachineStatus](generic)
+
+	if machine.Owner == nil || machine.Owner.Kind != rockferry.ResourceKindNode {
+		errs.add("spec.machine", "is not placed on a node")
+		return nil
+	}
+
+	if machine.Owner.Id == migration.TargetNode {
+		errs.add("spec.target_node", "machine is already on node %s", migration.TargetNode)
+	}
+
+	// NOTE: Only memory is copied, the target node has to reach the very same disks.
+	for i, disk := range machine.Spec.Disks {
+		if disk.Network == nil || disk.Network.Protocol != "rbd" {
+			errs.add("spec.machine", "disk %d is not on shared rbd storage", i)
+		}
+	}
+
+	target := new(rockferry.OwnerRef)
+	target.Kind = rockferry.ResourceKindNode
+	target.Id = migration.TargetNode
+
+	networks, err := lookup.ListOwned(ctx, rockferry.ResourceKindNetwork, target)
+	if err != nil {
+		return err
+	}
+
+	available := map[string]bool{}
+	for _, generic := range networks {
+		network := rockferry.CastFromMap[spec.NetworkSpec, rockferry.DefaultStatus](generic)
+		available[network.Spec.Name] = true
+	}
+
+	for i, iface := range machine.Spec.Interfaces {
+		if iface.Network != nil && !available[*iface.Network] {
+			errs.add("spec.machine", "interface %d uses network %s which node %s does not have", i, *iface.Network, migration.TargetNode)
+		}
+	}
+
+	return nil
+}
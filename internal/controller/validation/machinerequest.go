@@ -0,0 +1,56 @@
+package validation
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/eskpil/rockferry/pkg/rockferry"
+	"github.com/eskpil/rockferry/pkg/rockferry/spec"
+)
+
+func init() {
+	register(rockferry.ResourceKindMachineRequest, validateMachineRequest)
+}
+
+func validateMachineRequest(ctx context.Context, lookup Lookup, req *spec.MachineRequestSpec, errs *Errors) error {
+	if req.Name == "" {
+		errs.add("spec.name", "is required")
+	}
+
+	validateTopology("spec.topology", req.Topology, errs)
+
+	// NOTE: An empty network or pool is filled in with the default of the node the request ends up on.
+	if req.Network != "" {
+		if err := validateReference(ctx, lookup, "spec.network", rockferry.ResourceKindNetwork, req.Network, errs); err != nil {
+			return err
+		}
+	}
+
+	for i, disk := range req.Disks {
+		field := fmt.Sprintf("spec.disks[%d]", i)
+
+		if disk == nil {
+			errs.add(field, "must not be null")
+			continue
+		}
+
+		if disk.Capacity == 0 {
+			errs.add(field+".capacity", "must be greater than 0")
+		}
+
+		if disk.Pool != "" {
+			if err := validateReference(ctx, lookup, field+".pool", rockferry.ResourceKindStoragePool, disk.Pool, errs); err != nil {
+				return err
+			}
+		}
+	}
+
+	// NOTE: An empty cdrom is allowed, the machine is then created without an image attached.
+	if req.Cdrom != nil && req.Cdrom.Volume != "" {
+		if err := validateReference(ctx, lookup, "spec.cdrom.volume", rockferry.ResourceKindStorageVolume, req.Cdrom.Volume, errs); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
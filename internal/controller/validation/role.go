@@ -0,0 +1,85 @@
+package validation
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/eskpil/rockferry/pkg/rockferry"
+	"github.com/eskpil/rockferry/pkg/rockferry/spec"
+)
+
+func init() {
+	register(rockferry.ResourceKindRole, validateRole)
+	register(rockferry.ResourceKindRoleBinding, validateRoleBinding)
+}
+
+func validateRole(ctx context.Context, lookup Lookup, role *spec.RoleSpec, errs *Errors) error {
+	for i, rule := range role.Rules {
+		field := fmt.Sprintf("spec.rules[%d]", i)
+
+		if rule == nil {
+			errs.add(field, "is required")
+			continue
+		}
+
+		if len(rule.Verbs) == 0 {
+			errs.add(field+".verbs", "is required")
+		}
+
+		for j, verb := range rule.Verbs {
+			if verb != rockferry.VerbAll && !slices.Contains(rockferry.Verbs, verb) {
+				errs.add(fmt.Sprintf("%s.verbs[%d]", field, j), "unknown verb %s", verb)
+			}
+		}
+
+		if len(rule.Kinds) == 0 {
+			errs.add(field+".kinds", "is required")
+		}
+
+		for j, kind := range rule.Kinds {
+			if kind == "" {
+				errs.add(fmt.Sprintf("%s.kinds[%d]", field, j), "is required")
+			}
+		}
+
+		switch rule.Scope {
+		case "", spec.RoleScopeAll, spec.RoleScopeNode, spec.RoleScopeOwn:
+		default:
+			errs.add(field+".scope", "unknown scope %s", rule.Scope)
+		}
+	}
+
+	return nil
+}
+
+func validateRoleBinding(ctx context.Context, lookup Lookup, binding *spec.RoleBindingSpec, errs *Errors) error {
+	if _, ok := rockferry.BuiltinRoles[binding.Role]; !ok {
+		if err := validateReference(ctx, lookup, "spec.role", rockferry.ResourceKindRole, binding.Role, errs); err != nil {
+			return err
+		}
+	}
+
+	if len(binding.Subjects) == 0 {
+		errs.add("spec.subjects", "is required")
+	}
+
+	for i, subject := range binding.Subjects {
+		field := fmt.Sprintf("spec.subjects[%d]", i)
+
+		if subject == nil {
+			errs.add(field, "is required")
+			continue
+		}
+
+		if subject.Kind != spec.RoleSubjectKindUser && subject.Kind != spec.RoleSubjectKindGroup {
+			errs.add(field+".kind", "must be %s or %s", spec.RoleSubjectKindUser, spec.RoleSubjectKindGroup)
+		}
+
+		if subject.Name == "" {
+			errs.add(field+".name", "is required")
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,41 @@
+package validation
+
+import (
+	"context"
+
+	"github.com/eskpil/rockferry/pkg/rockferry"
+	"github.com/eskpil/rockferry/pkg/rockferry/spec"
+)
+
+func validateTopology(field string, topology spec.Topology, errs *Errors) {
+	if topology.Cores == 0 {
+		errs.add(field+".cores", "must be greater than 0")
+	}
+
+	if topology.Threads == 0 {
+		errs.add(field+".threads", "must be greater than 0")
+	}
+
+	if topology.Memory == 0 {
+		errs.add(field+".memory", "must be greater than 0")
+	}
+}
+
+// Rejects field unless a resource of kind with the given id exists.
+func validateReference(ctx context.Context, lookup Lookup, field string, kind rockferry.ResourceKind, id string, errs *Errors) error {
+	if id == "" {
+		errs.add(field, "is required")
+		return nil
+	}
+
+	exists, err := lookup.Exists(ctx, kind, id)
+	if err != nil {
+		return err
+	}
+
+	if !exists {
+		errs.add(field, "%s %s does not exist", kind, id)
+	}
+
+	return nil
+}
@@ -0,0 +1,125 @@
+package validation
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/eskpil/rockferry/pkg/rockferry"
+)
+
+// Describes why the value at Field was rejected. Field is a dotted path into
+// the resource, for example spec.disks[0].capacity.
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// Returned when a resource does not pass validation.
+type Errors []*FieldError
+
+func (e Errors) Error() string {
+	messages := make([]string, len(e))
+	for i, err := range e {
+		messages[i] = fmt.Sprintf("%s: %s", err.Field, err.Message)
+	}
+
+	return strings.Join(messages, "; ")
+}
+
+func (e *Errors) add(field string, format string, args ...any) {
+	err := new(FieldError)
+	err.Field = field
+	err.Message = fmt.Sprintf(format, args...)
+
+	*e = append(*e, err)
+}
+
+// Used by validators to make sure resources referenced by a spec exist.
+type Lookup interface {
+	Exists(ctx context.Context, kind rockferry.ResourceKind, id string) (bool, error)
+	// Returns ErrorNotFound if the resource does not exist.
+	Fetch(ctx context.Context, kind rockferry.ResourceKind, id string) (*rockferry.Generic, error)
+	ListOwned(ctx context.Context, kind rockferry.ResourceKind, owner *rockferry.OwnerRef) ([]*rockferry.Generic, error)
+}
+
+type validator func(ctx context.Context, lookup Lookup, resource *rockferry.Generic, previous *rockferry.Generic) (Errors, error)
+
+var registry = map[rockferry.ResourceKind]validator{}
+
+// Registers fn for kind. The spec is decoded into S before fn is called, a spec
+// which can not be decoded is rejected without calling fn.
+func register[S any](kind rockferry.ResourceKind, fn func(ctx context.Context, lookup Lookup, spec *S, errs *Errors) error) {
+	registerUpdate(kind, func(ctx context.Context, lookup Lookup, spec *S, _ *S, errs *Errors) error {
+		return fn(ctx, lookup, spec, errs)
+	})
+}
+
+// Like register, but fn is also given the spec before the change, nil when the
+// resource is being created. Lets checks against the state of other resources
+// be skipped once they no longer apply.
+func registerUpdate[S any](kind rockferry.ResourceKind, fn func(ctx context.Context, lookup Lookup, spec *S, previous *S, errs *Errors) error) {
+	registry[kind] = func(ctx context.Context, lookup Lookup, resource *rockferry.Generic, previous *rockferry.Generic) (Errors, error) {
+		errs := Errors{}
+
+		spec, err := decode[S](resource.Spec)
+		if err != nil {
+			if typeErr, ok := err.(*json.UnmarshalTypeError); ok && typeErr.Field != "" {
+				errs.add("spec."+typeErr.Field, "expected %s", typeErr.Type)
+			} else {
+				errs.add("spec", "malformed spec")
+			}
+
+			return errs, nil
+		}
+
+		var previousSpec *S
+		if previous != nil {
+			// NOTE: A stored spec which no longer decodes is validated like a new one.
+			previousSpec, _ = decode[S](previous.Spec)
+		}
+
+		if err := fn(ctx, lookup, spec, previousSpec, &errs); err != nil {
+			return nil, err
+		}
+
+		return errs, nil
+	}
+}
+
+func decode[S any](in any) (*S, error) {
+	bytes, err := json.Marshal(in)
+	if err != nil {
+		return nil, err
+	}
+
+	out := new(S)
+	if err := json.Unmarshal(bytes, out); err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
+
+// Validates the spec of resource against the validator registered for its kind,
+// kinds without a validator are always accepted. Previous is the resource before
+// the change, nil when it is being created. If the resource is rejected the
+// returned error is of type Errors.
+func Validate(ctx context.Context, lookup Lookup, resource *rockferry.Generic, previous *rockferry.Generic) error {
+	fn, ok := registry[resource.Kind]
+	if !ok {
+		return nil
+	}
+
+	errs, err := fn(ctx, lookup, resource, previous)
+	if err != nil {
+		return err
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+
+	return nil
+}
@@ -1,6 +1,32 @@
 package config
 
+const (
+	HypervisorLibvirt = "libvirt"
+	// Keeps everything in memory, for running the node agent without libvirt.
+	HypervisorFake = "fake"
+)
+
 type Config struct {
-	Id  string `json:"id"`
 	Url string `json:"url"`
+
+	// Either "libvirt", the default, or "fake".
+	Hypervisor string `json:"hypervisor"`
+
+	// Where the node keeps the id and certificate it was registered with,
+	// identity.json if empty.
+	Identity string `json:"identity"`
+	// Presented once to register the node, while it has no identity yet.
+	BootstrapToken string `json:"bootstrap_token" yaml:"bootstrap_token"`
+
+	// Connects to the controller without tls.
+	Insecure bool `json:"insecure"`
+	// Verifies the controller, the system roots are used if empty.
+	CA string `json:"ca"`
+
+	// Deprecated: nodes are registered with a bootstrap token instead. Only read
+	// by nodes without an identity, which adopt the id of the node they used to
+	// run as along with the certificate and key it was issued.
+	Id   string `json:"id"`
+	Cert string `json:"cert"`
+	Key  string `json:"key"`
 }
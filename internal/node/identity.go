@@ -0,0 +1,255 @@
+package node
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/eskpil/rockferry/internal/node/config"
+	"github.com/eskpil/rockferry/pkg/rockferry"
+)
+
+// How long the controller gets to register the node.
+const registerTimeout = 30 * time.Second
+
+const defaultIdentityPath = "identity.json"
+
+// Who the node is to the controller, kept locally once registered.
+type identity struct {
+	Id string `json:"id"`
+	// Pem encoded, both are empty when the controller is served without tls.
+	Cert []byte `json:"cert,omitempty"`
+	Key  []byte `json:"key,omitempty"`
+}
+
+func identityPath(c *config.Config) string {
+	if c.Identity == "" {
+		return defaultIdentityPath
+	}
+
+	return c.Identity
+}
+
+// Returns nil if the node has not been registered yet.
+func loadIdentity(path string) (*identity, error) {
+	contents, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	id := new(identity)
+	if err := json.Unmarshal(contents, id); err != nil {
+		return nil, fmt.Errorf("failed to read the identity in %s: %w", path, err)
+	}
+
+	return id, nil
+}
+
+func (i *identity) save(path string) error {
+	contents, err := json.Marshal(i)
+	if err != nil {
+		return err
+	}
+
+	// NOTE: Written next to the identity and moved in place, a node stopped
+	// halfway is either registered or not.
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".identity-*")
+	if err != nil {
+		return err
+	}
+
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(contents); err != nil {
+		tmp.Close()
+		return err
+	}
+
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
+
+// Registers the node with the bootstrap token, the controller decides its id.
+func register(c *config.Config, opts []rockferry.ClientOption) (*identity, error) {
+	if c.BootstrapToken == "" {
+		return nil, errors.New("the node is not registered and has no bootstrap token to register with")
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+
+	template := new(x509.CertificateRequest)
+	template.Subject = pkix.Name{CommonName: "rockferry node"}
+
+	der, err := x509.CreateCertificateRequest(rand.Reader, template, key)
+	if err != nil {
+		return nil, err
+	}
+
+	csr := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE REQUEST", Bytes: der})
+
+	client, err := rockferry.New(c.Url, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), registerTimeout)
+	defer cancel()
+
+	registration, err := client.RegisterNode(ctx, c.BootstrapToken, csr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to register the node: %w", err)
+	}
+
+	id := new(identity)
+	id.Id = registration.Id
+
+	if len(registration.Certificate) > 0 {
+		encoded, err := x509.MarshalECPrivateKey(key)
+		if err != nil {
+			return nil, err
+		}
+
+		id.Cert = registration.Certificate
+		id.Key = pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: encoded})
+	}
+
+	return id, nil
+}
+
+// Turns the id, certificate and key a node used to be configured with into an
+// identity, as long as the controller still knows the node.
+func adopt(c *config.Config, opts []rockferry.ClientOption) (*identity, []rockferry.ClientOption, error) {
+	id := new(identity)
+	id.Id = c.Id
+
+	if !c.Insecure {
+		if c.Cert == "" || c.Key == "" {
+			return nil, nil, fmt.Errorf("node %s is configured with an id but no cert and key, which is no longer supported: remove the id and register the node again with a bootstrap token", c.Id)
+		}
+
+		var err error
+		if id.Cert, err = os.ReadFile(c.Cert); err != nil {
+			return nil, nil, fmt.Errorf("failed to read the certificate of node %s, remove the id to register the node again with a bootstrap token: %w", c.Id, err)
+		}
+
+		if id.Key, err = os.ReadFile(c.Key); err != nil {
+			return nil, nil, fmt.Errorf("failed to read the key of node %s, remove the id to register the node again with a bootstrap token: %w", c.Id, err)
+		}
+
+		cert, err := tls.X509KeyPair(id.Cert, id.Key)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		opts = append(opts, rockferry.WithClientCertificate(cert))
+	}
+
+	client, err := rockferry.New(c.Url, opts...)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), registerTimeout)
+	defer cancel()
+
+	_, err = client.Nodes().Get(ctx, c.Id, nil)
+	if err == rockferry.ErrorNotFound {
+		return nil, nil, fmt.Errorf("node %s the config names does not exist, remove the id and register the node with a bootstrap token", c.Id)
+	}
+
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to look up node %s: %w", c.Id, err)
+	}
+
+	return id, opts, nil
+}
+
+// Loads the identity of the node, registering it on the first start. Returns
+// it along with how to connect to the controller as the node.
+func ensureIdentity(c *config.Config) (*identity, []rockferry.ClientOption, error) {
+	opts := []rockferry.ClientOption{}
+
+	if !c.Insecure {
+		conf, err := rockferry.LoadTLS(c.CA, "", "")
+		if err != nil {
+			return nil, nil, err
+		}
+
+		opts = append(opts, rockferry.WithTLS(conf))
+	}
+
+	path := identityPath(c)
+
+	id, err := loadIdentity(path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if id == nil && c.Id != "" {
+		id, opts, err := adopt(c, opts)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if err := id.save(path); err != nil {
+			return nil, nil, fmt.Errorf("failed to keep the identity of node %s: %w", id.Id, err)
+		}
+
+		fmt.Println("adopted node", id.Id, "from the config, its id, cert and key can be removed from it")
+
+		return id, opts, nil
+	}
+
+	if id != nil && c.Id != "" && c.Id != id.Id {
+		fmt.Println("ignoring the id in the config, the node is registered as", id.Id)
+	}
+
+	if id == nil {
+		// NOTE: Only the controller is verified, the node has nothing to present yet.
+		if id, err = register(c, opts); err != nil {
+			return nil, nil, err
+		}
+
+		if err := id.save(path); err != nil {
+			return nil, nil, fmt.Errorf("registered as %s but failed to keep the identity: %w", id.Id, err)
+		}
+
+		fmt.Println("registered as node", id.Id)
+	}
+
+	if c.Insecure {
+		return id, opts, nil
+	}
+
+	if len(id.Cert) == 0 {
+		return nil, nil, fmt.Errorf("the identity in %s has no certificate, the node was registered without tls", path)
+	}
+
+	cert, err := tls.X509KeyPair(id.Cert, id.Key)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return id, append(opts, rockferry.WithClientCertificate(cert)), nil
+}
@@ -0,0 +1,552 @@
+// Package fake is an in-memory hypervisor. Domains, storage pools, volumes and
+// networks only exist in memory, which allows running the node agent without
+// libvirt.
+package fake
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/eskpil/rockferry/internal/node/queries"
+	"github.com/eskpil/rockferry/pkg/rockferry"
+	"github.com/eskpil/rockferry/pkg/rockferry/spec"
+	"github.com/google/uuid"
+)
+
+var (
+	ErrorDomainNotFound  = errors.New("domain not found")
+	ErrorDomainRunning   = errors.New("domain is already running")
+	ErrorDomainInactive  = errors.New("domain is not running")
+	ErrorPoolNotFound    = errors.New("storage pool not found")
+	ErrorVolumeNotFound  = errors.New("storage volume not found")
+	ErrorVolumeExists    = errors.New("storage volume already exists")
+	ErrorNoSpace         = errors.New("not enough space in storage pool")
+	ErrorPeerUnreachable = errors.New("migration target is unreachable")
+)
+
+// The first vnc port handed out, like libvirt does.
+const firstVNCPort = 5700
+
+type domain struct {
+	spec  spec.MachineSpec
+	state spec.MachineStatusState
+	vnc   int32
+}
+
+type pool struct {
+	id      string
+	spec    spec.StoragePoolSpec
+	path    string
+	volumes map[string]*spec.StorageVolumeSpec
+}
+
+type Hypervisor struct {
+	mu sync.Mutex
+
+	domains  map[string]*domain
+	pools    map[string]*pool
+	networks []*rockferry.Network
+	peers    map[string]*Hypervisor
+
+	nextVNC int32
+}
+
+var _ queries.Hypervisor = (*Hypervisor)(nil)
+
+// Creates a hypervisor which looks like a fresh libvirt install, with a default
+// dir pool and a default nat network.
+func New() *Hypervisor {
+	h := new(Hypervisor)
+	h.domains = map[string]*domain{}
+	h.pools = map[string]*pool{}
+	h.peers = map[string]*Hypervisor{}
+	h.nextVNC = firstVNCPort
+
+	pool := new(spec.StoragePoolSpec)
+	pool.Name = "default"
+	pool.Type = "dir"
+	pool.Capacity = 100 << 30
+	pool.Available = pool.Capacity
+	h.AddStoragePool(pool)
+
+	network := new(spec.NetworkSpec)
+	network.Name = "default"
+	network.Bridge.Name = "virbr0"
+	network.Forward.Mode = "nat"
+	network.Mtu = 1500
+	h.AddNetwork(network)
+
+	return h
+}
+
+// Adds a storage pool and returns its id. Capacity, Allocation and Available
+// are kept up to date as volumes come and go.
+func (h *Hypervisor) AddStoragePool(s *spec.StoragePoolSpec) string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	p := new(pool)
+	p.id = uuid.NewString()
+	p.spec = *s
+	p.path = fmt.Sprintf("/var/lib/libvirt/images/%s", s.Name)
+	p.volumes = map[string]*spec.StorageVolumeSpec{}
+
+	if s.Type == "rbd" && s.Source != nil {
+		p.path = s.Source.Name
+	}
+
+	h.pools[s.Name] = p
+
+	return p.id
+}
+
+// Adds a network and returns its id.
+func (h *Hypervisor) AddNetwork(s *spec.NetworkSpec) string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	network := new(rockferry.Network)
+	network.Id = uuid.NewString()
+	network.Kind = rockferry.ResourceKindNetwork
+	network.Annotations = map[string]string{"origin": "sync"}
+	network.Spec = *s
+
+	h.networks = append(h.networks, network)
+
+	return network.Id
+}
+
+// Makes other reachable at uri, domains migrated to uri end up on other.
+func (h *Hypervisor) AddPeer(uri string, other *Hypervisor) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.peers[uri] = other
+}
+
+// Forces the state of a domain, for example to simulate a crash.
+func (h *Hypervisor) SetDomainState(id string, state spec.MachineStatusState) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	d, ok := h.domains[id]
+	if !ok {
+		return ErrorDomainNotFound
+	}
+
+	d.state = state
+
+	return nil
+}
+
+// The ids of every defined domain.
+func (h *Hypervisor) Domains() []string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	ids := []string{}
+	for id := range h.domains {
+		ids = append(ids, id)
+	}
+
+	slices.Sort(ids)
+
+	return ids
+}
+
+func (h *Hypervisor) domain(id string) (*domain, error) {
+	d, ok := h.domains[id]
+	if !ok {
+		return nil, ErrorDomainNotFound
+	}
+
+	return d, nil
+}
+
+func (h *Hypervisor) define(id string, s *spec.MachineSpec) *domain {
+	d, ok := h.domains[id]
+	if !ok {
+		d = new(domain)
+		d.state = spec.MachineStatusStateStopped
+		d.vnc = h.nextVNC
+		h.nextVNC++
+
+		h.domains[id] = d
+	}
+
+	d.spec = *s
+	d.spec.Disks = slices.Clone(s.Disks)
+
+	return d
+}
+
+func (h *Hypervisor) CreateDomain(id string, s *spec.MachineSpec) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if d, ok := h.domains[id]; ok && d.state == spec.MachineStatusStateRunning {
+		return ErrorDomainRunning
+	}
+
+	h.define(id, s).state = spec.MachineStatusStateRunning
+
+	return nil
+}
+
+func (h *Hypervisor) DomainExists(id string) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	_, ok := h.domains[id]
+	return ok
+}
+
+func (h *Hypervisor) GetDomainState(id string) (spec.MachineStatusState, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	d, err := h.domain(id)
+	if err != nil {
+		return "", err
+	}
+
+	return d.state, nil
+}
+
+func (h *Hypervisor) SyncDomainStatus(id string) (*spec.MachineStatus, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	d, err := h.domain(id)
+	if err != nil {
+		return nil, err
+	}
+
+	status := new(spec.MachineStatus)
+	status.State = d.state
+	status.VNC = append(status.VNC, spec.MachineStatusVNC{Port: d.vnc, Type: spec.MachineStatusVNCTypeWebsocket})
+
+	// NOTE: Like the guest agent, addresses are only known while the domain runs.
+	if d.state != spec.MachineStatusStateRunning {
+		return status, nil
+	}
+
+	status.Interfaces = make([]spec.MachineStatusInterface, len(d.spec.Interfaces))
+
+	for i, iface := range d.spec.Interfaces {
+		addr := spec.MachineStatusIp{Ip: fmt.Sprintf("192.168.122.%d", 2+i), Private: true}
+
+		status.Interfaces[i] = spec.MachineStatusInterface{
+			Name:  fmt.Sprintf("eth%d", i),
+			Mac:   iface.Mac,
+			Addrs: []spec.MachineStatusIp{addr},
+		}
+
+		status.ReachableIps = append(status.ReachableIps, addr)
+	}
+
+	return status, nil
+}
+
+func (h *Hypervisor) StartDomain(id string) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	d, err := h.domain(id)
+	if err != nil {
+		return err
+	}
+
+	if d.state == spec.MachineStatusStateRunning {
+		return ErrorDomainRunning
+	}
+
+	d.state = spec.MachineStatusStateRunning
+
+	return nil
+}
+
+// Guests always honour the request, the domain stops right away.
+func (h *Hypervisor) ShutdownDomain(id string) error {
+	return h.stop(id)
+}
+
+func (h *Hypervisor) DestroyDomain(id string) error {
+	return h.stop(id)
+}
+
+func (h *Hypervisor) stop(id string) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	d, err := h.domain(id)
+	if err != nil {
+		return err
+	}
+
+	if d.state != spec.MachineStatusStateRunning {
+		return ErrorDomainInactive
+	}
+
+	d.state = spec.MachineStatusStateStopped
+
+	return nil
+}
+
+func (h *Hypervisor) UndefineDomain(id string) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if _, err := h.domain(id); err != nil {
+		return err
+	}
+
+	delete(h.domains, id)
+
+	return nil
+}
+
+func (h *Hypervisor) DomainAddDisk(id string, disk *spec.MachineSpecDisk) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	d, err := h.domain(id)
+	if err != nil {
+		return err
+	}
+
+	d.spec.Disks = append(d.spec.Disks, disk)
+
+	return nil
+}
+
+func (h *Hypervisor) DomainRemoveDisk(id string, disk *spec.MachineSpecDisk) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	d, err := h.domain(id)
+	if err != nil {
+		return err
+	}
+
+	d.spec.Disks = slices.DeleteFunc(d.spec.Disks, func(other *spec.MachineSpecDisk) bool {
+		return other.Key == disk.Key
+	})
+
+	return nil
+}
+
+// Moves the domain to the peer added for uri, reporting the memory of the
+// domain as copied in a single step.
+func (h *Hypervisor) MigrateDomain(ctx context.Context, id string, uri string, progress func(*queries.MigrationProgress)) error {
+	h.mu.Lock()
+
+	d, err := h.domain(id)
+	if err != nil {
+		h.mu.Unlock()
+		return err
+	}
+
+	peer, ok := h.peers[uri]
+	if !ok {
+		h.mu.Unlock()
+		return ErrorPeerUnreachable
+	}
+
+	if d.state != spec.MachineStatusStateRunning {
+		h.mu.Unlock()
+		return ErrorDomainInactive
+	}
+
+	migrated := d.spec
+	h.mu.Unlock()
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	started := time.Now()
+
+	p := new(queries.MigrationProgress)
+	p.DataTotal = migrated.Topology.Memory
+	p.DataProcessed = migrated.Topology.Memory
+	p.TimeElapsed = time.Since(started)
+	progress(p)
+
+	if err := peer.CreateDomain(id, &migrated); err != nil {
+		return err
+	}
+
+	return h.UndefineDomain(id)
+}
+
+func (h *Hypervisor) CreateVolume(poolName string, name string, format string, capacity uint64, allocation uint64) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	p, ok := h.pools[poolName]
+	if !ok {
+		return ErrorPoolNotFound
+	}
+
+	if _, ok := p.volumes[name]; ok {
+		return ErrorVolumeExists
+	}
+
+	if allocation > p.spec.Available {
+		return ErrorNoSpace
+	}
+
+	volume := new(spec.StorageVolumeSpec)
+	volume.Name = name
+	volume.Key = fmt.Sprintf("%s/%s", p.path, name)
+	volume.Capacity = capacity
+	volume.Allocation = allocation
+
+	p.volumes[name] = volume
+	p.spec.Allocation += allocation
+	p.spec.Available -= allocation
+
+	return nil
+}
+
+func (h *Hypervisor) QueryVolumeSpec(poolName string, name string) (*spec.StorageVolumeSpec, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	p, ok := h.pools[poolName]
+	if !ok {
+		return nil, ErrorPoolNotFound
+	}
+
+	volume, ok := p.volumes[name]
+	if !ok {
+		return nil, ErrorVolumeNotFound
+	}
+
+	out := new(spec.StorageVolumeSpec)
+	*out = *volume
+
+	// NOTE: Same as libvirt, the type is guessed from the key.
+	if filepath.Ext(out.Key) == ".iso" {
+		out.Type = spec.StorageVolumeTypeIso
+	} else {
+		out.Type = spec.StorageVolumeTypeDiskImage
+	}
+
+	return out, nil
+}
+
+func (h *Hypervisor) QueryStorageVolumes() ([]*rockferry.StorageVolume, error) {
+	h.mu.Lock()
+	pools := []*pool{}
+	for _, p := range h.pools {
+		pools = append(pools, p)
+	}
+	h.mu.Unlock()
+
+	volumes := []*rockferry.StorageVolume{}
+
+	for _, p := range pools {
+		h.mu.Lock()
+		names := []string{}
+		for name := range p.volumes {
+			names = append(names, name)
+		}
+		h.mu.Unlock()
+
+		for _, name := range names {
+			volumeSpec, err := h.QueryVolumeSpec(p.spec.Name, name)
+			if err == ErrorVolumeNotFound {
+				continue
+			}
+
+			if err != nil {
+				return nil, err
+			}
+
+			volume := new(rockferry.StorageVolume)
+
+			volume.Id = fmt.Sprintf("%s/%s", p.id, name)
+			volume.Annotations = map[string]string{}
+			volume.Annotations["origin"] = "sync"
+
+			volume.Owner = new(rockferry.OwnerRef)
+			volume.Owner.Kind = rockferry.ResourceKindStoragePool
+			volume.Owner.Id = p.id
+
+			volume.Kind = rockferry.ResourceKindStorageVolume
+			volume.Phase = rockferry.PhaseCreated
+
+			volume.Spec = *volumeSpec
+
+			volumes = append(volumes, volume)
+		}
+	}
+
+	return volumes, nil
+}
+
+func (h *Hypervisor) QueryStoragePools() ([]*rockferry.StoragePool, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	out := []*rockferry.StoragePool{}
+
+	for _, p := range h.pools {
+		res := new(rockferry.StoragePool)
+
+		res.Id = p.id
+		res.Owner = new(rockferry.OwnerRef)
+		res.Owner.Kind = rockferry.ResourceKindNode
+
+		res.Spec = p.spec
+
+		res.Kind = rockferry.ResourceKindStoragePool
+		res.Phase = rockferry.PhaseCreated
+
+		out = append(out, res)
+	}
+
+	return out, nil
+}
+
+func (h *Hypervisor) DeleteStorageVolume(key string) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for _, p := range h.pools {
+		for name, volume := range p.volumes {
+			if volume.Key != key {
+				continue
+			}
+
+			delete(p.volumes, name)
+			p.spec.Allocation -= volume.Allocation
+			p.spec.Available += volume.Allocation
+
+			return nil
+		}
+	}
+
+	return ErrorVolumeNotFound
+}
+
+func (h *Hypervisor) ListAllNetworks() ([]*rockferry.Network, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	networks := make([]*rockferry.Network, len(h.networks))
+
+	for i, network := range h.networks {
+		networks[i] = new(rockferry.Network)
+		*networks[i] = *network
+	}
+
+	return networks, nil
+}
@@ -0,0 +1,75 @@
+package queries
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/digitalocean/go-libvirt"
+	"github.com/google/uuid"
+)
+
+type MigrationProgress struct {
+	DataTotal     uint64
+	DataProcessed uint64
+	DataRemaining uint64
+	TimeElapsed   time.Duration
+}
+
+// How often progress is reported while a migration runs.
+const migrationProgressInterval = 2 * time.Second
+
+// Live migrates the domain to the libvirt daemon at uri. The daemons talk to each
+// other directly, the domain is defined on the target and undefined here once it
+// is done. Cancelling ctx aborts the migration.
+func (c *Client) MigrateDomain(ctx context.Context, id string, uri string, progress func(*MigrationProgress)) error {
+	domId := uuid.MustParse(id)
+
+	dom, err := c.v.DomainLookupByUUID(libvirt.UUID(domId))
+	if err != nil {
+		return err
+	}
+
+	done := make(chan struct{})
+	wg := new(sync.WaitGroup)
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+
+		ticker := time.NewTicker(migrationProgressInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-done:
+				return
+			case <-ctx.Done():
+				c.v.DomainAbortJob(dom)
+				return
+			case <-ticker.C:
+				_, elapsed, _, total, processed, remaining, _, _, _, _, _, _, err := c.v.DomainGetJobInfo(dom)
+				if err != nil {
+					continue
+				}
+
+				p := new(MigrationProgress)
+				p.DataTotal = total
+				p.DataProcessed = processed
+				p.DataRemaining = remaining
+				p.TimeElapsed = time.Duration(elapsed) * time.Millisecond
+
+				progress(p)
+			}
+		}
+	}()
+
+	flags := libvirt.MigrateLive | libvirt.MigratePeer2peer | libvirt.MigratePersistDest | libvirt.MigrateUndefineSource
+
+	_, err = c.v.DomainMigratePerform3Params(dom, libvirt.OptString{uri}, []libvirt.TypedParam{}, []byte{}, flags)
+
+	close(done)
+	wg.Wait()
+
+	return err
+}
@@ -1 +1,34 @@
 package queries
+
+import (
+	"context"
+
+	"github.com/eskpil/rockferry/pkg/rockferry"
+	"github.com/eskpil/rockferry/pkg/rockferry/spec"
+)
+
+// Everything the node agent needs from the hypervisor. Implemented by Client
+// on top of libvirt, and by the in-memory fake in queries/fake.
+type Hypervisor interface {
+	CreateDomain(id string, spec *spec.MachineSpec) error
+	DomainExists(id string) bool
+	GetDomainState(id string) (spec.MachineStatusState, error)
+	SyncDomainStatus(id string) (*spec.MachineStatus, error)
+	StartDomain(id string) error
+	ShutdownDomain(id string) error
+	DestroyDomain(id string) error
+	UndefineDomain(id string) error
+	DomainAddDisk(id string, spec *spec.MachineSpecDisk) error
+	DomainRemoveDisk(id string, spec *spec.MachineSpecDisk) error
+	MigrateDomain(ctx context.Context, id string, uri string, progress func(*MigrationProgress)) error
+
+	CreateVolume(poolName string, name string, format string, capacity uint64, allocation uint64) error
+	QueryVolumeSpec(poolName string, name string) (*spec.StorageVolumeSpec, error)
+	QueryStorageVolumes() ([]*rockferry.StorageVolume, error)
+	QueryStoragePools() ([]*rockferry.StoragePool, error)
+	DeleteStorageVolume(key string) error
+
+	ListAllNetworks() ([]*rockferry.Network, error)
+}
+
+var _ Hypervisor = (*Client)(nil)
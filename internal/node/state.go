@@ -2,8 +2,11 @@ package node
 
 import (
 	"context"
+	"fmt"
 
 	"github.com/eskpil/rockferry/internal/node/config"
+	"github.com/eskpil/rockferry/internal/node/queries"
+	"github.com/eskpil/rockferry/internal/node/queries/fake"
 	"github.com/eskpil/rockferry/internal/node/tasks"
 	"github.com/eskpil/rockferry/pkg/rockferry"
 )
@@ -11,58 +14,75 @@ import (
 type State struct {
 	Client *rockferry.Client
 
-	t *tasks.TaskList
+	nodeId string
+	t      *tasks.TaskList
 }
 
-func createNodeResource(client *rockferry.Client, conf *config.Config) error {
-	node := new(rockferry.Node)
-
-	node.Id = conf.Id
-	node.Kind = rockferry.ResourceKindNode
-	node.Phase = rockferry.PhaseCreated
+// The controller creates the node when registering it, a node which is gone
+// has been removed and has to be registered again.
+func checkNodeResource(client *rockferry.Client, id string) error {
+	_, err := client.Nodes().Get(context.Background(), id, nil)
+	if err == rockferry.ErrorNotFound {
+		return fmt.Errorf("node %s has been removed, remove its identity to register it again", id)
+	}
 
-	return client.Nodes().Create(context.Background(), node)
+	return err
 }
 
-func ensureNodeResource(client *rockferry.Client, conf *config.Config) error {
-	_, err := client.Nodes().Get(context.Background(), conf.Id, nil)
-	if err != nil {
-		if err == rockferry.ErrorNotFound {
-			return createNodeResource(client, conf)
+func New(c *config.Config) (*State, error) {
+	var hypervisor queries.Hypervisor
+
+	switch c.Hypervisor {
+	case config.HypervisorFake:
+		hypervisor = fake.New()
+	case config.HypervisorLibvirt, "":
+		client, err := queries.NewClient()
+		if err != nil {
+			return nil, err
 		}
 
-		return err
+		hypervisor = client
+	default:
+		return nil, fmt.Errorf("unknown hypervisor %s", c.Hypervisor)
 	}
 
-	return nil
+	return NewWithHypervisor(c, hypervisor)
 }
 
-func New(c *config.Config) (*State, error) {
-	var err error
+// Like New, but runs against the given hypervisor. Passing a fake allows running
+// the whole node agent without libvirt.
+func NewWithHypervisor(c *config.Config, hypervisor queries.Hypervisor) (*State, error) {
 	state := new(State)
 
-	client, err := rockferry.New(c.Url)
+	id, opts, err := ensureIdentity(c)
 	if err != nil {
 		return nil, err
 	}
 
-	if err := ensureNodeResource(client, c); err != nil {
+	client, err := rockferry.New(c.Url, opts...)
+	if err != nil {
 		return nil, err
 	}
 
-	state.t, err = tasks.NewTaskList(client, c.Id)
-	if err != nil {
+	if err := checkNodeResource(client, id.Id); err != nil {
 		return nil, err
 	}
 
+	state.t = tasks.NewTaskList(client, hypervisor, id.Id)
+
 	state.Client = client
+	state.nodeId = id.Id
 
-	return state, err
+	return state, nil
 }
 
 func (s *State) Watch(ctx context.Context) error {
 	ctx = context.WithoutCancel(ctx)
 
+	if err := s.t.StartCaches(ctx); err != nil {
+		return err
+	}
+
 	if err := s.startupTasks(); err != nil {
 		return err
 	}
@@ -79,6 +99,10 @@ func (s *State) Watch(ctx context.Context) error {
 		return err
 	}
 
+	if err := s.watchMachineMigrations(ctx); err != nil {
+		return err
+	}
+
 	return s.t.Run(ctx)
 }
 
@@ -88,6 +112,11 @@ func (s *State) startupTasks() error {
 		s.t.AppendUnbound(task)
 	}
 
+	{
+		task := new(tasks.RenewLeaseTask)
+		s.t.AppendUnbound(task)
+	}
+
 	{
 		task := new(tasks.SyncStoragePoolsTask)
 		s.t.AppendUnbound(task)
@@ -0,0 +1,87 @@
+package tasks
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/eskpil/rockferry/controllerapi"
+	"github.com/eskpil/rockferry/internal/controller"
+	"github.com/eskpil/rockferry/internal/controller/api"
+	"github.com/eskpil/rockferry/internal/controller/runtime"
+	"github.com/eskpil/rockferry/internal/controller/store"
+	"github.com/eskpil/rockferry/internal/node/queries/fake"
+	"github.com/eskpil/rockferry/pkg/rockferry"
+	"github.com/eskpil/rockferry/pkg/rockferry/spec"
+	"google.golang.org/grpc"
+)
+
+// Serves the api of a controller backed by an in-memory store, without tls.
+// Returns the runtime behind it and the address it listens on.
+func startController(t *testing.T) (*runtime.Runtime, string) {
+	t.Helper()
+
+	s := store.NewMemory()
+	t.Cleanup(func() { s.Close() })
+
+	if err := controller.Initialize(s); err != nil {
+		t.Fatal(err)
+	}
+
+	r := runtime.New(s)
+
+	service, err := api.New(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	server := grpc.NewServer()
+	controllerapi.RegisterControllerApiServer(server, service)
+
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+
+	return r, listener.Addr().String()
+}
+
+// An executor for node on top of hypervisor, talking to the controller at address.
+func newExecutor(t *testing.T, address string, node string, hypervisor *fake.Hypervisor) *Executor {
+	t.Helper()
+
+	client, err := rockferry.New(address)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	e := new(Executor)
+	e.Hypervisor = hypervisor
+	e.Rockferry = client
+	e.Events = client.Events().WithSource(node)
+	e.NodeId = node
+
+	return e
+}
+
+// Stores a ready node, named like its id.
+func createNode(t *testing.T, r *runtime.Runtime, id string) {
+	t.Helper()
+
+	node := new(rockferry.Node)
+	node.Id = id
+	node.Kind = rockferry.ResourceKindNode
+	node.Spec.Name = id
+	node.Status.Conditions.Set(spec.NewCondition(spec.NodeConditionReady, spec.ConditionStatusTrue, "Ready", ""))
+
+	if err := r.CreateResource(context.Background(), node.Generic()); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func nodeRef(id string) *rockferry.OwnerRef {
+	return &rockferry.OwnerRef{Kind: rockferry.ResourceKindNode, Id: id}
+}
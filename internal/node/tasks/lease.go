@@ -0,0 +1,21 @@
+package tasks
+
+import (
+	"context"
+	"time"
+)
+
+// Well within the ttl of the lease, so a few missed renewals are tolerated.
+const leaseRenewInterval = 10 * time.Second
+
+// Keeps the node ready in the eyes of the controller.
+type RenewLeaseTask struct{}
+
+func (t *RenewLeaseTask) Execute(ctx context.Context, e *Executor) error {
+	return e.Rockferry.RenewLease(ctx, e.NodeId)
+}
+
+func (t *RenewLeaseTask) Repeats() *time.Duration {
+	interval := leaseRenewInterval
+	return &interval
+}
@@ -8,57 +8,110 @@ import (
 
 	"github.com/eskpil/rockferry/internal/node/queries"
 	"github.com/eskpil/rockferry/pkg/rockferry"
+	"github.com/eskpil/rockferry/pkg/rockferry/cache"
+	"github.com/eskpil/rockferry/pkg/rockferry/spec"
 )
 
 type Executor struct {
-	Libvirt   *queries.Client
-	Rockferry *rockferry.Client
+	Hypervisor queries.Hypervisor
+	Rockferry  *rockferry.Client
+
+	// Local copies kept up to date by informers, see StartCaches.
+	Machines     *cache.Lister[spec.MachineSpec, spec.MachineStatus]
+	StoragePools *cache.Lister[spec.StoragePoolSpec, rockferry.DefaultStatus]
+
+	// Records what happens to resources as coming from this node.
+	Events *rockferry.EventRecorder
 
 	NodeId string
 }
 
+// Event records an event about object, failing to do so is only reported.
+func (e *Executor) Event(ctx context.Context, object *rockferry.OwnerRef, eventType string, reason string, format string, args ...any) {
+	message := fmt.Sprintf(format, args...)
+	fmt.Println(object.Kind, object.Id, reason, message)
+
+	if err := e.Events.Record(ctx, object, eventType, reason, message); err != nil {
+		fmt.Println("failed to record event", reason, "about", object.Kind, object.Id, err)
+	}
+}
+
 type Task interface {
 	Execute(context.Context, *Executor) error
 	Repeats() *time.Duration
 }
 
-type BoundTask interface {
-	Execute(context.Context, *Executor) error
-	Resource() *rockferry.Resource[any, any]
+// Implemented by tasks acting on a single resource, their failures are
+// recorded as events about it.
+type ObjectTask interface {
+	Object() *rockferry.OwnerRef
+	// Recorded as the reason when the task fails, such as FailedDelete.
+	FailureReason() string
 }
 
 type TaskList struct {
 	e            *Executor
-	boundTasks   chan BoundTask
 	unboundTasks chan Task
 }
 
-func NewTaskList(client *rockferry.Client, nodeId string) (*TaskList, error) {
-	var err error
+func NewTaskList(client *rockferry.Client, hypervisor queries.Hypervisor, nodeId string) *TaskList {
 	list := new(TaskList)
 	list.unboundTasks = make(chan Task, 100)
-	list.boundTasks = make(chan BoundTask, 100)
 	list.e = new(Executor)
 
-	list.e.Libvirt, err = queries.NewClient()
+	list.e.Hypervisor = hypervisor
 	list.e.Rockferry = client
 	list.e.NodeId = nodeId
+	list.e.Events = client.Events().WithSource(nodeId)
+
+	return list
+}
+
+// Fills the executor caches, must be called before any task is run.
+func (t *TaskList) StartCaches(ctx context.Context) error {
+	owner := new(rockferry.OwnerRef)
+	owner.Id = t.e.NodeId
+	owner.Kind = rockferry.ResourceKindNode
+
+	machines := cache.NewInformer(t.e.Rockferry.Machines(), owner)
+	if err := machines.Start(ctx); err != nil {
+		return err
+	}
 
-	return list, err
+	// NOTE: Volumes can be requested in any pool, not only the ones on this node.
+	pools := cache.NewInformer(t.e.Rockferry.StoragePools(), nil)
+	if err := pools.Start(ctx); err != nil {
+		return err
+	}
+
+	t.e.Machines = machines.Lister()
+	t.e.StoragePools = pools.Lister()
+
+	return nil
 }
 
-func (t *TaskList) AppendBound(task BoundTask) {
-	t.boundTasks <- task
+// Tasks and reconcilers share the executor.
+func (t *TaskList) Executor() *Executor {
+	return t.e
 }
 
 func (t *TaskList) AppendUnbound(task Task) {
 	t.unboundTasks <- task
 }
 
+func (t *TaskList) failed(ctx context.Context, task Task, err error) {
+	if o, ok := task.(ObjectTask); ok {
+		t.e.Event(ctx, o.Object(), rockferry.EventTypeWarning, o.FailureReason(), "%s", err)
+		return
+	}
+
+	fmt.Println(reflect.TypeOf(task).Elem().Name(), "failed to execute task", err)
+}
+
 func (t *TaskList) executeUnbound(ctx context.Context, task Task) {
 	// Execute at start as well
 	if err := task.Execute(ctx, t.e); err != nil {
-		fmt.Println("failed to execute task", err)
+		t.failed(ctx, task, err)
 	}
 
 	if task.Repeats() == nil {
@@ -75,7 +128,7 @@ func (t *TaskList) executeUnbound(ctx context.Context, task Task) {
 		case <-ticker.C:
 			{
 				if err := task.Execute(ctx, t.e); err != nil {
-					fmt.Println(reflect.TypeOf(task).Elem().Name(), "failed to execute task", err)
+					t.failed(ctx, task, err)
 				}
 			}
 		}
@@ -83,26 +136,6 @@ func (t *TaskList) executeUnbound(ctx context.Context, task Task) {
 
 }
 
-func (t *TaskList) setResourcePhase(ctx context.Context, original *rockferry.Resource[any, any], phase rockferry.Phase) error {
-	generic := t.e.Rockferry.Generic(rockferry.ResourceKindAll)
-
-	copy := new(rockferry.Generic)
-	*copy = *original
-	copy.Phase = phase
-
-	return generic.Patch(ctx, original, copy)
-}
-
-func (t *TaskList) executeBound(ctx context.Context, task BoundTask) {
-	if err := task.Execute(ctx, t.e); err != nil {
-		fmt.Println(reflect.TypeOf(task).Elem().Name(), "failed to execute task", err)
-	}
-
-	if err := t.setResourcePhase(ctx, task.Resource(), rockferry.PhaseCreated); err != nil {
-		fmt.Println(err)
-	}
-
-}
 func (t *TaskList) Run(ctx context.Context) error {
 	for {
 		select {
@@ -110,10 +143,6 @@ func (t *TaskList) Run(ctx context.Context) error {
 			{
 				go t.executeUnbound(ctx, task)
 			}
-		case task := <-t.boundTasks:
-			{
-				go t.executeBound(ctx, task)
-			}
 		}
 	}
 }
@@ -0,0 +1,188 @@
+package tasks
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/eskpil/rockferry/internal/node/queries"
+	"github.com/eskpil/rockferry/pkg/reconcile"
+	"github.com/eskpil/rockferry/pkg/rockferry"
+	"github.com/eskpil/rockferry/pkg/rockferry/spec"
+	"github.com/siderolabs/go-pointer"
+)
+
+// Set on a node to override the uri its libvirt daemon is reached at by other nodes.
+const AnnotationLibvirtUri = "libvirt.uri"
+
+func libvirtUri(node *rockferry.Node) string {
+	if uri := node.Annotations[AnnotationLibvirtUri]; uri != "" {
+		return uri
+	}
+
+	return fmt.Sprintf("qemu+tcp://%s/system", node.Spec.Name)
+}
+
+// Writes the status of the migration, refetching it when it has been changed since.
+func updateMigration(ctx context.Context, iface *rockferry.Interface[spec.MachineMigrationSpec, spec.MachineMigrationStatus], original *rockferry.MachineMigration, mutate func(*rockferry.MachineMigration)) (*rockferry.MachineMigration, error) {
+	var modified *rockferry.MachineMigration
+
+	err := rockferry.RetryOnConflict(ctx, func() error {
+		modified = new(rockferry.MachineMigration)
+		*modified = *original
+		mutate(modified)
+
+		err := iface.PatchStatus(ctx, original, modified)
+		if err == rockferry.ErrorConflict {
+			latest, err := iface.Get(ctx, original.Id, nil)
+			if err != nil {
+				return err
+			}
+
+			original = latest
+			return rockferry.ErrorConflict
+		}
+
+		return err
+	})
+
+	return modified, err
+}
+
+type MigrateMachineTask struct {
+	Migration *rockferry.MachineMigration
+}
+
+// Migrates the machine and hands it over to the target node. Progress is written
+// to the status of the migration while it runs.
+func (t *MigrateMachineTask) Execute(ctx context.Context, e *Executor) error {
+	iface := e.Rockferry.MachineMigrations()
+
+	target, err := e.Rockferry.Nodes().Get(ctx, t.Migration.Spec.TargetNode, nil)
+	if err != nil {
+		return err
+	}
+
+	migration, err := updateMigration(ctx, iface, t.Migration, func(m *rockferry.MachineMigration) {
+		m.Phase = rockferry.PhaseCreating
+		m.Status.State = spec.MachineMigrationStatusStateMigrating
+		m.Status.StartedAt = pointer.To(time.Now().UTC())
+		m.Status.Conditions.Set(spec.NewCondition(spec.ConditionReady, spec.ConditionStatusFalse, "Migrating", "migrating to node "+m.Spec.TargetNode))
+	})
+	if err != nil {
+		return err
+	}
+
+	progress := func(p *queries.MigrationProgress) {
+		updated, err := updateMigration(ctx, iface, migration, func(m *rockferry.MachineMigration) {
+			m.Status.DataTotal = p.DataTotal
+			m.Status.DataProcessed = p.DataProcessed
+			m.Status.DataRemaining = p.DataRemaining
+			m.Status.TimeElapsedMs = uint64(p.TimeElapsed.Milliseconds())
+
+			if p.DataTotal > 0 {
+				m.Status.Progress = p.DataProcessed * 100 / p.DataTotal
+			}
+		})
+		if err != nil {
+			fmt.Println("failed to report migration progress", err)
+			return
+		}
+
+		migration = updated
+	}
+
+	if err := e.Hypervisor.MigrateDomain(ctx, t.Migration.Spec.Machine, libvirtUri(target), progress); err != nil {
+		return err
+	}
+
+	// NOTE: The machine now runs on the target, which takes it over from here.
+	// Handing it over is only allowed while the migration is creating.
+	err = rockferry.RetryOnConflict(ctx, func() error {
+		machine, err := e.Rockferry.Machines().Get(ctx, t.Migration.Spec.Machine, nil)
+		if err != nil {
+			return err
+		}
+
+		modified := new(rockferry.Machine)
+		*modified = *machine
+		modified.Owner = new(rockferry.OwnerRef)
+		modified.Owner.Kind = rockferry.ResourceKindNode
+		modified.Owner.Id = target.Id
+
+		return e.Rockferry.Machines().Patch(ctx, machine, modified)
+	})
+	if err != nil {
+		return err
+	}
+
+	t.Migration = migration
+
+	return nil
+}
+
+func (t *MigrateMachineTask) Repeats() *time.Duration {
+	return nil
+}
+
+// Performs migrations of machines on this node.
+type MachineMigrationReconciler struct {
+	Executor *Executor
+}
+
+// TODO: Pick up migrations interrupted by a restart of the node, they are left migrating.
+func (r *MachineMigrationReconciler) Reconcile(ctx context.Context, id string) (reconcile.Result, error) {
+	iface := r.Executor.Rockferry.MachineMigrations()
+
+	migration, err := iface.Get(ctx, id, nil)
+	if err == rockferry.ErrorNotFound {
+		return reconcile.Result{}, nil
+	}
+
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+
+	if migration.Phase != rockferry.PhaseRequested || migration.Deleting() {
+		return reconcile.Result{}, nil
+	}
+
+	if migration.Owner == nil || migration.Owner.Kind != rockferry.ResourceKindNode || migration.Owner.Id != r.Executor.NodeId {
+		return reconcile.Result{}, nil
+	}
+
+	task := new(MigrateMachineTask)
+	task.Migration = migration
+
+	// NOTE: A failed migration is not retried, the machine keeps running where it is.
+	outcome := task.Execute(ctx, r.Executor)
+
+	// NOTE: Recorded about the migration, the machine belongs to the target node once migrated.
+	object := &rockferry.OwnerRef{Kind: rockferry.ResourceKindMachineMigration, Id: migration.Id}
+	if outcome != nil {
+		r.Executor.Event(ctx, object, rockferry.EventTypeWarning, "FailedMigration", "%s", outcome)
+	} else {
+		r.Executor.Event(ctx, object, rockferry.EventTypeNormal, "Migrated", "migrated machine %s from node %s to node %s", migration.Spec.Machine, r.Executor.NodeId, migration.Spec.TargetNode)
+	}
+
+	_, err = updateMigration(ctx, iface, task.Migration, func(m *rockferry.MachineMigration) {
+		m.Status.CompletedAt = pointer.To(time.Now().UTC())
+		m.Status.Conditions.Set(spec.OutcomeCondition(spec.ConditionReady, outcome, "Migrated", "FailedMigration"))
+		m.Status.Conditions.SetReconciled(migration.Generation, outcome)
+		m.Status.ObservedGeneration = migration.Generation
+
+		if outcome != nil {
+			m.Phase = rockferry.PhaseErrored
+			m.Status.State = spec.MachineMigrationStatusStateFailed
+			m.Status.Error = pointer.To(outcome.Error())
+			return
+		}
+
+		m.Phase = rockferry.PhaseCreated
+		m.Status.State = spec.MachineMigrationStatusStateCompleted
+		m.Status.Progress = 100
+		m.Status.Error = nil
+	})
+
+	return reconcile.Result{}, err
+}
@@ -0,0 +1,155 @@
+package tasks
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/eskpil/rockferry/internal/node/queries/fake"
+	"github.com/eskpil/rockferry/pkg/rockferry"
+	"github.com/eskpil/rockferry/pkg/rockferry/spec"
+)
+
+func TestMachineMigration(t *testing.T) {
+	ctx := context.Background()
+	r, address := startController(t)
+
+	createNode(t, r, "a")
+	createNode(t, r, "b")
+
+	source := fake.New()
+	target := fake.New()
+
+	e := newExecutor(t, address, "a", source)
+	source.AddPeer(libvirtUri(&rockferry.Node{Spec: spec.NodeSpec{Name: "b"}}), target)
+
+	machine := new(rockferry.Machine)
+	machine.Id = "m"
+	machine.Kind = rockferry.ResourceKindMachine
+	machine.Owner = nodeRef("a")
+	machine.Spec.Name = "m"
+	machine.Spec.Topology.Memory = 1 << 30
+	machine.Spec.Disks = []*spec.MachineSpecDisk{{Network: &spec.MachineSpecDiskNetwork{Protocol: "rbd"}}}
+
+	if err := r.CreateResource(ctx, machine.Generic()); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := source.CreateDomain(machine.Id, &machine.Spec); err != nil {
+		t.Fatal(err)
+	}
+
+	migration := new(rockferry.MachineMigration)
+	migration.Kind = rockferry.ResourceKindMachineMigration
+	migration.Spec.Machine = machine.Id
+	migration.Spec.TargetNode = "a"
+
+	if err := e.Rockferry.MachineMigrations().Create(ctx, migration); err == nil {
+		t.Fatal("migration to the node the machine is on was accepted")
+	}
+
+	migration.Spec.TargetNode = "b"
+
+	generic := migration.Generic()
+	if err := r.CreateResource(ctx, generic); err != nil {
+		t.Fatal(err)
+	}
+
+	reconciler := new(MachineMigrationReconciler)
+	reconciler.Executor = e
+
+	if _, err := reconciler.Reconcile(ctx, generic.Id); err != nil {
+		t.Fatal(err)
+	}
+
+	if slices.Contains(source.Domains(), machine.Id) || !slices.Contains(target.Domains(), machine.Id) {
+		t.Fatalf("machine is defined on %v and %v, want only the target", source.Domains(), target.Domains())
+	}
+
+	migrated, err := e.Rockferry.Machines().Get(ctx, machine.Id, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if migrated.Owner.Id != "b" {
+		t.Fatalf("machine is owned by %s, want b", migrated.Owner.Id)
+	}
+
+	completed, err := e.Rockferry.MachineMigrations().Get(ctx, generic.Id, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if completed.Phase != rockferry.PhaseCreated || completed.Status.State != spec.MachineMigrationStatusStateCompleted {
+		t.Fatalf("migration is %s and %s, want it completed", completed.Phase, completed.Status.State)
+	}
+
+	if !completed.Status.Conditions.IsTrue(spec.ConditionReady) || completed.Status.ObservedGeneration != completed.Generation {
+		t.Fatalf("migration was not observed as ready: %+v", completed.Status.Conditions)
+	}
+
+	// NOTE: The machine is on the target node now, which must not keep the
+	// migration from being changed.
+	modified := new(rockferry.MachineMigration)
+	*modified = *completed
+	modified.Annotations = map[string]string{"note": "done"}
+
+	if err := e.Rockferry.MachineMigrations().Patch(ctx, completed, modified); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestMachineMigrationUnreachableTarget(t *testing.T) {
+	ctx := context.Background()
+	r, address := startController(t)
+
+	createNode(t, r, "a")
+	createNode(t, r, "b")
+
+	source := fake.New()
+	e := newExecutor(t, address, "a", source)
+
+	machine := new(rockferry.Machine)
+	machine.Id = "m"
+	machine.Kind = rockferry.ResourceKindMachine
+	machine.Owner = nodeRef("a")
+	machine.Spec.Name = "m"
+
+	if err := r.CreateResource(ctx, machine.Generic()); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := source.CreateDomain(machine.Id, &machine.Spec); err != nil {
+		t.Fatal(err)
+	}
+
+	migration := new(rockferry.MachineMigration)
+	migration.Kind = rockferry.ResourceKindMachineMigration
+	migration.Spec.Machine = machine.Id
+	migration.Spec.TargetNode = "b"
+
+	generic := migration.Generic()
+	if err := r.CreateResource(ctx, generic); err != nil {
+		t.Fatal(err)
+	}
+
+	reconciler := new(MachineMigrationReconciler)
+	reconciler.Executor = e
+
+	if _, err := reconciler.Reconcile(ctx, generic.Id); err != nil {
+		t.Fatal(err)
+	}
+
+	failed, err := e.Rockferry.MachineMigrations().Get(ctx, generic.Id, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if failed.Phase != rockferry.PhaseErrored || failed.Status.State != spec.MachineMigrationStatusStateFailed || failed.Status.Error == nil {
+		t.Fatalf("migration is %s and %s, want it failed", failed.Phase, failed.Status.State)
+	}
+
+	if !slices.Contains(source.Domains(), machine.Id) {
+		t.Fatal("machine left the source node after a failed migration")
+	}
+}
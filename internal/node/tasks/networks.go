@@ -12,16 +12,21 @@ type SyncNetworksTask struct {
 }
 
 func (t *SyncNetworksTask) Execute(ctx context.Context, e *Executor) error {
-	volumes, err := e.Libvirt.ListAllNetworks()
+	volumes, err := e.Hypervisor.ListAllNetworks()
 	if err != nil {
 		return err
 	}
 
 	iface := e.Rockferry.Networks()
 	for _, local := range volumes {
+		local.Owner = new(rockferry.OwnerRef)
+		local.Owner.Kind = rockferry.ResourceKindNode
+		local.Owner.Id = e.NodeId
+
 		remote, err := iface.Get(ctx, local.Id, nil)
 		if err != nil {
 			if err == rockferry.ErrorNotFound {
+				local.Status.Conditions.Set(foundCondition())
 				if err := iface.Create(ctx, local); err != nil {
 					return err
 				}
@@ -34,6 +39,8 @@ func (t *SyncNetworksTask) Execute(ctx context.Context, e *Executor) error {
 		}
 		// NOTE: This will make sure we do not lose any annotations on the way.
 		local.Merge(remote)
+		local.Status.Conditions = remote.Status.Conditions
+		local.Status.Conditions.Set(foundCondition())
 
 		if err := iface.Patch(ctx, remote, local); err != nil {
 			panic(err)
@@ -79,14 +79,8 @@ func readNodeCpu() (uint64, uint64, uint64, error) {
 	return uint64(len(socketMap)), uint64(len(coreMap)), uint64(threads), nil
 }
 
-func (t *SyncNodeTask) Execute(ctx context.Context, e *Executor) error {
-	node, err := e.Rockferry.Nodes().Get(ctx, e.NodeId, nil)
-	if err != nil {
-		return err
-	}
-
-	modified := deepcopy.Copy(node).(*rockferry.Node)
-
+// Fills in what the node knows about itself.
+func describeNode(modified *rockferry.Node) error {
 	modified.Spec.Name, _ = os.Hostname()
 
 	modified.Spec.ActiveMachines = 2
@@ -111,8 +105,6 @@ func (t *SyncNodeTask) Execute(ctx context.Context, e *Executor) error {
 		return err
 	}
 
-	modified.Spec.Uptime = info.Uptime
-
 	modified.Spec.Topology.Memory = info.Totalram
 
 	uname, _ := uname.New()
@@ -125,8 +117,67 @@ func (t *SyncNodeTask) Execute(ctx context.Context, e *Executor) error {
 
 	modified.Spec.Interfaces = interfaces
 
-	// TODO: Should be patch, but caused error on controller
-	return e.Rockferry.Nodes().Create(ctx, modified)
+	return nil
+}
+
+func (t *SyncNodeTask) Execute(ctx context.Context, e *Executor) error {
+	iface := e.Rockferry.Nodes()
+
+	node, err := iface.Get(ctx, e.NodeId, nil)
+	if err != nil {
+		return err
+	}
+
+	err = rockferry.RetryOnConflict(ctx, func() error {
+		modified := deepcopy.Copy(node).(*rockferry.Node)
+		if err := describeNode(modified); err != nil {
+			return err
+		}
+
+		err := iface.Patch(ctx, node, modified)
+		if err == rockferry.ErrorConflict {
+			node, err = iface.Get(ctx, e.NodeId, nil)
+			if err != nil {
+				return err
+			}
+
+			return rockferry.ErrorConflict
+		}
+
+		if err == nil {
+			node = modified
+		}
+
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	var info syscall.Sysinfo_t
+	if err := syscall.Sysinfo(&info); err != nil {
+		return err
+	}
+
+	// NOTE: The uptime changes on every sync, in the spec it would move the
+	// generation every time. Only the uptime is patched, the rest of the status
+	// belongs to the controller watching the lease.
+	return rockferry.RetryOnConflict(ctx, func() error {
+		modified := deepcopy.Copy(node).(*rockferry.Node)
+		modified.Status.Uptime = info.Uptime
+
+		err := iface.PatchStatus(ctx, node, modified)
+		if err == rockferry.ErrorConflict {
+			node, err = iface.Get(ctx, e.NodeId, nil)
+			if err != nil {
+				return err
+			}
+
+			return rockferry.ErrorConflict
+		}
+
+		return err
+	})
 }
 
 func (t *SyncNodeTask) Repeats() *time.Duration {
@@ -0,0 +1,53 @@
+package tasks
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/eskpil/rockferry/internal/node/queries/fake"
+)
+
+// The uptime is reported on every sync, it must not move the generation.
+func TestSyncNodeKeepsGeneration(t *testing.T) {
+	ctx := context.Background()
+	r, address := startController(t)
+
+	createNode(t, r, "a")
+
+	e := newExecutor(t, address, "a", fake.New())
+	task := new(SyncNodeTask)
+
+	if err := task.Execute(ctx, e); err != nil {
+		t.Fatal(err)
+	}
+
+	synced, err := e.Rockferry.Nodes().Get(ctx, "a", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if synced.Status.Uptime == 0 {
+		t.Fatal("uptime not reported")
+	}
+
+	if !synced.Status.Ready() {
+		t.Fatal("node no longer ready, the conditions were overwritten")
+	}
+
+	// NOTE: The uptime is counted in seconds.
+	time.Sleep(time.Second)
+
+	if err := task.Execute(ctx, e); err != nil {
+		t.Fatal(err)
+	}
+
+	resynced, err := e.Rockferry.Nodes().Get(ctx, "a", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if resynced.Generation != synced.Generation {
+		t.Fatalf("generation moved from %d to %d", synced.Generation, resynced.Generation)
+	}
+}
@@ -11,7 +11,7 @@ import (
 type SyncStoragePoolsTask struct{}
 
 func (t *SyncStoragePoolsTask) Execute(ctx context.Context, executor *Executor) error {
-	pools, err := executor.Libvirt.QueryStoragePools()
+	pools, err := executor.Hypervisor.QueryStoragePools()
 	if err != nil {
 		return err
 	}
@@ -23,6 +23,7 @@ func (t *SyncStoragePoolsTask) Execute(ctx context.Context, executor *Executor)
 		remote, err := iface.Get(ctx, local.Id, nil)
 		if err != nil {
 			if err == rockferry.ErrorNotFound {
+				local.Status.Conditions.Set(foundCondition())
 				if err := iface.Create(ctx, local); err != nil {
 					return err
 				}
@@ -36,6 +37,8 @@ func (t *SyncStoragePoolsTask) Execute(ctx context.Context, executor *Executor)
 
 		// NOTE: This will make sure we do not lose any annotations on the way.
 		local.Merge(remote)
+		local.Status.Conditions = remote.Status.Conditions
+		local.Status.Conditions.Set(foundCondition())
 
 		if err := iface.Patch(ctx, remote, local); err != nil {
 			panic(err)
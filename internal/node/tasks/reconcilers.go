@@ -0,0 +1,369 @@
+package tasks
+
+import (
+	"context"
+	"time"
+
+	"github.com/eskpil/rockferry/pkg/reconcile"
+	"github.com/eskpil/rockferry/pkg/rockferry"
+	"github.com/eskpil/rockferry/pkg/rockferry/spec"
+	"github.com/siderolabs/go-pointer"
+)
+
+func pending(phase rockferry.Phase) bool {
+	return phase == rockferry.PhaseRequested || phase == rockferry.PhaseErrored
+}
+
+func errorMessage(err error) *string {
+	if err == nil {
+		return nil
+	}
+
+	return pointer.To(err.Error())
+}
+
+// The resource is ready once it has been created.
+func createdCondition(outcome error) *spec.Condition {
+	return spec.OutcomeCondition(spec.ConditionReady, outcome, "Created", "FailedCreate")
+}
+
+// Whatever the node finds in libvirt is ready to be used.
+func foundCondition() *spec.Condition {
+	return spec.NewCondition(spec.ConditionReady, spec.ConditionStatusTrue, "Found", "")
+}
+
+func setDefaultOutcome(status *rockferry.DefaultStatus, generation int64, outcome error) {
+	status.Error = errorMessage(outcome)
+	status.Conditions.Set(createdCondition(outcome))
+	status.Conditions.SetReconciled(generation, outcome)
+	status.ObservedGeneration = generation
+}
+
+// Marks the resource as created, or as errored with the reason in its status.
+// The generation of original is the one which was acted on.
+func reportOutcome[S any, T any](ctx context.Context, iface *rockferry.Interface[S, T], original *rockferry.Resource[S, T], outcome error, setOutcome func(*T, int64, error)) error {
+	generation := original.Generation
+
+	return rockferry.RetryOnConflict(ctx, func() error {
+		modified := new(rockferry.Resource[S, T])
+		*modified = *original
+
+		modified.Phase = rockferry.PhaseCreated
+		if outcome != nil {
+			modified.Phase = rockferry.PhaseErrored
+		}
+
+		setOutcome(&modified.Status, generation, outcome)
+
+		err := iface.PatchStatus(ctx, original, modified)
+		if err == rockferry.ErrorConflict {
+			latest, err := iface.Get(ctx, original.Id, nil)
+			if err != nil {
+				return err
+			}
+
+			original = latest
+			return rockferry.ErrorConflict
+		}
+
+		return err
+	})
+}
+
+// Creates the machine for requests assigned to this node.
+type MachineRequestReconciler struct {
+	Executor *Executor
+}
+
+func (r *MachineRequestReconciler) Reconcile(ctx context.Context, id string) (reconcile.Result, error) {
+	iface := r.Executor.Rockferry.MachineRequests()
+
+	request, err := iface.Get(ctx, id, nil)
+	if err == rockferry.ErrorNotFound {
+		return reconcile.Result{}, nil
+	}
+
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+
+	if !pending(request.Phase) {
+		return reconcile.Result{}, nil
+	}
+
+	if request.Owner == nil || request.Owner.Kind != rockferry.ResourceKindNode || request.Owner.Id != r.Executor.NodeId {
+		return reconcile.Result{}, nil
+	}
+
+	task := new(CreateVirtualMachineTask)
+	task.Request = request
+
+	outcome := task.Execute(ctx, r.Executor)
+
+	object := &rockferry.OwnerRef{Kind: rockferry.ResourceKindMachineRequest, Id: request.Id}
+	if outcome != nil {
+		r.Executor.Event(ctx, object, rockferry.EventTypeWarning, "FailedCreate", "%s", outcome)
+	} else {
+		r.Executor.Event(ctx, object, rockferry.EventTypeNormal, "Created", "created the machine on node %s", r.Executor.NodeId)
+	}
+
+	setOutcome := func(status *spec.MachineRequestStatus, generation int64, outcome error) {
+		status.Error = errorMessage(outcome)
+
+		for _, condition := range task.Conditions {
+			status.Conditions.Set(condition)
+		}
+
+		status.Conditions.Set(createdCondition(outcome))
+		status.Conditions.SetReconciled(generation, outcome)
+		status.ObservedGeneration = generation
+	}
+
+	if err := reportOutcome(ctx, iface, request, outcome, setOutcome); err != nil {
+		return reconcile.Result{}, err
+	}
+
+	return reconcile.Result{}, outcome
+}
+
+// Creates requested volumes in the storage pools of this node.
+type StorageVolumeReconciler struct {
+	Executor *Executor
+}
+
+func (r *StorageVolumeReconciler) Reconcile(ctx context.Context, id string) (reconcile.Result, error) {
+	iface := r.Executor.Rockferry.StorageVolumes()
+
+	volume, err := iface.Get(ctx, id, nil)
+	if err == rockferry.ErrorNotFound {
+		return reconcile.Result{}, nil
+	}
+
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+
+	if !pending(volume.Phase) || volume.Owner == nil {
+		return reconcile.Result{}, nil
+	}
+
+	pool, err := r.Executor.StoragePools.Get(volume.Owner.Id)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+
+	// NOTE: The pool belongs to another node, which will create the volume.
+	if pool.Owner == nil || pool.Owner.Id != r.Executor.NodeId {
+		return reconcile.Result{}, nil
+	}
+
+	task := new(CreateVolumeTask)
+	task.Volume = volume
+
+	outcome := task.Execute(ctx, r.Executor)
+
+	object := &rockferry.OwnerRef{Kind: rockferry.ResourceKindStorageVolume, Id: volume.Id}
+	if outcome != nil {
+		r.Executor.Event(ctx, object, rockferry.EventTypeWarning, "FailedCreate", "%s", outcome)
+	} else {
+		r.Executor.Event(ctx, object, rockferry.EventTypeNormal, "Created", "created the volume in pool %s", pool.Spec.Name)
+	}
+
+	if err := reportOutcome(ctx, iface, volume, outcome, setDefaultOutcome); err != nil {
+		return reconcile.Result{}, err
+	}
+
+	return reconcile.Result{}, outcome
+}
+
+// Removes finalizer from the resource, which is deleted by the controller if it
+// was the last one.
+func removeFinalizer[S any, T any](ctx context.Context, iface *rockferry.Interface[S, T], original *rockferry.Resource[S, T], finalizer string) error {
+	return rockferry.RetryOnConflict(ctx, func() error {
+		if !original.HasFinalizer(finalizer) {
+			return nil
+		}
+
+		modified := new(rockferry.Resource[S, T])
+		*modified = *original
+		modified.RemoveFinalizer(finalizer)
+
+		err := iface.Patch(ctx, original, modified)
+		if err == rockferry.ErrorConflict {
+			latest, err := iface.Get(ctx, original.Id, nil)
+			if err != nil {
+				return err
+			}
+
+			original = latest
+			return rockferry.ErrorConflict
+		}
+
+		if err == rockferry.ErrorNotFound {
+			return nil
+		}
+
+		return err
+	})
+}
+
+// Tears down the domain of machines on this node which are being deleted.
+type MachineFinalizer struct {
+	Executor *Executor
+}
+
+func (r *MachineFinalizer) Reconcile(ctx context.Context, id string) (reconcile.Result, error) {
+	iface := r.Executor.Rockferry.Machines()
+
+	machine, err := iface.Get(ctx, id, nil)
+	if err == rockferry.ErrorNotFound {
+		return reconcile.Result{}, nil
+	}
+
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+
+	if !machine.Deleting() || !machine.HasFinalizer(rockferry.FinalizerNode) {
+		return reconcile.Result{}, nil
+	}
+
+	if machine.Owner == nil || machine.Owner.Kind != rockferry.ResourceKindNode || machine.Owner.Id != r.Executor.NodeId {
+		return reconcile.Result{}, nil
+	}
+
+	task := new(DeleteVmTask)
+	task.Machine = machine
+
+	if err := task.Execute(ctx, r.Executor); err != nil {
+		r.Executor.Event(ctx, task.Object(), rockferry.EventTypeWarning, task.FailureReason(), "%s", err)
+		return reconcile.Result{}, err
+	}
+
+	r.Executor.Event(ctx, task.Object(), rockferry.EventTypeNormal, "Deleted", "removed the domain from node %s", r.Executor.NodeId)
+
+	return reconcile.Result{}, removeFinalizer(ctx, iface, machine, rockferry.FinalizerNode)
+}
+
+// Starts machines which have been failed over to this node.
+type MachineFailover struct {
+	Executor *Executor
+}
+
+func (r *MachineFailover) Reconcile(ctx context.Context, id string) (reconcile.Result, error) {
+	iface := r.Executor.Rockferry.Machines()
+
+	machine, err := iface.Get(ctx, id, nil)
+	if err == rockferry.ErrorNotFound {
+		return reconcile.Result{}, nil
+	}
+
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+
+	failover := machine.Status.Failover
+	if machine.Deleting() || failover == nil || failover.State != spec.MachineStatusFailoverStatePending || failover.TargetNode != r.Executor.NodeId {
+		return reconcile.Result{}, nil
+	}
+
+	if machine.Owner == nil || machine.Owner.Kind != rockferry.ResourceKindNode || machine.Owner.Id != r.Executor.NodeId {
+		return reconcile.Result{}, nil
+	}
+
+	object := &rockferry.OwnerRef{Kind: rockferry.ResourceKindMachine, Id: machine.Id}
+
+	// NOTE: The disks are on network storage, defining the domain is all there is to it.
+	if !r.Executor.Hypervisor.DomainExists(machine.Id) {
+		if err := r.Executor.Hypervisor.CreateDomain(machine.Id, &machine.Spec); err != nil {
+			r.Executor.Event(ctx, object, rockferry.EventTypeWarning, "FailedFailover", "%s", err)
+			return reconcile.Result{}, err
+		}
+	}
+
+	r.Executor.Event(ctx, object, rockferry.EventTypeNormal, "FailedOver", "started on node %s after node %s was lost", r.Executor.NodeId, failover.SourceNode)
+
+	err = rockferry.RetryOnConflict(ctx, func() error {
+		modified := new(rockferry.Machine)
+		*modified = *machine
+
+		modified.Status.Failover = new(spec.MachineStatusFailover)
+		*modified.Status.Failover = *machine.Status.Failover
+		modified.Status.Failover.State = spec.MachineStatusFailoverStateCompleted
+		modified.Status.Failover.Time = time.Now().UTC()
+
+		err := iface.PatchStatus(ctx, machine, modified)
+		if err == rockferry.ErrorConflict {
+			machine, err = iface.Get(ctx, machine.Id, nil)
+			if err != nil {
+				return err
+			}
+
+			return rockferry.ErrorConflict
+		}
+
+		return err
+	})
+
+	return reconcile.Result{}, err
+}
+
+// Removes volumes which are being deleted from the storage pools of this node.
+type StorageVolumeFinalizer struct {
+	Executor *Executor
+}
+
+// How long to wait for a machine still using a volume to be torn down.
+const volumeInUseDelay = 10 * time.Second
+
+func (r *StorageVolumeFinalizer) Reconcile(ctx context.Context, id string) (reconcile.Result, error) {
+	iface := r.Executor.Rockferry.StorageVolumes()
+
+	volume, err := iface.Get(ctx, id, nil)
+	if err == rockferry.ErrorNotFound {
+		return reconcile.Result{}, nil
+	}
+
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+
+	if !volume.Deleting() || !volume.HasFinalizer(rockferry.FinalizerNode) || volume.Owner == nil {
+		return reconcile.Result{}, nil
+	}
+
+	pool, err := r.Executor.StoragePools.Get(volume.Owner.Id)
+	if err == rockferry.ErrorNotFound {
+		// NOTE: The volume went away together with its pool.
+		return reconcile.Result{}, removeFinalizer(ctx, iface, volume, rockferry.FinalizerNode)
+	}
+
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+
+	if pool.Owner == nil || pool.Owner.Id != r.Executor.NodeId {
+		return reconcile.Result{}, nil
+	}
+
+	// NOTE: Machines are deleted along with their volumes, let the domain go first.
+	for _, machine := range r.Executor.Machines.List() {
+		for _, disk := range machine.Spec.Disks {
+			if disk.Volume == volume.Id {
+				return reconcile.Result{RequeueAfter: volumeInUseDelay}, nil
+			}
+		}
+	}
+
+	if _, err := r.Executor.Hypervisor.QueryVolumeSpec(pool.Spec.Name, volume.Spec.Name); err == nil {
+		task := new(DeleteVolumeTask)
+		task.Volume = volume
+
+		if err := task.Execute(ctx, r.Executor); err != nil {
+			r.Executor.Event(ctx, task.Object(), rockferry.EventTypeWarning, task.FailureReason(), "%s", err)
+			return reconcile.Result{}, err
+		}
+	}
+
+	return reconcile.Result{}, removeFinalizer(ctx, iface, volume, rockferry.FinalizerNode)
+}
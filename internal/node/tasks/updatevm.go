@@ -18,26 +18,26 @@ type UpdateVmTask struct {
 	Prev    *rockferry.Machine
 }
 
-func (t *UpdateVmTask) handleUpdateState(_ context.Context, e *Executor, change diff.Change) error {
-	desired := change.To.(spec.MachineStatusState)
-	current, err := e.Libvirt.GetDomainState(t.Machine.Id)
+func (t *UpdateVmTask) handleUpdatePowerState(_ context.Context, e *Executor, change diff.Change) error {
+	desired := change.To.(spec.MachineSpecPowerState)
+	current, err := e.Hypervisor.GetDomainState(t.Machine.Id)
 	if err != nil {
 		return err
 	}
 
-	if current == spec.MachineStatusStateStopped && desired == spec.MachineStatusStateBooting {
-		return e.Libvirt.StartDomain(t.Machine.Id)
+	if current == spec.MachineStatusStateStopped && desired == spec.MachineSpecPowerStateOn {
+		return e.Hypervisor.StartDomain(t.Machine.Id)
 	}
 
-	if current == spec.MachineStatusStateRunning && desired == spec.MachineStatusStateShutdown {
+	if current == spec.MachineStatusStateRunning && desired == spec.MachineSpecPowerStateOff {
 		// TODO: This is a bad check. Currently, if the machine runs without a qemu-guest-agent
 		// 		 we just kill the qemu process. We should instead configure libvirt to use acpi.
 		// 		 the hard part is knowing if the machine is still in the bootloader, where acpi
 		// 		 will not work.
 		if strings.Contains(strings.Join(t.Machine.Status.Errors, " "), "Guest agent is not responding: QEMU guest agent is not connected") {
-			return e.Libvirt.DestroyDomain(t.Machine.Id)
+			return e.Hypervisor.DestroyDomain(t.Machine.Id)
 		} else {
-			return e.Libvirt.ShutdownDomain(t.Machine.Id)
+			return e.Hypervisor.ShutdownDomain(t.Machine.Id)
 		}
 	}
 
@@ -55,8 +55,6 @@ func (t *UpdateVmTask) handleCreateDisk(ctx context.Context, e *Executor, index
 		return nil
 	}
 
-	modified := deepcopy.Copy(t.Machine).(*rockferry.Machine)
-
 	stream, err := e.Rockferry.StorageVolumes().Watch(ctx, rockferry.WatchActionUpdate, disk.Volume, nil)
 	if err != nil {
 		return err
@@ -74,46 +72,72 @@ func (t *UpdateVmTask) handleCreateDisk(ctx context.Context, e *Executor, index
 					continue
 				}
 
-				modified.Spec.Disks[index].Device = "disk"
-				modified.Spec.Disks[index].Key = event.Resource.Spec.Key
-
 				pool, err := e.Rockferry.StoragePools().Get(ctx, event.Resource.Owner.Id, nil)
 				if err != nil {
 					return err
 				}
 
-				if pool.Spec.Type == "rbd" {
-					modified.Spec.Disks[index].Type = "network"
+				current := t.Machine
+				var attached *spec.MachineSpecDisk
 
-					modified.Spec.Disks[index].Network = new(spec.MachineSpecDiskNetwork)
-					modified.Spec.Disks[index].Network.Protocol = pool.Spec.Type
-					modified.Spec.Disks[index].Network.Hosts = pool.Spec.Source.Hosts
-					modified.Spec.Disks[index].Network.Auth = *pool.Spec.Source.Auth
+				err = rockferry.RetryOnConflict(ctx, func() error {
+					if index >= len(current.Spec.Disks) {
+						return fmt.Errorf("disk %d no longer exists on machine", index)
+					}
 
-					rockferry.MachineEnsureUniqueDiskTargets(modified.Spec.Disks, rockferry.MachineDiskTargetBaseVD)
-				}
+					modified := deepcopy.Copy(current).(*rockferry.Machine)
+					fillDisk(modified, index, event.Resource.Spec.Key, pool)
 
-				if pool.Spec.Type == "dir" {
-					modified.Spec.Disks[index].Type = "file"
-					modified.Spec.Disks[index].File = new(spec.MachineSpecDiskFile)
+					err := e.Rockferry.Machines().Patch(ctx, current, modified)
+					if err == rockferry.ErrorConflict {
+						current, err = e.Rockferry.Machines().Get(ctx, t.Machine.Id, nil)
+						if err != nil {
+							return err
+						}
 
-					rockferry.MachineEnsureUniqueDiskTargets(modified.Spec.Disks, rockferry.MachineDiskTargetBaseSD)
-				}
+						return rockferry.ErrorConflict
+					}
 
-				if err := e.Rockferry.Machines().Patch(ctx, t.Machine, modified); err != nil {
+					attached = modified.Spec.Disks[index]
+					return err
+				})
+				if err != nil {
 					return err
 				}
 
-				return e.Libvirt.DomainAddDisk(t.Machine.Id, modified.Spec.Disks[index])
+				return e.Hypervisor.DomainAddDisk(t.Machine.Id, attached)
 			}
 		}
 	}
 
 }
 
+func fillDisk(machine *rockferry.Machine, index int, key string, pool *rockferry.StoragePool) {
+	machine.Spec.Disks[index].Device = "disk"
+	machine.Spec.Disks[index].Key = key
+
+	if pool.Spec.Type == "rbd" {
+		machine.Spec.Disks[index].Type = "network"
+
+		machine.Spec.Disks[index].Network = new(spec.MachineSpecDiskNetwork)
+		machine.Spec.Disks[index].Network.Protocol = pool.Spec.Type
+		machine.Spec.Disks[index].Network.Hosts = pool.Spec.Source.Hosts
+		machine.Spec.Disks[index].Network.Auth = *pool.Spec.Source.Auth
+
+		rockferry.MachineEnsureUniqueDiskTargets(machine.Spec.Disks, rockferry.MachineDiskTargetBaseVD)
+	}
+
+	if pool.Spec.Type == "dir" {
+		machine.Spec.Disks[index].Type = "file"
+		machine.Spec.Disks[index].File = new(spec.MachineSpecDiskFile)
+
+		rockferry.MachineEnsureUniqueDiskTargets(machine.Spec.Disks, rockferry.MachineDiskTargetBaseSD)
+	}
+}
+
 func (t *UpdateVmTask) handleDeleteDisk(ctx context.Context, e *Executor, index int) error {
 	// TODO: Implement
-	err := e.Libvirt.DomainRemoveDisk(t.Machine.Id, t.Prev.Spec.Disks[index])
+	err := e.Hypervisor.DomainRemoveDisk(t.Machine.Id, t.Prev.Spec.Disks[index])
 
 	if err != nil {
 		if strings.Contains(err.Error(), "cannot be detached") || strings.Contains(err.Error(), "This type of disk cannot be hot unplugged") {
@@ -123,10 +147,19 @@ func (t *UpdateVmTask) handleDeleteDisk(ctx context.Context, e *Executor, index
 			// 		 The cycle should only occur if disk removal fails. And that will
 			// 		 rarely happen, since all disk removals require the vm to be rebooted.
 
-			modified := deepcopy.Copy(t.Prev).(*rockferry.Machine)
+			iface := e.Rockferry.Machines()
+
+			restored := deepcopy.Copy(t.Machine).(*rockferry.Machine)
+			restored.Spec = deepcopy.Copy(t.Prev).(*rockferry.Machine).Spec
+
+			if err := iface.Patch(ctx, t.Machine, restored); err != nil {
+				return err
+			}
+
+			modified := deepcopy.Copy(restored).(*rockferry.Machine)
 			modified.Status.Errors = append(modified.Status.Errors, err.Error())
 
-			return e.Rockferry.Machines().Create(ctx, modified)
+			return iface.PatchStatus(ctx, restored, modified)
 		}
 
 		return err
@@ -141,7 +174,7 @@ func (t *UpdateVmTask) handleDeleteDisk(ctx context.Context, e *Executor, index
 //	this is something we do not want in this scenario. So i had deepseek write
 //	a fucking terrible solution which makes me want to puke. It is a fine solution
 //	to a stupid problem.
-func (t *UpdateVmTask) Execute(ctx context.Context, e *Executor) error {
+func (t *UpdateVmTask) apply(ctx context.Context, e *Executor) error {
 	changes, err := diff.Diff(t.Prev, t.Machine)
 	if err != nil {
 		return err
@@ -177,14 +210,68 @@ func (t *UpdateVmTask) Execute(ctx context.Context, e *Executor) error {
 		}
 
 		// Handle other specific changes
-		if change.Type == "update" && path == "Status.State" {
-			return t.handleUpdateState(ctx, e, change)
+		if change.Type == "update" && path == "Spec.PowerState" {
+			return t.handleUpdatePowerState(ctx, e, change)
 		}
 	}
 
 	return nil
 }
 
+func (t *UpdateVmTask) Execute(ctx context.Context, e *Executor) error {
+	err := t.apply(ctx, e)
+
+	// NOTE: A failed update is reported as well, the failure is what was observed.
+	if rerr := t.reportObserved(ctx, e, err); rerr != nil {
+		fmt.Println("failed to report observed generation", t.Machine.Id, rerr)
+	}
+
+	return err
+}
+
+// Records the generation the update was made for as observed by the node.
+func (t *UpdateVmTask) reportObserved(ctx context.Context, e *Executor, outcome error) error {
+	iface := e.Rockferry.Machines()
+	generation := t.Machine.Generation
+	machine := t.Machine
+
+	return rockferry.RetryOnConflict(ctx, func() error {
+		// NOTE: The update of a later generation finished first.
+		if machine.Status.ObservedGeneration > generation {
+			return nil
+		}
+
+		modified := new(rockferry.Machine)
+		*modified = *machine
+		modified.Status.Conditions.SetReconciled(generation, outcome)
+		modified.Status.ObservedGeneration = generation
+
+		err := iface.PatchStatus(ctx, machine, modified)
+		if err == rockferry.ErrorConflict {
+			machine, err = iface.Get(ctx, machine.Id, nil)
+			if err != nil {
+				return err
+			}
+
+			return rockferry.ErrorConflict
+		}
+
+		if err == rockferry.ErrorNotFound {
+			return nil
+		}
+
+		return err
+	})
+}
+
+func (t *UpdateVmTask) Object() *rockferry.OwnerRef {
+	return &rockferry.OwnerRef{Kind: rockferry.ResourceKindMachine, Id: t.Machine.Id}
+}
+
+func (t *UpdateVmTask) FailureReason() string {
+	return "FailedUpdate"
+}
+
 func (t *UpdateVmTask) Repeats() *time.Duration {
 	return nil
 }
@@ -45,6 +45,9 @@ func downloadFile(dst string, remote string) error {
 
 type CreateVirtualMachineTask struct {
 	Request *rockferry.MachineRequest
+
+	// How far the task got, set on the request once it is done.
+	Conditions spec.Conditions
 }
 
 func (t *CreateVirtualMachineTask) createVmDisks(ctx context.Context, executor *Executor) ([]*spec.MachineSpecDisk, error) {
@@ -85,7 +88,7 @@ func (t *CreateVirtualMachineTask) createVmDisks(ctx context.Context, executor *
 		}
 	}
 
-	if t.Request.Spec.Cdrom.Volume != "" {
+	if t.Request.Spec.Cdrom != nil && t.Request.Spec.Cdrom.Volume != "" {
 		volume, err := executor.Rockferry.StorageVolumes().Get(ctx, t.Request.Spec.Cdrom.Volume, nil)
 		if err != nil {
 			return nil, err
@@ -174,9 +177,11 @@ func (t *CreateVirtualMachineTask) downloadPossibleResources(machineSpec *spec.M
 
 func (t *CreateVirtualMachineTask) Execute(ctx context.Context, executor *Executor) error {
 	// NOTE: Used to annotate storage volumes with the vm id. This is useful for deletion.
-	vmId := uuid.NewString()
+	// Derived from the request so a retried request does not create a second machine.
+	vmId := uuid.NewSHA1(uuid.NameSpaceOID, []byte(t.Request.Id)).String()
 
 	disks, err := t.createVmDisks(ctx, executor)
+	t.Conditions.Set(spec.OutcomeCondition(spec.MachineRequestConditionDisksCreated, err, "Created", "FailedCreate"))
 	if err != nil {
 		return err
 	}
@@ -211,34 +216,51 @@ func (t *CreateVirtualMachineTask) Execute(ctx context.Context, executor *Execut
 	res.Owner.Id = executor.NodeId
 	res.Owner.Kind = rockferry.ResourceKindNode
 
+	res.Parents = []*rockferry.OwnerRef{{Kind: rockferry.ResourceKindMachineRequest, Id: t.Request.Id}}
+	res.Finalizers = []string{rockferry.FinalizerNode}
+
 	res.Status.State = spec.MachineStatusStateBooting
+	res.Status.Conditions.Set(machineReadyCondition(res.Status.State))
+
+	// NOTE: The domain was defined from the spec the machine is created with, its first generation.
+	res.Status.Conditions.SetReconciled(1, nil)
+	res.Status.ObservedGeneration = 1
 
 	res.Spec = *machineSpec
 
-	if err := executor.Libvirt.CreateDomain(vmId, machineSpec); err != nil {
+	if !executor.Hypervisor.DomainExists(vmId) {
+		err := executor.Hypervisor.CreateDomain(vmId, machineSpec)
+		t.Conditions.Set(spec.OutcomeCondition(spec.MachineRequestConditionDomainDefined, err, "Defined", "FailedDefine"))
+		if err != nil {
+			return err
+		}
+	} else {
+		t.Conditions.Set(spec.NewCondition(spec.MachineRequestConditionDomainDefined, spec.ConditionStatusTrue, "Defined", ""))
+	}
+
+	if _, err := executor.Rockferry.Machines().Get(ctx, vmId, nil); err != rockferry.ErrorNotFound {
 		return err
 	}
 
 	return executor.Rockferry.Machines().Create(ctx, res)
 }
 
-func (t *CreateVirtualMachineTask) Resource() *rockferry.Resource[any, any] {
-	return t.Request.Generic()
-}
-
 type DeleteVmTask struct {
 	Machine *rockferry.Machine
 }
 
+// Safe to run again, parts which have already been cleaned up are skipped.
 func (t *DeleteVmTask) Execute(ctx context.Context, e *Executor) error {
-	if t.Machine.Status.State == spec.MachineStatusStateRunning || t.Machine.Status.State == spec.MachineStatusStateBooting {
-		if err := e.Libvirt.DestroyDomain(t.Machine.Id); err != nil {
-			return err
+	if e.Hypervisor.DomainExists(t.Machine.Id) {
+		if t.Machine.Status.State == spec.MachineStatusStateRunning || t.Machine.Status.State == spec.MachineStatusStateBooting {
+			if err := e.Hypervisor.DestroyDomain(t.Machine.Id); err != nil {
+				return err
+			}
 		}
-	}
 
-	if err := e.Libvirt.UndefineDomain(t.Machine.Id); err != nil {
-		return err
+		if err := e.Hypervisor.UndefineDomain(t.Machine.Id); err != nil {
+			return err
+		}
 	}
 
 	// Cleanup, yay
@@ -247,13 +269,30 @@ func (t *DeleteVmTask) Execute(ctx context.Context, e *Executor) error {
 			continue
 		}
 
-		if err := e.Rockferry.StorageVolumes().Delete(ctx, disk.Volume); err != nil {
+		if err := e.Rockferry.StorageVolumes().Delete(ctx, disk.Volume); err != nil && err != rockferry.ErrorNotFound {
 			fmt.Println("failed to delete storage volume", err)
 			continue
 		}
 	}
 
-	return e.Rockferry.MachineRequests().Delete(ctx, t.Machine.Annotations["machinerequest.id"])
+	requestId := t.Machine.Annotations["machinerequest.id"]
+	if requestId == "" {
+		return nil
+	}
+
+	if err := e.Rockferry.MachineRequests().Delete(ctx, requestId); err != nil && err != rockferry.ErrorNotFound {
+		return err
+	}
+
+	return nil
+}
+
+func (t *DeleteVmTask) Object() *rockferry.OwnerRef {
+	return &rockferry.OwnerRef{Kind: rockferry.ResourceKindMachine, Id: t.Machine.Id}
+}
+
+func (t *DeleteVmTask) FailureReason() string {
+	return "FailedDelete"
 }
 
 func (t *DeleteVmTask) Repeats() *time.Duration {
@@ -263,36 +302,60 @@ func (t *DeleteVmTask) Repeats() *time.Duration {
 type SyncMachineStatusesTask struct {
 }
 
+// A machine is ready while its domain is running.
+func machineReadyCondition(state spec.MachineStatusState) *spec.Condition {
+	if state == spec.MachineStatusStateRunning {
+		return spec.NewCondition(spec.ConditionReady, spec.ConditionStatusTrue, "Running", "")
+	}
+
+	return spec.NewCondition(spec.ConditionReady, spec.ConditionStatusFalse, "NotRunning", fmt.Sprintf("the domain is %s", state))
+}
+
 func (t *SyncMachineStatusesTask) Execute(ctx context.Context, e *Executor) error {
 	iface := e.Rockferry.Machines()
 
 	owner := new(rockferry.OwnerRef)
 	owner.Id = e.NodeId
 	owner.Kind = rockferry.ResourceKindNode
-	machines, err := iface.List(ctx, "", owner)
-	if err != nil && err != rockferry.ErrorNotFound {
-		return err
-	}
 
-	for _, machine := range machines {
+	for _, machine := range e.Machines.ListByOwner(owner) {
 		// TODO: If a machine exists in rockferry but not libvirt we are out of sync.
 		// 		 We need logic to sync machines as well.
-		if !e.Libvirt.DomainExists(machine.Id) {
+		if !e.Hypervisor.DomainExists(machine.Id) {
 			continue
 		}
 
-		status, err := e.Libvirt.SyncDomainStatus(machine.Id)
+		status, err := e.Hypervisor.SyncDomainStatus(machine.Id)
 		if err != nil {
 			fmt.Println("failed to sync machine status", err)
 			continue
 		}
 
-		copy := new(rockferry.Machine)
-		*copy = *machine
-
-		copy.Status = *status
+		err = rockferry.RetryOnConflict(ctx, func() error {
+			copy := new(rockferry.Machine)
+			*copy = *machine
+
+			copy.Status = *status
+			// NOTE: Written by the controller, libvirt knows nothing about it.
+			copy.Status.Failover = machine.Status.Failover
+			copy.Status.Conditions = machine.Status.Conditions
+			copy.Status.ObservedGeneration = machine.Status.ObservedGeneration
+			copy.Status.Conditions.Set(machineReadyCondition(status.State))
+
+			err := iface.PatchStatus(ctx, machine, copy)
+			if err == rockferry.ErrorConflict {
+				// Someone else wrote to the machine, base the status on their version.
+				machine, err = iface.Get(ctx, machine.Id, nil)
+				if err != nil {
+					return err
+				}
+
+				return rockferry.ErrorConflict
+			}
 
-		if err := iface.Patch(ctx, machine, copy); err != nil {
+			return err
+		})
+		if err != nil {
 			fmt.Println("failed to patch machine", err)
 			continue
 		}
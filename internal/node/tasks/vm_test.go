@@ -0,0 +1,240 @@
+package tasks
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/eskpil/rockferry/internal/node/queries/fake"
+	"github.com/eskpil/rockferry/pkg/rockferry"
+	"github.com/eskpil/rockferry/pkg/rockferry/spec"
+)
+
+// An executor for node a with its caches started, on top of a fake hypervisor
+// whose pools and networks have been synced.
+func newSyncedExecutor(t *testing.T) (*Executor, *fake.Hypervisor) {
+	t.Helper()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	r, address := startController(t)
+	createNode(t, r, "a")
+
+	hypervisor := fake.New()
+	client := newExecutor(t, address, "a", hypervisor).Rockferry
+
+	list := NewTaskList(client, hypervisor, "a")
+	if err := list.StartCaches(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	e := list.Executor()
+
+	for _, task := range []Task{new(SyncStoragePoolsTask), new(SyncNetworksTask)} {
+		if err := task.Execute(ctx, e); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return e, hypervisor
+}
+
+// Waits for the machine cache of e to hold id.
+func waitForMachine(t *testing.T, e *Executor, id string) {
+	t.Helper()
+
+	for range 100 {
+		for _, machine := range e.Machines.ListByOwner(nodeRef(e.NodeId)) {
+			if machine.Id == id {
+				return
+			}
+		}
+
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	t.Fatalf("machine %s never made it into the cache", id)
+}
+
+func TestSyncPoolsAndNetworks(t *testing.T) {
+	ctx := context.Background()
+	e, _ := newSyncedExecutor(t)
+
+	// NOTE: The second sync patches what the first one created.
+	for _, task := range []Task{new(SyncStoragePoolsTask), new(SyncNetworksTask)} {
+		if err := task.Execute(ctx, e); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	pools, err := e.Rockferry.StoragePools().List(ctx, "", nodeRef("a"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(pools) != 1 || pools[0].Spec.Name != "default" || !pools[0].Status.Conditions.IsTrue(spec.ConditionReady) {
+		t.Fatalf("synced pools are %+v, want the ready default pool", pools)
+	}
+
+	networks, err := e.Rockferry.Networks().List(ctx, "", nodeRef("a"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(networks) != 1 || networks[0].Spec.Name != "default" || !networks[0].Status.Conditions.IsTrue(spec.ConditionReady) {
+		t.Fatalf("synced networks are %+v, want the ready default network", networks)
+	}
+}
+
+func TestVirtualMachineLifecycle(t *testing.T) {
+	ctx := context.Background()
+	e, hypervisor := newSyncedExecutor(t)
+
+	pools, err := e.Rockferry.StoragePools().List(ctx, "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	networks, err := e.Rockferry.Networks().List(ctx, "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	request := new(rockferry.MachineRequest)
+	request.Kind = rockferry.ResourceKindMachineRequest
+	request.Owner = nodeRef("a")
+	request.Spec.Name = "web"
+	request.Spec.Topology = spec.Topology{Sockets: 1, Cores: 1, Threads: 1, Memory: 1 << 30}
+	request.Spec.Network = networks[0].Id
+	request.Spec.Disks = []*spec.MachineRequestSpecDisk{{Pool: pools[0].Id, Capacity: 1 << 30, Volume: "web-root"}}
+
+	if err := e.Rockferry.MachineRequests().Create(ctx, request); err != nil {
+		t.Fatal(err)
+	}
+
+	requests, err := e.Rockferry.MachineRequests().List(ctx, "", nil)
+	if err != nil || len(requests) != 1 {
+		t.Fatalf("listing the request gave %d requests, %v", len(requests), err)
+	}
+
+	create := new(CreateVirtualMachineTask)
+	create.Request = requests[0]
+
+	if err := create.Execute(ctx, e); err != nil {
+		t.Fatal(err)
+	}
+
+	// NOTE: A retried request must not define a second machine.
+	if err := create.Execute(ctx, e); err != nil {
+		t.Fatal(err)
+	}
+
+	if domains := hypervisor.Domains(); len(domains) != 1 {
+		t.Fatalf("hypervisor has domains %v, want one", domains)
+	}
+
+	if !create.Conditions.IsTrue(spec.MachineRequestConditionDisksCreated) || !create.Conditions.IsTrue(spec.MachineRequestConditionDomainDefined) {
+		t.Fatalf("conditions of the task are %+v", create.Conditions)
+	}
+
+	id := hypervisor.Domains()[0]
+
+	machine, err := e.Rockferry.Machines().Get(ctx, id, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if machine.Owner.Id != "a" || len(machine.Spec.Disks) != 1 || len(machine.Spec.Interfaces) != 1 {
+		t.Fatalf("machine is %+v", machine)
+	}
+
+	waitForMachine(t, e, id)
+
+	sync := new(SyncMachineStatusesTask)
+	if err := sync.Execute(ctx, e); err != nil {
+		t.Fatal(err)
+	}
+
+	machine, err = e.Rockferry.Machines().Get(ctx, id, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if machine.Status.State != spec.MachineStatusStateRunning || !machine.Status.Conditions.IsTrue(spec.ConditionReady) {
+		t.Fatalf("synced machine is %s, want it running and ready", machine.Status.State)
+	}
+
+	if machine.Status.ObservedGeneration != 1 {
+		t.Fatalf("sync lost the observed generation, got %d", machine.Status.ObservedGeneration)
+	}
+
+	if err := hypervisor.SetDomainState(id, spec.MachineStatusStateStopped); err != nil {
+		t.Fatal(err)
+	}
+
+	// NOTE: The cache may still hold the machine from before the last sync,
+	// which conflicts and is retried.
+	if err := sync.Execute(ctx, e); err != nil {
+		t.Fatal(err)
+	}
+
+	machine, err = e.Rockferry.Machines().Get(ctx, id, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if machine.Status.State != spec.MachineStatusStateStopped || !machine.Status.Conditions.IsFalse(spec.ConditionReady) {
+		t.Fatalf("synced machine is %s, want it stopped and not ready", machine.Status.State)
+	}
+
+	// Turning the machine on is a change of the spec, the node starts the domain.
+	modified := new(rockferry.Machine)
+	*modified = *machine
+	modified.Spec.PowerState = spec.MachineSpecPowerStateOn
+
+	if err := e.Rockferry.Machines().Patch(ctx, machine, modified); err != nil {
+		t.Fatal(err)
+	}
+
+	update := new(UpdateVmTask)
+	update.Prev = machine
+	update.Machine = modified
+
+	if err := update.Execute(ctx, e); err != nil {
+		t.Fatal(err)
+	}
+
+	if state, err := hypervisor.GetDomainState(id); err != nil || state != spec.MachineStatusStateRunning {
+		t.Fatalf("domain is %s after being turned on, %v", state, err)
+	}
+
+	machine, err = e.Rockferry.Machines().Get(ctx, id, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if machine.Generation != 2 || machine.Status.ObservedGeneration != 2 {
+		t.Fatalf("machine is at generation %d and observed %d, want both 2", machine.Generation, machine.Status.ObservedGeneration)
+	}
+
+	remove := new(DeleteVmTask)
+	remove.Machine = machine
+
+	if err := remove.Execute(ctx, e); err != nil {
+		t.Fatal(err)
+	}
+
+	// NOTE: Deleting again finds everything cleaned up already.
+	if err := remove.Execute(ctx, e); err != nil {
+		t.Fatal(err)
+	}
+
+	if hypervisor.DomainExists(id) {
+		t.Fatal("domain still exists after the machine was deleted")
+	}
+
+	if _, err := e.Rockferry.MachineRequests().Get(ctx, requests[0].Id, nil); err != rockferry.ErrorNotFound {
+		t.Fatalf("getting the request of the deleted machine gave %v, want it gone", err)
+	}
+}
@@ -13,7 +13,7 @@ type SyncStorageVolumesTask struct {
 }
 
 func (t *SyncStorageVolumesTask) Execute(ctx context.Context, executor *Executor) error {
-	volumes, err := executor.Libvirt.QueryStorageVolumes()
+	volumes, err := executor.Hypervisor.QueryStorageVolumes()
 	if err != nil {
 		return err
 	}
@@ -23,6 +23,7 @@ func (t *SyncStorageVolumesTask) Execute(ctx context.Context, executor *Executor
 		remote, err := iface.Get(ctx, local.Id, nil)
 		if err != nil {
 			if err == rockferry.ErrorNotFound {
+				local.Status.Conditions.Set(foundCondition())
 				if err := iface.Create(ctx, local); err != nil {
 					return err
 				}
@@ -35,6 +36,8 @@ func (t *SyncStorageVolumesTask) Execute(ctx context.Context, executor *Executor
 		}
 		// NOTE: This will make sure we do not lose any annotations on the way.
 		local.Merge(remote)
+		local.Status.Conditions = remote.Status.Conditions
+		local.Status.Conditions.Set(foundCondition())
 
 		if err := iface.Patch(ctx, remote, local); err != nil {
 			panic(err)
@@ -55,7 +58,15 @@ type DeleteVolumeTask struct {
 }
 
 func (t *DeleteVolumeTask) Execute(ctx context.Context, executor *Executor) error {
-	return executor.Libvirt.DeleteStorageVolume(t.Volume.Spec.Key)
+	return executor.Hypervisor.DeleteStorageVolume(t.Volume.Spec.Key)
+}
+
+func (t *DeleteVolumeTask) Object() *rockferry.OwnerRef {
+	return &rockferry.OwnerRef{Kind: rockferry.ResourceKindStorageVolume, Id: t.Volume.Id}
+}
+
+func (t *DeleteVolumeTask) FailureReason() string {
+	return "FailedDelete"
 }
 
 func (t *DeleteVolumeTask) Repeats() *time.Duration {
@@ -67,11 +78,10 @@ type CreateVolumeTask struct {
 }
 
 func (t *CreateVolumeTask) Execute(ctx context.Context, executor *Executor) error {
-	pools, err := executor.Rockferry.StoragePools().List(ctx, t.Volume.Owner.Id, nil)
+	pool, err := executor.StoragePools.Get(t.Volume.Owner.Id)
 	if err != nil {
 		return err
 	}
-	pool := pools[0]
 
 	fmt.Println("creating storage volume for: ", t.Volume.Annotations["machinereq.name"])
 
@@ -80,11 +90,14 @@ func (t *CreateVolumeTask) Execute(ctx context.Context, executor *Executor) erro
 	capacity := t.Volume.Spec.Capacity
 	allocation := t.Volume.Spec.Allocation
 
-	if err := executor.Libvirt.CreateVolume(pool.Spec.Name, name, format, capacity, allocation); err != nil {
-		return err
+	// NOTE: A previous attempt may have created the volume before failing.
+	if _, err := executor.Hypervisor.QueryVolumeSpec(pool.Spec.Name, name); err != nil {
+		if err := executor.Hypervisor.CreateVolume(pool.Spec.Name, name, format, capacity, allocation); err != nil {
+			return err
+		}
 	}
 
-	updatedSpec, err := executor.Libvirt.QueryVolumeSpec(pool.Spec.Name, t.Volume.Spec.Name)
+	updatedSpec, err := executor.Hypervisor.QueryVolumeSpec(pool.Spec.Name, t.Volume.Spec.Name)
 	if err != nil {
 		return err
 	}
@@ -94,7 +107,3 @@ func (t *CreateVolumeTask) Execute(ctx context.Context, executor *Executor) erro
 
 	return executor.Rockferry.StorageVolumes().Patch(ctx, t.Volume, modified)
 }
-
-func (t *CreateVolumeTask) Resource() *rockferry.Resource[any, any] {
-	return t.Volume.Generic()
-}
@@ -2,47 +2,137 @@ package node
 
 import (
 	"context"
+	"fmt"
 
 	"github.com/eskpil/rockferry/internal/node/tasks"
+	"github.com/eskpil/rockferry/pkg/reconcile"
 	"github.com/eskpil/rockferry/pkg/rockferry"
+	"github.com/eskpil/rockferry/pkg/rockferry/cache"
+	"github.com/eskpil/rockferry/pkg/rockferry/spec"
 )
 
-func (s *State) watchMachineRequests(ctx context.Context) error {
-	go func() {
-		requests, err := s.Client.MachineRequests().List(ctx, "", nil)
-		if err != nil && err != rockferry.ErrorNotFound {
-			return
-		}
-
-		for _, req := range requests {
-			if req.Phase == rockferry.PhaseRequested {
-				task := new(tasks.CreateVirtualMachineTask)
-				task.Request = req
-				s.t.AppendBound(task)
+// Only resources which still have to be created, or failed to be, are of interest.
+var pendingSelector = rockferry.WithFieldSelector(fmt.Sprintf("%s in (%s,%s)", rockferry.FieldPhase, rockferry.PhaseRequested, rockferry.PhaseErrored))
+
+func enqueuePending[S any, T any](c *reconcile.Controller) *cache.EventHandler[S, T] {
+	return &cache.EventHandler[S, T]{
+		OnAdd: func(resource *rockferry.Resource[S, T]) {
+			c.Enqueue(resource.Id)
+		},
+		OnUpdate: func(_ *rockferry.Resource[S, T], resource *rockferry.Resource[S, T]) {
+			// NOTE: Errored resources are retried with backoff by the controller,
+			//       our own status writes should not cut that short.
+			if resource.Phase == rockferry.PhaseRequested {
+				c.Enqueue(resource.Id)
 			}
+		},
+	}
+}
+
+// Resources being deleted which still carry the finalizer of the node.
+func enqueueFinalizing[S any, T any](c *reconcile.Controller) *cache.EventHandler[S, T] {
+	enqueue := func(resource *rockferry.Resource[S, T]) {
+		if resource.Deleting() && resource.HasFinalizer(rockferry.FinalizerNode) {
+			c.Enqueue(resource.Id)
 		}
+	}
 
-		stream, err := s.Client.MachineRequests().Watch(ctx, rockferry.WatchActionUpdate, "", nil)
-		if err != nil {
-			return
+	return &cache.EventHandler[S, T]{
+		OnAdd: enqueue,
+		OnUpdate: func(_ *rockferry.Resource[S, T], resource *rockferry.Resource[S, T]) {
+			enqueue(resource)
+		},
+	}
+}
+
+// Machines which have been failed over to this node and are yet to be started.
+func enqueueFailover(c *reconcile.Controller, node string) *cache.EventHandler[spec.MachineSpec, spec.MachineStatus] {
+	enqueue := func(machine *rockferry.Machine) {
+		failover := machine.Status.Failover
+		if failover != nil && failover.State == spec.MachineStatusFailoverStatePending && failover.TargetNode == node {
+			c.Enqueue(machine.Id)
 		}
+	}
 
-		for {
-			req := <-stream
+	return &cache.EventHandler[spec.MachineSpec, spec.MachineStatus]{
+		OnAdd: enqueue,
+		OnUpdate: func(_ *rockferry.Machine, machine *rockferry.Machine) {
+			enqueue(machine)
+		},
+	}
+}
 
-			if req.Resource.Phase == rockferry.PhaseRequested {
-				task := new(tasks.CreateVirtualMachineTask)
-				task.Request = req.Resource
-				s.t.AppendBound(task)
-			}
+func (s *State) watchMachineRequests(ctx context.Context) error {
+	owner := new(rockferry.OwnerRef)
+	owner.Id = s.nodeId
+	owner.Kind = rockferry.ResourceKindNode
 
-		}
-	}()
+	reconciler := new(tasks.MachineRequestReconciler)
+	reconciler.Executor = s.t.Executor()
+
+	controller := reconcile.NewController("machinerequests", reconciler, 2)
+
+	informer := cache.NewInformer(s.Client.MachineRequests(), owner, pendingSelector)
+	informer.AddEventHandler(enqueuePending[spec.MachineRequestSpec, spec.MachineRequestStatus](controller))
+
+	if err := informer.Start(ctx); err != nil {
+		return err
+	}
+
+	go controller.Run(ctx)
+
+	return nil
+}
+
+func (s *State) watchMachineMigrations(ctx context.Context) error {
+	owner := new(rockferry.OwnerRef)
+	owner.Id = s.nodeId
+	owner.Kind = rockferry.ResourceKindNode
+
+	reconciler := new(tasks.MachineMigrationReconciler)
+	reconciler.Executor = s.t.Executor()
+
+	// NOTE: Migrations saturate the network, one at a time is plenty.
+	controller := reconcile.NewController("machinemigrations", reconciler, 1)
+
+	informer := cache.NewInformer(s.Client.MachineMigrations(), owner, pendingSelector)
+	informer.AddEventHandler(enqueuePending[spec.MachineMigrationSpec, spec.MachineMigrationStatus](controller))
+
+	if err := informer.Start(ctx); err != nil {
+		return err
+	}
+
+	go controller.Run(ctx)
 
 	return nil
 }
 
 func (s *State) watchMachines(ctx context.Context) error {
+	owner := new(rockferry.OwnerRef)
+	owner.Id = s.nodeId
+	owner.Kind = rockferry.ResourceKindNode
+
+	finalizer := new(tasks.MachineFinalizer)
+	finalizer.Executor = s.t.Executor()
+
+	controller := reconcile.NewController("machines-finalizer", finalizer, 2)
+
+	failover := new(tasks.MachineFailover)
+	failover.Executor = s.t.Executor()
+
+	failoverController := reconcile.NewController("machines-failover", failover, 2)
+
+	informer := cache.NewInformer(s.Client.Machines(), owner)
+	informer.AddEventHandler(enqueueFinalizing[spec.MachineSpec, spec.MachineStatus](controller))
+	informer.AddEventHandler(enqueueFailover(failoverController, s.nodeId))
+
+	if err := informer.Start(ctx); err != nil {
+		return err
+	}
+
+	go controller.Run(ctx)
+	go failoverController.Run(ctx)
+
 	go func() {
 		stream, err := s.Client.Machines().Watch(ctx, rockferry.WatchActionDelete, "", nil)
 		if err != nil {
@@ -51,6 +141,12 @@ func (s *State) watchMachines(ctx context.Context) error {
 
 		for {
 			machine := <-stream
+
+			// NOTE: Machines with finalizers have already been torn down by the finalizer.
+			if machine.Resource.Deleting() {
+				continue
+			}
+
 			task := new(tasks.DeleteVmTask)
 			task.Machine = machine.Resource
 			s.t.AppendUnbound(task)
@@ -65,6 +161,12 @@ func (s *State) watchMachines(ctx context.Context) error {
 
 		for {
 			e := <-stream
+
+			// NOTE: Status updates are mostly written by ourselves, nothing to act on.
+			if !e.SpecChanged || e.Resource.Deleting() {
+				continue
+			}
+
 			task := new(tasks.UpdateVmTask)
 			task.Machine = e.Resource
 			task.Prev = e.Prev
@@ -76,37 +178,33 @@ func (s *State) watchMachines(ctx context.Context) error {
 }
 
 func (s *State) watchStorageVolumes(ctx context.Context) error {
-	volumes, err := s.Client.StorageVolumes().List(ctx, "", nil)
-	if err != nil && err != rockferry.ErrorNotFound {
+	reconciler := new(tasks.StorageVolumeReconciler)
+	reconciler.Executor = s.t.Executor()
+
+	controller := reconcile.NewController("storagevolumes", reconciler, 2)
+
+	informer := cache.NewInformer(s.Client.StorageVolumes(), nil, pendingSelector)
+	informer.AddEventHandler(enqueuePending[spec.StorageVolumeSpec, rockferry.DefaultStatus](controller))
+
+	if err := informer.Start(ctx); err != nil {
 		return err
 	}
 
-	for _, vol := range volumes {
-		if vol.Phase == rockferry.PhaseRequested {
-			task := new(tasks.CreateVolumeTask)
-			task.Volume = vol
-			s.t.AppendBound(task)
-		}
-	}
+	go controller.Run(ctx)
 
-	// TODO: Combine?
-	go func() {
-		stream, err := s.Client.StorageVolumes().Watch(ctx, rockferry.WatchActionCreate, "", nil)
-		if err != nil {
-			return
-		}
+	finalizer := new(tasks.StorageVolumeFinalizer)
+	finalizer.Executor = s.t.Executor()
 
-		for {
-			vol := <-stream
+	finalizerController := reconcile.NewController("storagevolumes-finalizer", finalizer, 2)
 
-			if vol.Resource.Phase == rockferry.PhaseRequested {
-				task := new(tasks.CreateVolumeTask)
-				task.Volume = vol.Resource
-				s.t.AppendBound(task)
-			}
+	finalizing := cache.NewInformer(s.Client.StorageVolumes(), nil)
+	finalizing.AddEventHandler(enqueueFinalizing[spec.StorageVolumeSpec, rockferry.DefaultStatus](finalizerController))
 
-		}
-	}()
+	if err := finalizing.Start(ctx); err != nil {
+		return err
+	}
+
+	go finalizerController.Run(ctx)
 
 	go func() {
 		stream, err := s.Client.StorageVolumes().Watch(ctx, rockferry.WatchActionDelete, "", nil)
@@ -117,6 +215,11 @@ func (s *State) watchStorageVolumes(ctx context.Context) error {
 		for {
 			vol := <-stream
 
+			// NOTE: Volumes with finalizers are removed by the finalizer.
+			if vol.Resource.Deleting() {
+				continue
+			}
+
 			task := new(tasks.DeleteVolumeTask)
 			task.Volume = vol.Resource
 			s.t.AppendUnbound(task)
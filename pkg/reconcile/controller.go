@@ -0,0 +1,107 @@
+package reconcile
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+type Result struct {
+	// Reconcile the key again, with backoff.
+	Requeue bool
+	// Reconcile the key again after this long, takes precedence over Requeue.
+	RequeueAfter time.Duration
+}
+
+// Brings whatever key refers to closer to its desired state. Reconcile is never
+// called concurrently for the same key. Returning an error requeues the key with
+// exponential backoff.
+type Reconciler interface {
+	Reconcile(ctx context.Context, key string) (Result, error)
+}
+
+type ReconcilerFunc func(ctx context.Context, key string) (Result, error)
+
+func (f ReconcilerFunc) Reconcile(ctx context.Context, key string) (Result, error) {
+	return f(ctx, key)
+}
+
+type Controller struct {
+	Name string
+
+	queue      *Queue
+	reconciler Reconciler
+	workers    int
+}
+
+func NewController(name string, reconciler Reconciler, workers int) *Controller {
+	c := new(Controller)
+	c.Name = name
+	c.queue = NewQueue()
+	c.reconciler = reconciler
+	c.workers = max(workers, 1)
+	return c
+}
+
+func (c *Controller) Enqueue(key string) {
+	c.queue.Add(key)
+}
+
+func (c *Controller) EnqueueAfter(key string, delay time.Duration) {
+	c.queue.AddAfter(key, delay)
+}
+
+// Processes keys until ctx is done.
+func (c *Controller) Run(ctx context.Context) {
+	wg := new(sync.WaitGroup)
+
+	for range c.workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for c.processNext(ctx) {
+			}
+		}()
+	}
+
+	<-ctx.Done()
+	c.queue.ShutDown()
+	wg.Wait()
+}
+
+func (c *Controller) processNext(ctx context.Context) bool {
+	key, ok := c.queue.Get()
+	if !ok {
+		return false
+	}
+
+	defer c.queue.Done(key)
+
+	result, err := c.reconcile(ctx, key)
+	switch {
+	case err != nil:
+		fmt.Println(c.Name, "failed to reconcile", key, err)
+		c.queue.AddRateLimited(key)
+	case result.RequeueAfter > 0:
+		c.queue.Forget(key)
+		c.queue.AddAfter(key, result.RequeueAfter)
+	case result.Requeue:
+		c.queue.AddRateLimited(key)
+	default:
+		c.queue.Forget(key)
+	}
+
+	return true
+}
+
+// NOTE: A panicking reconciler should not take the whole agent down with it.
+func (c *Controller) reconcile(ctx context.Context, key string) (result Result, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+
+	return c.reconciler.Reconcile(ctx, key)
+}
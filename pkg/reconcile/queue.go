@@ -0,0 +1,148 @@
+package reconcile
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	backoffBase = 500 * time.Millisecond
+	backoffMax  = 5 * time.Minute
+)
+
+// Queue hands out keys to workers. A key is only queued once no matter how many
+// times it is added, and a key is never handed to two workers at the same time.
+// Keys added while being processed are queued again once Done is called.
+type Queue struct {
+	mu   sync.Mutex
+	cond *sync.Cond
+
+	order      []string
+	queued     map[string]struct{}
+	processing map[string]struct{}
+	// Keys which were added while being processed.
+	dirty map[string]struct{}
+
+	failures map[string]int
+	shutdown bool
+}
+
+func NewQueue() *Queue {
+	q := new(Queue)
+	q.cond = sync.NewCond(&q.mu)
+	q.queued = map[string]struct{}{}
+	q.processing = map[string]struct{}{}
+	q.dirty = map[string]struct{}{}
+	q.failures = map[string]int{}
+	return q
+}
+
+func (q *Queue) Add(key string) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.shutdown {
+		return
+	}
+
+	if _, ok := q.processing[key]; ok {
+		q.dirty[key] = struct{}{}
+		return
+	}
+
+	if _, ok := q.queued[key]; ok {
+		return
+	}
+
+	q.queued[key] = struct{}{}
+	q.order = append(q.order, key)
+	q.cond.Signal()
+}
+
+func (q *Queue) AddAfter(key string, delay time.Duration) {
+	if delay <= 0 {
+		q.Add(key)
+		return
+	}
+
+	time.AfterFunc(delay, func() {
+		q.Add(key)
+	})
+}
+
+// Adds key after a delay which doubles for every failure since the last Forget.
+func (q *Queue) AddRateLimited(key string) {
+	q.mu.Lock()
+	failures := q.failures[key]
+	q.failures[key] = failures + 1
+	q.mu.Unlock()
+
+	delay := backoffMax
+	if failures < 20 {
+		delay = min(backoffBase<<failures, backoffMax)
+	}
+
+	q.AddAfter(key, delay)
+}
+
+// Resets the backoff of key.
+func (q *Queue) Forget(key string) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	delete(q.failures, key)
+}
+
+// Blocks until a key is available. The key has to be handed back with Done.
+// Returns false once the queue has been shut down.
+func (q *Queue) Get() (string, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for len(q.order) == 0 && !q.shutdown {
+		q.cond.Wait()
+	}
+
+	if q.shutdown {
+		return "", false
+	}
+
+	key := q.order[0]
+	q.order = q.order[1:]
+
+	delete(q.queued, key)
+	q.processing[key] = struct{}{}
+
+	return key, true
+}
+
+func (q *Queue) Done(key string) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	delete(q.processing, key)
+
+	if _, ok := q.dirty[key]; ok && !q.shutdown {
+		delete(q.dirty, key)
+
+		q.queued[key] = struct{}{}
+		q.order = append(q.order, key)
+		q.cond.Signal()
+	}
+}
+
+func (q *Queue) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	return len(q.order)
+}
+
+// Wakes every blocked Get, keys added afterwards are dropped.
+func (q *Queue) ShutDown() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.shutdown = true
+	q.cond.Broadcast()
+}
@@ -0,0 +1,51 @@
+package rockferry
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/eskpil/rockferry/controllerapi"
+	"github.com/eskpil/rockferry/pkg/rockferry/spec"
+)
+
+// AuditEvents returns up to limit of the latest creates, patches and deletes
+// of resources of kind, newest first. An empty id returns them for every
+// resource of the kind, a limit of zero lets the controller decide.
+func (c *Client) AuditEvents(ctx context.Context, kind string, id string, limit int) ([]*spec.AuditEventSpec, error) {
+	req := new(controllerapi.ListAuditEventsRequest)
+	req.Kind = kind
+	req.Limit = int32(limit)
+
+	if id != "" {
+		req.Id = &id
+	}
+
+	res, err := c.t.A().ListAuditEvents(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	events := make([]*spec.AuditEventSpec, len(res.Events))
+	for i, in := range res.Events {
+		event := new(spec.AuditEventSpec)
+		event.Time, _ = time.Parse(time.RFC3339Nano, in.Time)
+		event.User = in.User
+		event.Groups = in.Groups
+		event.Source = in.Source
+		event.Api = in.Api
+		event.Verb = in.Verb
+		event.Kind = in.Kind
+		event.Id = in.Id
+		event.Subresource = in.Subresource
+		event.Code = in.Code
+
+		if len(in.Patch) > 0 {
+			event.Patch = json.RawMessage(in.Patch)
+		}
+
+		events[i] = event
+	}
+
+	return events, nil
+}
@@ -0,0 +1,98 @@
+package rockferry
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"time"
+
+	"github.com/eskpil/rockferry/controllerapi"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func randomHex(n int) (string, error) {
+	bytes := make([]byte, n)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(bytes), nil
+}
+
+// HashBootstrapSecret is how the secret of a bootstrap token is kept.
+func HashBootstrapSecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+// NewBootstrapToken generates a bootstrap token valid for ttl. Returns the
+// resource to create and the token to hand to the node agent, which can not be
+// recovered from the resource.
+func NewBootstrapToken(ttl time.Duration, description string) (*BootstrapToken, string, error) {
+	id, err := randomHex(4)
+	if err != nil {
+		return nil, "", err
+	}
+
+	secret, err := randomHex(16)
+	if err != nil {
+		return nil, "", err
+	}
+
+	token := new(BootstrapToken)
+	token.Kind = ResourceKindBootstrapToken
+	token.Id = id
+	token.Spec.SecretHash = HashBootstrapSecret(secret)
+	token.Spec.Expires = time.Now().Add(ttl).UTC()
+	token.Spec.Description = description
+
+	return token, id + "." + secret, nil
+}
+
+// ParseBootstrapToken splits a token into the id of its resource and the secret.
+func ParseBootstrapToken(token string) (string, string, error) {
+	id, secret, ok := strings.Cut(token, ".")
+	if !ok || id == "" || secret == "" {
+		return "", "", ErrorInvalidToken
+	}
+
+	return id, secret, nil
+}
+
+// A node registered with a bootstrap token.
+type Registration struct {
+	// The id the controller gave the node.
+	Id string
+	// Pem encoded, signed by the ca of the controller. Both are empty when the
+	// controller is served without tls.
+	Certificate []byte
+	CA          []byte
+}
+
+// RegisterNode registers a new node with a bootstrap token, which can not be
+// used again. csr is a pem encoded certificate request for the key of the node,
+// the name it asks for is ignored.
+func (c *Client) RegisterNode(ctx context.Context, bootstrapToken string, csr []byte) (*Registration, error) {
+	req := new(controllerapi.RegisterNodeRequest)
+	req.BootstrapToken = bootstrapToken
+	req.Csr = csr
+
+	res, err := c.t.A().RegisterNode(ctx, req)
+	if status.Code(err) == codes.PermissionDenied {
+		return nil, ErrorInvalidToken
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	registration := new(Registration)
+	registration.Id = res.Id
+	registration.Certificate = res.Certificate
+	registration.CA = res.Ca
+
+	return registration, nil
+}
@@ -0,0 +1,149 @@
+package cache
+
+import (
+	"context"
+	"sync"
+
+	"github.com/eskpil/rockferry/pkg/rockferry"
+)
+
+// Any of the functions may be left nil. They are called one at a time, in the
+// order the changes happened.
+type EventHandler[S any, T any] struct {
+	OnAdd    func(resource *rockferry.Resource[S, T])
+	OnUpdate func(old *rockferry.Resource[S, T], new *rockferry.Resource[S, T])
+	OnDelete func(resource *rockferry.Resource[S, T])
+}
+
+// Informer keeps a local store of resources up to date by listing them once and
+// then following the watch stream.
+type Informer[S any, T any] struct {
+	iface *rockferry.Interface[S, T]
+	owner *rockferry.OwnerRef
+	opts  []rockferry.ListOption
+
+	store  *Store[S, T]
+	synced chan struct{}
+
+	// Held while a change is applied and dispatched, so handlers which are
+	// added later see a consistent store.
+	mu       sync.Mutex
+	handlers []*EventHandler[S, T]
+}
+
+// Only resources owned by owner and matching the options are kept, owner may be nil.
+func NewInformer[S any, T any](iface *rockferry.Interface[S, T], owner *rockferry.OwnerRef, opts ...rockferry.ListOption) *Informer[S, T] {
+	i := new(Informer[S, T])
+	i.iface = iface
+	i.owner = owner
+	i.opts = opts
+	i.store = NewStore[S, T]()
+	i.synced = make(chan struct{})
+	return i
+}
+
+func (i *Informer[S, T]) Lister() *Lister[S, T] {
+	return NewLister(i.store)
+}
+
+// Registers handler. OnAdd is called right away for every resource already stored.
+func (i *Informer[S, T]) AddEventHandler(handler *EventHandler[S, T]) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	i.handlers = append(i.handlers, handler)
+
+	if handler.OnAdd == nil {
+		return
+	}
+
+	for _, resource := range i.store.list() {
+		handler.OnAdd(resource)
+	}
+}
+
+func (i *Informer[S, T]) HasSynced() bool {
+	select {
+	case <-i.synced:
+		return true
+	default:
+		return false
+	}
+}
+
+// Blocks until the initial list has been stored or ctx is done.
+func (i *Informer[S, T]) WaitForSync(ctx context.Context) error {
+	select {
+	case <-i.synced:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Runs the informer in the background and waits for the initial list.
+func (i *Informer[S, T]) Start(ctx context.Context) error {
+	errs := make(chan error, 1)
+	go func() {
+		errs <- i.Run(ctx)
+	}()
+
+	select {
+	case <-i.synced:
+		return nil
+	case err := <-errs:
+		return err
+	}
+}
+
+// Lists the resources and applies every change to the store until ctx is done.
+func (i *Informer[S, T]) Run(ctx context.Context) error {
+	list, stream, err := i.iface.ListAndWatch(ctx, i.owner, i.opts...)
+	if err != nil {
+		return err
+	}
+
+	for _, resource := range list {
+		i.apply(rockferry.WatchActionCreate, resource)
+	}
+
+	close(i.synced)
+
+	for e := range stream {
+		i.apply(e.Action, e.Resource)
+	}
+
+	return ctx.Err()
+}
+
+func (i *Informer[S, T]) apply(action rockferry.WatchAction, resource *rockferry.Resource[S, T]) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	if action == rockferry.WatchActionDelete {
+		old := i.store.Delete(resource.Id)
+		if old == nil {
+			old = resource
+		}
+
+		for _, h := range i.handlers {
+			if h.OnDelete != nil {
+				h.OnDelete(old)
+			}
+		}
+
+		return
+	}
+
+	old := i.store.Set(resource)
+
+	for _, h := range i.handlers {
+		if old == nil && h.OnAdd != nil {
+			h.OnAdd(resource)
+		}
+
+		if old != nil && h.OnUpdate != nil {
+			h.OnUpdate(old, resource)
+		}
+	}
+}
@@ -0,0 +1,172 @@
+package cache
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/eskpil/rockferry/pkg/rockferry"
+)
+
+type index map[string]map[string]struct{}
+
+func (i index) add(key string, id string) {
+	ids, ok := i[key]
+	if !ok {
+		ids = map[string]struct{}{}
+		i[key] = ids
+	}
+
+	ids[id] = struct{}{}
+}
+
+func (i index) remove(key string, id string) {
+	delete(i[key], id)
+	if len(i[key]) == 0 {
+		delete(i, key)
+	}
+}
+
+func ownerKey(owner *rockferry.OwnerRef) string {
+	return owner.Kind + "/" + owner.Id
+}
+
+func annotationKey(key string, value string) string {
+	return key + "=" + value
+}
+
+// Keeps resources by id, indexed by owner and annotations. Safe for concurrent use.
+type Store[S any, T any] struct {
+	mu sync.RWMutex
+
+	items       map[string]*rockferry.Resource[S, T]
+	owners      index
+	annotations index
+}
+
+func NewStore[S any, T any]() *Store[S, T] {
+	s := new(Store[S, T])
+	s.items = map[string]*rockferry.Resource[S, T]{}
+	s.owners = index{}
+	s.annotations = index{}
+	return s
+}
+
+// Adds or replaces the resource, the replaced resource is returned if there was one.
+func (s *Store[S, T]) Set(resource *rockferry.Resource[S, T]) *rockferry.Resource[S, T] {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	old := s.remove(resource.Id)
+
+	s.items[resource.Id] = resource
+
+	if resource.Owner != nil {
+		s.owners.add(ownerKey(resource.Owner), resource.Id)
+	}
+
+	for k, v := range resource.Annotations {
+		s.annotations.add(annotationKey(k, v), resource.Id)
+	}
+
+	return old
+}
+
+// Removes the resource with id, returning it if it was stored.
+func (s *Store[S, T]) Delete(id string) *rockferry.Resource[S, T] {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.remove(id)
+}
+
+func (s *Store[S, T]) remove(id string) *rockferry.Resource[S, T] {
+	old, ok := s.items[id]
+	if !ok {
+		return nil
+	}
+
+	delete(s.items, id)
+
+	if old.Owner != nil {
+		s.owners.remove(ownerKey(old.Owner), id)
+	}
+
+	for k, v := range old.Annotations {
+		s.annotations.remove(annotationKey(k, v), id)
+	}
+
+	return old
+}
+
+func (s *Store[S, T]) get(id string) (*rockferry.Resource[S, T], bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	resource, ok := s.items[id]
+	return resource, ok
+}
+
+func (s *Store[S, T]) list() []*rockferry.Resource[S, T] {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	out := make([]*rockferry.Resource[S, T], 0, len(s.items))
+	for _, resource := range s.items {
+		out = append(out, resource)
+	}
+
+	return sorted(out)
+}
+
+func (s *Store[S, T]) byIndex(i index, key string) []*rockferry.Resource[S, T] {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	out := make([]*rockferry.Resource[S, T], 0, len(i[key]))
+	for id := range i[key] {
+		out = append(out, s.items[id])
+	}
+
+	return sorted(out)
+}
+
+func sorted[S any, T any](resources []*rockferry.Resource[S, T]) []*rockferry.Resource[S, T] {
+	sort.Slice(resources, func(a, b int) bool {
+		return resources[a].Id < resources[b].Id
+	})
+
+	return resources
+}
+
+// Read only access to a store. The returned resources are shared with the store
+// and must not be modified, copy them first.
+type Lister[S any, T any] struct {
+	s *Store[S, T]
+}
+
+func NewLister[S any, T any](s *Store[S, T]) *Lister[S, T] {
+	l := new(Lister[S, T])
+	l.s = s
+	return l
+}
+
+func (l *Lister[S, T]) Get(id string) (*rockferry.Resource[S, T], error) {
+	resource, ok := l.s.get(id)
+	if !ok {
+		return nil, rockferry.ErrorNotFound
+	}
+
+	return resource, nil
+}
+
+func (l *Lister[S, T]) List() []*rockferry.Resource[S, T] {
+	return l.s.list()
+}
+
+func (l *Lister[S, T]) ListByOwner(owner *rockferry.OwnerRef) []*rockferry.Resource[S, T] {
+	return l.s.byIndex(l.s.owners, ownerKey(owner))
+}
+
+func (l *Lister[S, T]) ListByAnnotation(key string, value string) []*rockferry.Resource[S, T] {
+	return l.s.byIndex(l.s.annotations, annotationKey(key, value))
+}
@@ -13,6 +13,11 @@ func Cast[Spec any, Status any](r *Generic) *Resource[Spec, Status] {
 	mapped.Kind = r.Kind
 	mapped.Annotations = r.Annotations
 	mapped.Phase = r.Phase
+	mapped.Parents = r.Parents
+	mapped.Finalizers = r.Finalizers
+	mapped.DeletionTimestamp = r.DeletionTimestamp
+	mapped.ResourceVersion = r.ResourceVersion
+	mapped.Generation = r.Generation
 
 	status, err := convert.Convert[Status](r.RawStatus)
 	if err != nil {
@@ -36,6 +41,11 @@ func CastFromMap[Spec any, Status any](r *Generic) *Resource[Spec, Status] {
 	mapped.Kind = r.Kind
 	mapped.Annotations = r.Annotations
 	mapped.Phase = r.Phase
+	mapped.Parents = r.Parents
+	mapped.Finalizers = r.Finalizers
+	mapped.DeletionTimestamp = r.DeletionTimestamp
+	mapped.ResourceVersion = r.ResourceVersion
+	mapped.Generation = r.Generation
 
 	statusBytes, err := json.Marshal(r.Status)
 	if err != nil {
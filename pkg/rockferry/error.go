@@ -8,6 +8,18 @@ const (
 	ErrorUnexpectedResults   Error = "unexpected results"
 	ErrorStreamClosed        Error = "stream closed"
 	ErrorInternalServerError Error = "internal server error"
+	ErrorConflict            Error = "resource has been modified"
+	ErrorStatusPatch         Error = "status can only be patched through the status subresource"
+	ErrorSpecPatch           Error = "only status and phase can be patched through the status subresource"
+	ErrorInvalidSelector     Error = "invalid selector"
+	ErrorCompacted           Error = "requested revision has been compacted"
+	ErrorDeletionTimestamp   Error = "deletion timestamp can only be set by deleting the resource"
+	ErrorInvalidToken        Error = "invalid token"
+	ErrorForbidden           Error = "permission denied"
+	ErrorCreatorAnnotation   Error = "creator annotation can not be changed"
+	ErrorReconcileFailed     Error = "reconciler failed to act on the spec"
+	ErrorAlreadyExists       Error = "resource already exists"
+	ErrorNotReconciled       Error = "nothing reconciles resources of this kind"
 )
 
 func (e Error) Error() string {
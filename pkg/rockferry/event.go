@@ -0,0 +1,176 @@
+package rockferry
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/eskpil/rockferry/pkg/rockferry/spec"
+)
+
+const (
+	EventTypeNormal  = "Normal"
+	EventTypeWarning = "Warning"
+)
+
+// The fields events about a resource can be selected by.
+const (
+	FieldEventObjectKind = "spec.object.kind"
+	FieldEventObjectId   = "spec.object.id"
+)
+
+// How many events a recorder remembers having stored, it looks them up again
+// once it has forgotten them.
+const eventRecorderCacheSize = 1024
+
+// EventId is the id of the event a source reports about object. Repeats of
+// the same event share the id, which is how they are counted instead of stored again.
+func EventId(source string, object *OwnerRef, eventType string, reason string, message string) string {
+	sum := sha256.Sum256([]byte(strings.Join([]string{source, object.Kind, object.Id, eventType, reason, message}, "\x00")))
+	return hex.EncodeToString(sum[:16])
+}
+
+// NewEvent returns the first occurrence of an event.
+func NewEvent(source string, object *OwnerRef, eventType string, reason string, message string) *Event {
+	now := time.Now().UTC()
+
+	event := new(Event)
+	event.Id = EventId(source, object, eventType, reason, message)
+	event.Kind = ResourceKindEvent
+	event.Spec.Object.Kind = object.Kind
+	event.Spec.Object.Id = object.Id
+	event.Spec.Type = eventType
+	event.Spec.Reason = reason
+	event.Spec.Message = message
+	event.Spec.Source = source
+	event.Spec.Count = 1
+	event.Spec.FirstSeen = now
+	event.Spec.LastSeen = now
+
+	return event
+}
+
+// Selects the events about object, see Interface.List.
+func WithEventObject(object *OwnerRef) ListOption {
+	return WithFieldSelector(fmt.Sprintf("%s=%s,%s=%s", FieldEventObjectKind, object.Kind, FieldEventObjectId, object.Id))
+}
+
+// EventRecorder records events about resources. Repeats of an event are
+// counted on the stored event. It is safe to use from several goroutines.
+type EventRecorder struct {
+	*Interface[spec.EventSpec, DefaultStatus]
+
+	source string
+
+	mu sync.Mutex
+	// The latest version of the events recorded so far, saves looking them up.
+	recorded map[string]*Event
+}
+
+func NewEventRecorder(i *Interface[spec.EventSpec, DefaultStatus], source string) *EventRecorder {
+	e := new(EventRecorder)
+	e.Interface = i
+	e.source = source
+	e.recorded = map[string]*Event{}
+	return e
+}
+
+// WithSource returns a recorder for the same controller which reports events
+// as coming from source, usually the id of a node.
+func (e *EventRecorder) WithSource(source string) *EventRecorder {
+	return NewEventRecorder(e.Interface, source)
+}
+
+// Record stores an event about object, or counts it if it was already stored.
+func (e *EventRecorder) Record(ctx context.Context, object *OwnerRef, eventType string, reason string, message string) error {
+	id := EventId(e.source, object, eventType, reason, message)
+
+	return RetryOnConflict(ctx, func() error {
+		original, ok := e.lookup(id)
+		if !ok {
+			var err error
+			original, err = e.Get(ctx, id, nil)
+			if err == ErrorNotFound {
+				event := NewEvent(e.source, object, eventType, reason, message)
+				err := e.Create(ctx, event)
+				if err == ErrorAlreadyExists {
+					// NOTE: Another recorder stored it first, count it on theirs.
+					return ErrorConflict
+				}
+
+				if err != nil {
+					return err
+				}
+
+				e.remember(event)
+				return nil
+			}
+
+			if err != nil {
+				return err
+			}
+		}
+
+		modified := *original
+		modified.Spec.Count++
+		modified.Spec.LastSeen = time.Now().UTC()
+
+		err := e.Patch(ctx, original, &modified)
+		if err == ErrorConflict || err == ErrorNotFound {
+			// NOTE: Either someone else counted it, or the event expired. Look it up again.
+			e.forget(id)
+			if err == ErrorNotFound {
+				return ErrorConflict
+			}
+		}
+
+		if err != nil {
+			return err
+		}
+
+		e.remember(&modified)
+		return nil
+	})
+}
+
+// NOTE: The lock is only held around the cache, never while talking to the
+// controller. Concurrent counts of the same event conflict and are retried.
+func (e *EventRecorder) lookup(id string) (*Event, bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	event, ok := e.recorded[id]
+	return event, ok
+}
+
+func (e *EventRecorder) remember(event *Event) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if len(e.recorded) >= eventRecorderCacheSize {
+		clear(e.recorded)
+	}
+
+	e.recorded[event.Id] = event
+}
+
+func (e *EventRecorder) forget(id string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	delete(e.recorded, id)
+}
+
+// Recordf is Record with a formatted message.
+func (e *EventRecorder) Recordf(ctx context.Context, object *OwnerRef, eventType string, reason string, format string, args ...any) error {
+	return e.Record(ctx, object, eventType, reason, fmt.Sprintf(format, args...))
+}
+
+// For lists the events about object.
+func (e *EventRecorder) For(ctx context.Context, object *OwnerRef) ([]*Event, error) {
+	return e.List(ctx, "", nil, WithEventObject(object))
+}
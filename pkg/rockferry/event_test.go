@@ -0,0 +1,105 @@
+package rockferry_test
+
+import (
+	"context"
+	"net"
+	"sync"
+	"testing"
+
+	"github.com/eskpil/rockferry/controllerapi"
+	"github.com/eskpil/rockferry/internal/controller"
+	"github.com/eskpil/rockferry/internal/controller/api"
+	"github.com/eskpil/rockferry/internal/controller/runtime"
+	"github.com/eskpil/rockferry/internal/controller/store"
+	"github.com/eskpil/rockferry/pkg/rockferry"
+	"google.golang.org/grpc"
+)
+
+// A client of a controller backed by an in-memory store, served without tls.
+func newClient(t *testing.T) *rockferry.Client {
+	t.Helper()
+
+	s := store.NewMemory()
+	t.Cleanup(func() { s.Close() })
+
+	if err := controller.Initialize(s); err != nil {
+		t.Fatal(err)
+	}
+
+	service, err := api.New(runtime.New(s))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	server := grpc.NewServer()
+	controllerapi.RegisterControllerApiServer(server, service)
+
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+
+	client, err := rockferry.New(listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return client
+}
+
+func TestCreateFillsInVersion(t *testing.T) {
+	client := newClient(t)
+
+	event := rockferry.NewEvent("test", &rockferry.OwnerRef{Kind: rockferry.ResourceKindMachine, Id: "a"}, rockferry.EventTypeNormal, "Created", "")
+	if err := client.Events().Create(context.Background(), event); err != nil {
+		t.Fatal(err)
+	}
+
+	stored, err := client.Events().Get(context.Background(), event.Id, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if event.ResourceVersion == 0 || event.ResourceVersion != stored.ResourceVersion {
+		t.Fatalf("created at version %d, stored at %d", event.ResourceVersion, stored.ResourceVersion)
+	}
+}
+
+func TestEventRecorderCounts(t *testing.T) {
+	ctx := context.Background()
+	client := newClient(t)
+
+	object := &rockferry.OwnerRef{Kind: rockferry.ResourceKindMachine, Id: "a"}
+
+	// NOTE: Two recorders for the same source count on the same event, each
+	// patching the version it last saw.
+	recorders := []*rockferry.EventRecorder{client.Events().WithSource("node"), client.Events().WithSource("node")}
+
+	wg := new(sync.WaitGroup)
+	for _, recorder := range recorders {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for range 5 {
+				if err := recorder.Record(ctx, object, rockferry.EventTypeWarning, "Failed", "disk full"); err != nil {
+					t.Error(err)
+				}
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	events, err := client.Events().For(ctx, object)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(events) != 1 || events[0].Spec.Count != 10 {
+		t.Fatalf("got %d events, want one counted 10 times", len(events))
+	}
+}
@@ -16,8 +16,8 @@ func NewInterface[S any, T any](kind ResourceKind, t *Transport) *Interface[S, T
 	return i
 }
 
-func (i *Interface[S, T]) List(ctx context.Context, id string, owner *OwnerRef) ([]*Resource[S, T], error) {
-	in, err := i.t.List(ctx, i.kind, id, owner)
+func (i *Interface[S, T]) List(ctx context.Context, id string, owner *OwnerRef, opts ...ListOption) ([]*Resource[S, T], error) {
+	in, err := i.t.List(ctx, i.kind, id, owner, opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -31,12 +31,17 @@ func (i *Interface[S, T]) List(ctx context.Context, id string, owner *OwnerRef)
 	return out, nil
 }
 
-func (i *Interface[S, T]) Get(ctx context.Context, id string, owner *OwnerRef) (*Resource[S, T], error) {
-	list, err := i.List(ctx, id, owner)
+func (i *Interface[S, T]) Get(ctx context.Context, id string, owner *OwnerRef, opts ...ListOption) (*Resource[S, T], error) {
+	list, err := i.List(ctx, id, owner, opts...)
 	if err != nil {
 		return nil, err
 	}
 
+	// NOTE: Selectors can filter out every resource.
+	if len(list) == 0 {
+		return nil, ErrorNotFound
+	}
+
 	if len(list) > 1 {
 		return nil, ErrorUnexpectedResults
 	}
@@ -44,19 +49,43 @@ func (i *Interface[S, T]) Get(ctx context.Context, id string, owner *OwnerRef) (
 	return list[0], nil
 }
 
-func (i *Interface[S, T]) Watch(ctx context.Context, action WatchAction, id string, owner *OwnerRef) (chan *WatchEvent[S, T], error) {
-	in, err := i.t.Watch(ctx, action, i.kind, id, owner)
+func (i *Interface[S, T]) Watch(ctx context.Context, action WatchAction, id string, owner *OwnerRef, opts ...ListOption) (chan *WatchEvent[S, T], error) {
+	in, err := i.t.Watch(ctx, action, i.kind, id, owner, opts...)
 	if err != nil {
 		return nil, err
 	}
 
+	return castEvents[S, T](in), nil
+}
+
+// ListAndWatch returns the current resources and a stream of every change made to
+// them afterwards, see Transport.ListAndWatch.
+func (i *Interface[S, T]) ListAndWatch(ctx context.Context, owner *OwnerRef, opts ...ListOption) ([]*Resource[S, T], chan *WatchEvent[S, T], error) {
+	in, stream, err := i.t.ListAndWatch(ctx, i.kind, owner, opts...)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	out := make([]*Resource[S, T], len(in))
+	for idx, unmapped := range in {
+		out[idx] = Cast[S, T](unmapped)
+	}
+
+	return out, castEvents[S, T](stream), nil
+}
+
+func castEvents[S any, T any](in chan *WatchEvent[any, any]) chan *WatchEvent[S, T] {
 	out := make(chan *WatchEvent[S, T])
 
 	go func() {
-		for {
-			unmapped := <-in
+		defer close(out)
 
+		for unmapped := range in {
 			mapped := new(WatchEvent[S, T])
+			mapped.Action = unmapped.Action
+			mapped.Revision = unmapped.Revision
+			mapped.SpecChanged = unmapped.SpecChanged
+			mapped.StatusChanged = unmapped.StatusChanged
 			mapped.Resource = Cast[S, T](unmapped.Resource)
 			if unmapped.Prev != nil {
 				mapped.Prev = Cast[S, T](unmapped.Prev)
@@ -66,15 +95,51 @@ func (i *Interface[S, T]) Watch(ctx context.Context, action WatchAction, id stri
 		}
 	}()
 
-	return out, nil
+	return out
 }
 
+// Patch sends the difference between original and modified to the controller.
+// Changes to the status and phase are left out, use PatchStatus for those.
+// The patch is only applied if the resource is still at the version of original,
+// otherwise ErrorConflict is returned. On success modified carries the new version
+// and generation.
 func (i *Interface[S, T]) Patch(ctx context.Context, original *Resource[S, T], modified *Resource[S, T]) error {
-	return i.t.Patch(ctx, original.Generic(), modified.Generic())
+	generic := modified.Generic()
+	if err := i.t.Patch(ctx, original.Generic(), generic); err != nil {
+		return err
+	}
+
+	modified.ResourceVersion = generic.ResourceVersion
+	modified.Generation = generic.Generation
+
+	return nil
 }
 
+// PatchStatus is like Patch, but only sends the changes to the status and phase.
+func (i *Interface[S, T]) PatchStatus(ctx context.Context, original *Resource[S, T], modified *Resource[S, T]) error {
+	generic := modified.Generic()
+	if err := i.t.PatchStatus(ctx, original.Generic(), generic); err != nil {
+		return err
+	}
+
+	modified.ResourceVersion = generic.ResourceVersion
+	modified.Generation = generic.Generation
+
+	return nil
+}
+
+// Create stores res, filling in the id it was created with and its version.
 func (i *Interface[S, T]) Create(ctx context.Context, res *Resource[S, T]) error {
-	return i.t.Create(ctx, res.Generic())
+	generic := res.Generic()
+	if err := i.t.Create(ctx, generic); err != nil {
+		return err
+	}
+
+	res.Id = generic.Id
+	res.ResourceVersion = generic.ResourceVersion
+	res.Generation = generic.Generation
+
+	return nil
 }
 
 func (i *Interface[S, T]) Delete(ctx context.Context, id string) error {
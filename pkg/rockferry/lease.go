@@ -0,0 +1,23 @@
+package rockferry
+
+import (
+	"context"
+
+	"github.com/eskpil/rockferry/controllerapi"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// RenewLease tells the controller the node agent is alive. Nodes which stop
+// renewing their lease are marked as not ready.
+func (c *Client) RenewLease(ctx context.Context, nodeId string) error {
+	req := new(controllerapi.RenewLeaseRequest)
+	req.NodeId = nodeId
+
+	_, err := c.t.C().RenewLease(ctx, req)
+	if status.Code(err) == codes.NotFound {
+		return ErrorNotFound
+	}
+
+	return err
+}
@@ -0,0 +1,93 @@
+package rockferry
+
+import (
+	"context"
+
+	"github.com/eskpil/rockferry/controllerapi"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// A controller taking part in the etcd cluster.
+type Member struct {
+	Id uint64 `json:"id"`
+	// Empty until the member has started.
+	Name       string   `json:"name"`
+	PeerUrls   []string `json:"peer_urls"`
+	ClientUrls []string `json:"client_urls"`
+}
+
+func mapMember(m *controllerapi.Member) *Member {
+	out := new(Member)
+	out.Id = m.Id
+	out.Name = m.Name
+	out.PeerUrls = m.PeerUrls
+	out.ClientUrls = m.ClientUrls
+	return out
+}
+
+func mapMembers(members []*controllerapi.Member) []*Member {
+	out := make([]*Member, len(members))
+	for i, m := range members {
+		out[i] = mapMember(m)
+	}
+
+	return out
+}
+
+type Status struct {
+	// The controller answering.
+	Identity string `json:"identity"`
+	// Runs the background loops, empty while there is none.
+	Leader string `json:"leader"`
+}
+
+func (c *Client) Status(ctx context.Context) (*Status, error) {
+	res, err := c.t.A().Status(ctx, new(controllerapi.StatusRequest))
+	if err != nil {
+		return nil, err
+	}
+
+	status := new(Status)
+	status.Identity = res.Identity
+	status.Leader = res.Leader
+
+	return status, nil
+}
+
+func (c *Client) Members(ctx context.Context) ([]*Member, error) {
+	res, err := c.t.A().ListMembers(ctx, new(controllerapi.ListMembersRequest))
+	if err != nil {
+		return nil, err
+	}
+
+	return mapMembers(res.Members), nil
+}
+
+// AddMember announces a controller which is about to join the cluster. Returns
+// the member along with every member of the cluster, which the joining
+// controller needs to start.
+func (c *Client) AddMember(ctx context.Context, peerUrls []string, joinToken string) (*Member, []*Member, error) {
+	req := new(controllerapi.AddMemberRequest)
+	req.PeerUrls = peerUrls
+	req.JoinToken = joinToken
+
+	res, err := c.t.A().AddMember(ctx, req)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return mapMember(res.Member), mapMembers(res.Members), nil
+}
+
+func (c *Client) RemoveMember(ctx context.Context, id uint64) error {
+	req := new(controllerapi.RemoveMemberRequest)
+	req.Id = id
+
+	_, err := c.t.A().RemoveMember(ctx, req)
+	if status.Code(err) == codes.NotFound {
+		return ErrorNotFound
+	}
+
+	return err
+}
@@ -0,0 +1,103 @@
+package rockferry
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/eskpil/rockferry/pkg/rockferry/spec"
+)
+
+// The kinds whose reconcilers report the generation they acted on. Waiting for
+// any other kind would never end.
+var reconciledKinds = map[ResourceKind]bool{
+	ResourceKindMachine:          true,
+	ResourceKindMachineRequest:   true,
+	ResourceKindMachineMigration: true,
+	ResourceKindStorageVolume:    true,
+	ResourceKindClusterRequest:   true,
+}
+
+// The parts of the status every kind shares, read from a status of any kind.
+type observedStatus struct {
+	ObservedGeneration int64           `json:"observed_generation"`
+	Conditions         spec.Conditions `json:"conditions"`
+}
+
+// Reports whether the reconciler has acted on generation of the resource, the
+// error tells why it failed to.
+func observedGeneration[S any, T any](resource *Resource[S, T], generation int64) (bool, error) {
+	bytes, err := json.Marshal(resource.Status)
+	if err != nil {
+		return false, err
+	}
+
+	status := new(observedStatus)
+	if err := json.Unmarshal(bytes, status); err != nil {
+		return false, err
+	}
+
+	if status.ObservedGeneration < generation {
+		return false, nil
+	}
+
+	reconciled := status.Conditions.Get(spec.ConditionReconciled)
+	if reconciled != nil && reconciled.Status == spec.ConditionStatusFalse && reconciled.ObservedGeneration >= generation {
+		return true, fmt.Errorf("%w: %s", ErrorReconcileFailed, reconciled.Message)
+	}
+
+	return true, nil
+}
+
+// WaitForObserved blocks until the reconciler of the resource has acted on
+// generation of its spec, usually the generation Patch leaves in the modified
+// resource, and returns the resource as it was then. Fails with
+// ErrorReconcileFailed if the reconciler could not act on it and with
+// ErrorNotFound if the resource is deleted. Kinds nothing reconciles fail with
+// ErrorNotReconciled right away. Cancel ctx to stop waiting, a reconciler which
+// is down keeps it waiting otherwise.
+func (i *Interface[S, T]) WaitForObserved(ctx context.Context, id string, generation int64) (*Resource[S, T], error) {
+	if !reconciledKinds[i.kind] {
+		return nil, fmt.Errorf("%w: %s", ErrorNotReconciled, i.kind)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// NOTE: Watched before the resource is read, so no change is missed in between.
+	stream, err := i.Watch(ctx, WatchActionAll, id, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resource, err := i.Get(ctx, id, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		observed, err := observedGeneration(resource, generation)
+		if observed || err != nil {
+			return resource, err
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case event, ok := <-stream:
+			if !ok {
+				return nil, ErrorStreamClosed
+			}
+
+			if event.Resource == nil || event.Resource.Id != id {
+				continue
+			}
+
+			if event.Action == WatchActionDelete {
+				return nil, ErrorNotFound
+			}
+
+			resource = event.Resource
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package rockferry_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/eskpil/rockferry/pkg/rockferry"
+)
+
+func TestWaitForObservedUnreconciledKind(t *testing.T) {
+	client := newClient(t)
+
+	node := new(rockferry.Node)
+	node.Id = "a"
+	node.Kind = rockferry.ResourceKindNode
+	node.Phase = rockferry.PhaseCreated
+
+	if err := client.Nodes().Create(context.Background(), node); err != nil {
+		t.Fatal(err)
+	}
+
+	// NOTE: Without the check this would only end with the deadline.
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if _, err := client.Nodes().WaitForObserved(ctx, node.Id, node.Generation); !errors.Is(err, rockferry.ErrorNotReconciled) {
+		t.Fatalf("waiting for a node gave %v, want %v", err, rockferry.ErrorNotReconciled)
+	}
+}
+
+func TestWaitForObserved(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	client := newClient(t)
+
+	machine := new(rockferry.Machine)
+	machine.Id = "a"
+	machine.Kind = rockferry.ResourceKindMachine
+	machine.Phase = rockferry.PhaseCreated
+
+	if err := client.Machines().Create(ctx, machine); err != nil {
+		t.Fatal(err)
+	}
+
+	// Acts on the machine once it is being waited for.
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+
+		modified := *machine
+		modified.Status.ObservedGeneration = machine.Generation
+
+		if err := client.Machines().PatchStatus(ctx, machine, &modified); err != nil {
+			t.Error(err)
+		}
+	}()
+
+	observed, err := client.Machines().WaitForObserved(ctx, machine.Id, machine.Generation)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if observed.Status.ObservedGeneration != machine.Generation {
+		t.Fatalf("observed generation %d, want %d", observed.Status.ObservedGeneration, machine.Generation)
+	}
+}
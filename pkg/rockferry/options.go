@@ -0,0 +1,87 @@
+package rockferry
+
+import "crypto/tls"
+
+type ListOptions struct {
+	LabelSelector string
+	FieldSelector string
+}
+
+// Narrows down the resources returned by List, Get and Watch.
+type ListOption func(*ListOptions)
+
+// Only return resources whose annotations match selector, see Selector.
+func WithLabelSelector(selector string) ListOption {
+	return func(o *ListOptions) {
+		o.LabelSelector = selector
+	}
+}
+
+// Only return resources whose fields match selector, see ParseFieldSelector.
+func WithFieldSelector(selector string) ListOption {
+	return func(o *ListOptions) {
+		o.FieldSelector = selector
+	}
+}
+
+func collectListOptions(opts []ListOption) *ListOptions {
+	o := new(ListOptions)
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	return o
+}
+
+type ClientOptions struct {
+	// Connects without tls when nil.
+	TLS          *tls.Config
+	Certificates []tls.Certificate
+	// Sent as a bearer token with every call.
+	Token string
+}
+
+// Changes how New connects to the controller.
+type ClientOption func(*ClientOptions)
+
+// Connects with tls, the controller is verified against the roots of config.
+func WithTLS(config *tls.Config) ClientOption {
+	return func(o *ClientOptions) {
+		o.TLS = config
+	}
+}
+
+// Presents cert to the controller, connects with tls using the system roots
+// unless WithTLS is given as well.
+func WithClientCertificate(cert tls.Certificate) ClientOption {
+	return func(o *ClientOptions) {
+		o.Certificates = append(o.Certificates, cert)
+	}
+}
+
+// Authenticates with an id token from the OpenID Connect provider of the
+// controller. Tokens are only sent over tls.
+func WithBearerToken(token string) ClientOption {
+	return func(o *ClientOptions) {
+		o.Token = token
+	}
+}
+
+func collectClientOptions(opts []ClientOption) *ClientOptions {
+	o := new(ClientOptions)
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	if len(o.Certificates) > 0 {
+		if o.TLS == nil {
+			o.TLS = new(tls.Config)
+		} else {
+			o.TLS = o.TLS.Clone()
+		}
+
+		o.TLS.Certificates = append(o.TLS.Certificates, o.Certificates...)
+	}
+
+	return o
+}
@@ -2,14 +2,20 @@ package rockferry
 
 import (
 	"encoding/json"
+	"slices"
+	"time"
 
 	"github.com/eskpil/rockferry/controllerapi"
 	"github.com/eskpil/rockferry/pkg/convert"
+	"github.com/eskpil/rockferry/pkg/rockferry/spec"
 	"google.golang.org/protobuf/types/known/structpb"
 )
 
 type DefaultStatus struct {
-	Error *string `json:"error"`
+	Error      *string         `json:"error"`
+	Conditions spec.Conditions `json:"conditions"`
+
+	ObservedGeneration int64 `json:"observed_generation"`
 }
 
 type ResourceKind = string
@@ -25,8 +31,23 @@ const (
 	ResourceKindInstance       = "instance"
 	ResourceKindClusterRequest = "clusterrequest"
 	ResourceKindCluster        = "cluster"
+
+	ResourceKindMachineMigration = "machinemigration"
+	ResourceKindBootstrapToken   = "bootstraptoken"
+	ResourceKindRole             = "role"
+	ResourceKindRoleBinding      = "rolebinding"
+	ResourceKindAuditEvent       = "auditevent"
+	ResourceKindEvent            = "event"
 )
 
+// Added to resources which exist on a node, such as machines and volumes. The
+// node removes it once the resource has been cleaned up there.
+const FinalizerNode = "rockferry.node/cleanup"
+
+// Set by the controller to whoever created the resource, it can not be changed
+// afterwards.
+const AnnotationCreator = "rockferry.creator"
+
 type Phase string
 
 const (
@@ -57,6 +78,25 @@ type Resource[Spec any, Status any] struct {
 	Status      Status            `json:"status"`
 	Phase       Phase             `json:"phase"`
 
+	// Other resources this one was created for. Once any of them, or the owner,
+	// is deleted the resource is garbage collected as well.
+	Parents []*OwnerRef `json:"parents,omitempty"`
+
+	// A resource with finalizers is not removed when it is deleted, instead the
+	// deletion timestamp is set. Whoever added a finalizer is expected to clean
+	// up and remove it again, the resource is gone once none are left.
+	Finalizers        []string   `json:"finalizers,omitempty"`
+	DeletionTimestamp *time.Time `json:"deletion_timestamp,omitempty"`
+
+	// The etcd mod revision of the resource. It is filled in by the controller
+	// whenever a resource is read and is used to detect concurrent writes.
+	ResourceVersion int64 `json:"resource_version,omitempty"`
+
+	// Starts at one and is bumped by the controller whenever the spec changes,
+	// whatever clients send is ignored. Reconcilers record the generation they
+	// acted on as the observed generation in the status.
+	Generation int64 `json:"generation,omitempty"`
+
 	RawSpec   *structpb.Struct `json:"-"`
 	RawStatus *structpb.Struct `json:"-"`
 }
@@ -70,6 +110,29 @@ func (r *Resource[T, S]) Merge(with *Resource[T, S]) {
 	for k, v := range with.Annotations {
 		r.Annotations[k] = v
 	}
+
+	// NOTE: These are never known locally, keep them so patches leave them alone.
+	r.Parents = with.Parents
+	r.Finalizers = with.Finalizers
+	r.DeletionTimestamp = with.DeletionTimestamp
+	r.ResourceVersion = with.ResourceVersion
+	r.Generation = with.Generation
+}
+
+func (r *Resource[T, S]) HasFinalizer(finalizer string) bool {
+	return slices.Contains(r.Finalizers, finalizer)
+}
+
+func (r *Resource[T, S]) RemoveFinalizer(finalizer string) {
+	r.Finalizers = slices.DeleteFunc(slices.Clone(r.Finalizers), func(f string) bool {
+		return f == finalizer
+	})
+}
+
+// Reports whether deletion has been requested, the resource is kept around
+// until its finalizers are gone.
+func (r *Resource[T, S]) Deleting() bool {
+	return r.DeletionTimestamp != nil
 }
 
 func (r *Resource[T, S]) Generic() *Resource[any, any] {
@@ -85,6 +148,13 @@ func (r *Resource[T, S]) Generic() *Resource[any, any] {
 		Owner:       r.Owner,
 		Spec:        &spec, // Store spec as interface{}
 		Status:      status,
+
+		Parents:           r.Parents,
+		Finalizers:        r.Finalizers,
+		DeletionTimestamp: r.DeletionTimestamp,
+
+		ResourceVersion: r.ResourceVersion,
+		Generation:      r.Generation,
 	}
 }
 
@@ -100,6 +170,8 @@ func (r *Resource[T, S]) Transport() (*controllerapi.Resource, error) {
 	out.Kind = string(r.Kind)
 	out.Annotations = r.Annotations
 	out.Phase = string(r.Phase)
+	out.ResourceVersion = r.ResourceVersion
+	out.Generation = r.Generation
 
 	if r.Owner != nil {
 		out.Owner = new(controllerapi.Owner)
@@ -107,6 +179,20 @@ func (r *Resource[T, S]) Transport() (*controllerapi.Resource, error) {
 		out.Owner.Kind = r.Owner.Kind
 	}
 
+	for _, parent := range r.Parents {
+		p := new(controllerapi.Owner)
+		p.Id = parent.Id
+		p.Kind = parent.Kind
+		out.Parents = append(out.Parents, p)
+	}
+
+	out.Finalizers = r.Finalizers
+
+	if r.DeletionTimestamp != nil {
+		out.DeletionTimestamp = new(string)
+		*out.DeletionTimestamp = r.DeletionTimestamp.Format(time.RFC3339Nano)
+	}
+
 	spec, err := convert.Outgoing(&r.Spec)
 	if err != nil {
 		return nil, err
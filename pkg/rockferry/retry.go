@@ -0,0 +1,35 @@
+package rockferry
+
+import (
+	"context"
+	"time"
+)
+
+const (
+	retryOnConflictAttempts = 5
+	retryOnConflictBackoff  = 50 * time.Millisecond
+)
+
+// RetryOnConflict calls fn until it no longer fails with ErrorConflict. fn is
+// expected to fetch the latest version of the resource and reapply its changes
+// on every call, otherwise it will keep conflicting.
+func RetryOnConflict(ctx context.Context, fn func() error) error {
+	backoff := retryOnConflictBackoff
+
+	var err error
+	for range retryOnConflictAttempts {
+		err = fn()
+		if err != ErrorConflict {
+			return err
+		}
+
+		select {
+		case <-time.After(backoff):
+			backoff *= 2
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	return err
+}
@@ -15,54 +15,71 @@ const (
 )
 
 type Generic = Resource[any, any]
-type MachineRequest = Resource[spec.MachineRequestSpec, DefaultStatus]
+type MachineRequest = Resource[spec.MachineRequestSpec, spec.MachineRequestStatus]
 type StorageVolume = Resource[spec.StorageVolumeSpec, DefaultStatus]
 type StoragePool = Resource[spec.StoragePoolSpec, DefaultStatus]
-type Node = Resource[spec.NodeSpec, DefaultStatus]
+type Node = Resource[spec.NodeSpec, spec.NodeStatus]
 type Network = Resource[spec.NetworkSpec, DefaultStatus]
 type Machine = Resource[spec.MachineSpec, spec.MachineStatus]
 type Instance = Resource[spec.InstanceSpec, DefaultStatus]
 type ClusterRequest = Resource[spec.ClusterRequestSpec, DefaultStatus]
 type Cluster = Resource[spec.ClusterSpec, spec.ClusterStatus]
+type MachineMigration = Resource[spec.MachineMigrationSpec, spec.MachineMigrationStatus]
+type BootstrapToken = Resource[spec.BootstrapTokenSpec, DefaultStatus]
+type Role = Resource[spec.RoleSpec, DefaultStatus]
+type RoleBinding = Resource[spec.RoleBindingSpec, DefaultStatus]
+type AuditEvent = Resource[spec.AuditEventSpec, DefaultStatus]
+type Event = Resource[spec.EventSpec, DefaultStatus]
 
 type Client struct {
 	c *controllerapi.ControllerApiClient
 	t *Transport
 
-	nodesv1            *Interface[spec.NodeSpec, DefaultStatus]
+	nodesv1            *Interface[spec.NodeSpec, spec.NodeStatus]
 	storagevolumesv1   *Interface[spec.StorageVolumeSpec, DefaultStatus]
 	machinesv1         *Interface[spec.MachineSpec, spec.MachineStatus]
-	machinesrequestsv1 *Interface[spec.MachineRequestSpec, DefaultStatus]
+	machinesrequestsv1 *Interface[spec.MachineRequestSpec, spec.MachineRequestStatus]
 	networksv1         *Interface[spec.NetworkSpec, DefaultStatus]
 	storagepoolsv1     *Interface[spec.StoragePoolSpec, DefaultStatus]
 	instancev1         *Interface[spec.InstanceSpec, DefaultStatus]
 	clustersrequestsv1 *Interface[spec.ClusterRequestSpec, DefaultStatus]
 	clustersv1         *Interface[spec.ClusterSpec, spec.ClusterStatus]
+	migrationsv1       *Interface[spec.MachineMigrationSpec, spec.MachineMigrationStatus]
+	bootstraptokensv1  *Interface[spec.BootstrapTokenSpec, DefaultStatus]
+	rolesv1            *Interface[spec.RoleSpec, DefaultStatus]
+	rolebindingsv1     *Interface[spec.RoleBindingSpec, DefaultStatus]
+	eventsv1           *EventRecorder
 }
 
-func New(url string) (*Client, error) {
-	transport, err := NewTransport(url)
+// New connects to the controller at url, without tls unless told otherwise by opts.
+func New(url string, opts ...ClientOption) (*Client, error) {
+	transport, err := NewTransport(url, opts...)
 	if err != nil {
 		return nil, err
 	}
 
 	return &Client{
-		nodesv1:            NewInterface[spec.NodeSpec, DefaultStatus](ResourceKindNode, transport),
+		nodesv1:            NewInterface[spec.NodeSpec, spec.NodeStatus](ResourceKindNode, transport),
 		storagevolumesv1:   NewInterface[spec.StorageVolumeSpec, DefaultStatus](ResourceKindStorageVolume, transport),
 		machinesv1:         NewInterface[spec.MachineSpec, spec.MachineStatus](ResourceKindMachine, transport),
-		machinesrequestsv1: NewInterface[spec.MachineRequestSpec, DefaultStatus](ResourceKindMachineRequest, transport),
+		machinesrequestsv1: NewInterface[spec.MachineRequestSpec, spec.MachineRequestStatus](ResourceKindMachineRequest, transport),
 		networksv1:         NewInterface[spec.NetworkSpec, DefaultStatus](ResourceKindNetwork, transport),
 		storagepoolsv1:     NewInterface[spec.StoragePoolSpec, DefaultStatus](ResourceKindStoragePool, transport),
 		instancev1:         NewInterface[spec.InstanceSpec, DefaultStatus](ResourceKindInstance, transport),
 
 		clustersrequestsv1: NewInterface[spec.ClusterRequestSpec, DefaultStatus](ResourceKindClusterRequest, transport),
 		clustersv1:         NewInterface[spec.ClusterSpec, spec.ClusterStatus](ResourceKindCluster, transport),
+		migrationsv1:       NewInterface[spec.MachineMigrationSpec, spec.MachineMigrationStatus](ResourceKindMachineMigration, transport),
+		bootstraptokensv1:  NewInterface[spec.BootstrapTokenSpec, DefaultStatus](ResourceKindBootstrapToken, transport),
+		rolesv1:            NewInterface[spec.RoleSpec, DefaultStatus](ResourceKindRole, transport),
+		rolebindingsv1:     NewInterface[spec.RoleBindingSpec, DefaultStatus](ResourceKindRoleBinding, transport),
+		eventsv1:           NewEventRecorder(NewInterface[spec.EventSpec, DefaultStatus](ResourceKindEvent, transport), ""),
 
 		t: transport,
 	}, nil
 }
 
-func (c *Client) Nodes() *Interface[spec.NodeSpec, DefaultStatus] {
+func (c *Client) Nodes() *Interface[spec.NodeSpec, spec.NodeStatus] {
 	return c.nodesv1
 }
 
@@ -78,7 +95,7 @@ func (c *Client) Machines() *Interface[spec.MachineSpec, spec.MachineStatus] {
 	return c.machinesv1
 }
 
-func (c *Client) MachineRequests() *Interface[spec.MachineRequestSpec, DefaultStatus] {
+func (c *Client) MachineRequests() *Interface[spec.MachineRequestSpec, spec.MachineRequestStatus] {
 	return c.machinesrequestsv1
 }
 
@@ -97,3 +114,24 @@ func (c *Client) Resource() *Interface[spec.InstanceSpec, DefaultStatus] {
 func (c *Client) ClusterRequest() *Interface[spec.ClusterRequestSpec, DefaultStatus] {
 	return c.clustersrequestsv1
 }
+
+func (c *Client) MachineMigrations() *Interface[spec.MachineMigrationSpec, spec.MachineMigrationStatus] {
+	return c.migrationsv1
+}
+
+func (c *Client) BootstrapTokens() *Interface[spec.BootstrapTokenSpec, DefaultStatus] {
+	return c.bootstraptokensv1
+}
+
+func (c *Client) Roles() *Interface[spec.RoleSpec, DefaultStatus] {
+	return c.rolesv1
+}
+
+func (c *Client) RoleBindings() *Interface[spec.RoleBindingSpec, DefaultStatus] {
+	return c.rolebindingsv1
+}
+
+// Events records events as coming from no one in particular, see EventRecorder.WithSource.
+func (c *Client) Events() *EventRecorder {
+	return c.eventsv1
+}
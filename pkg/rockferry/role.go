@@ -0,0 +1,77 @@
+package rockferry
+
+import "github.com/eskpil/rockferry/pkg/rockferry/spec"
+
+// What a role rule can grant. Reading a single resource is listing it.
+const (
+	VerbList   = "list"
+	VerbWatch  = "watch"
+	VerbCreate = "create"
+	VerbPatch  = "patch"
+	VerbDelete = "delete"
+
+	VerbAll = "*"
+	KindAll = "*"
+)
+
+var Verbs = []string{VerbList, VerbWatch, VerbCreate, VerbPatch, VerbDelete}
+
+// Builtin roles, bindings refer to them by name. The controller binds the
+// certificates it issues to them, admins are operators and nodes are nodes.
+const (
+	// Manages everything, including the members of the cluster.
+	RoleOperator = "rockferry:operator"
+	// What a node agent needs to run the resources of its node.
+	RoleNode = "rockferry:node"
+	// Requests machines and manages those it requested.
+	RoleTenant = "rockferry:tenant"
+)
+
+var BuiltinRoles = map[string]*spec.RoleSpec{
+	RoleOperator: {
+		Rules: []*spec.RoleRule{
+			{Verbs: []string{VerbAll}, Kinds: []string{KindAll}},
+		},
+	},
+	RoleNode: {
+		Rules: []*spec.RoleRule{
+			// NOTE: Migrations need to know where the target node is.
+			{Verbs: []string{VerbList, VerbWatch}, Kinds: []string{ResourceKindNode}},
+			{
+				Verbs: []string{VerbAll},
+				Kinds: []string{
+					ResourceKindNode,
+					ResourceKindMachine,
+					ResourceKindMachineRequest,
+					ResourceKindMachineMigration,
+					ResourceKindStoragePool,
+					ResourceKindStorageVolume,
+					ResourceKindNetwork,
+					ResourceKindEvent,
+				},
+				Scope: spec.RoleScopeNode,
+			},
+		},
+	},
+	RoleTenant: {
+		Rules: []*spec.RoleRule{
+			// NOTE: Requests refer to them by id.
+			{Verbs: []string{VerbList, VerbWatch}, Kinds: []string{ResourceKindNetwork, ResourceKindStoragePool}},
+			{
+				Verbs: []string{VerbAll},
+				Kinds: []string{ResourceKindMachineRequest, ResourceKindClusterRequest},
+				Scope: spec.RoleScopeOwn,
+			},
+			{
+				Verbs: []string{VerbList, VerbWatch, VerbPatch, VerbDelete},
+				Kinds: []string{ResourceKindMachine, ResourceKindCluster},
+				Scope: spec.RoleScopeOwn,
+			},
+			{
+				Verbs: []string{VerbList, VerbWatch},
+				Kinds: []string{ResourceKindStorageVolume, ResourceKindEvent},
+				Scope: spec.RoleScopeOwn,
+			},
+		},
+	},
+}
@@ -0,0 +1,256 @@
+package rockferry
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+type SelectorOperator string
+
+const (
+	SelectorOperatorEquals       SelectorOperator = "="
+	SelectorOperatorNotEquals    SelectorOperator = "!="
+	SelectorOperatorIn           SelectorOperator = "in"
+	SelectorOperatorNotIn        SelectorOperator = "notin"
+	SelectorOperatorExists       SelectorOperator = "exists"
+	SelectorOperatorDoesNotExist SelectorOperator = "!"
+)
+
+// The fields which can be used in a field selector, see FieldEventObjectKind
+// for events as well.
+const (
+	FieldPhase    = "phase"
+	FieldSpecName = "spec.name"
+)
+
+var fieldKeys = []string{FieldPhase, FieldSpecName, FieldEventObjectKind, FieldEventObjectId}
+
+type Requirement struct {
+	Key      string
+	Operator SelectorOperator
+	Values   []string
+}
+
+func (r *Requirement) Matches(value string, exists bool) bool {
+	switch r.Operator {
+	case SelectorOperatorEquals:
+		return exists && value == r.Values[0]
+	case SelectorOperatorNotEquals:
+		return !exists || value != r.Values[0]
+	case SelectorOperatorIn:
+		return exists && slices.Contains(r.Values, value)
+	case SelectorOperatorNotIn:
+		return !exists || !slices.Contains(r.Values, value)
+	case SelectorOperatorExists:
+		return exists
+	case SelectorOperatorDoesNotExist:
+		return !exists
+	}
+
+	return false
+}
+
+func (r *Requirement) String() string {
+	switch r.Operator {
+	case SelectorOperatorIn, SelectorOperatorNotIn:
+		return fmt.Sprintf("%s %s (%s)", r.Key, r.Operator, strings.Join(r.Values, ","))
+	case SelectorOperatorExists:
+		return r.Key
+	case SelectorOperatorDoesNotExist:
+		return "!" + r.Key
+	}
+
+	return r.Key + string(r.Operator) + r.Values[0]
+}
+
+// A selector matches when all of its requirements match, an empty selector
+// matches everything. Selectors are written as a comma separated list of
+// requirements:
+//
+//	key=value, key!=value, key in (a,b), key notin (a,b), key, !key
+type Selector []*Requirement
+
+func (s Selector) String() string {
+	requirements := make([]string, len(s))
+	for i, r := range s {
+		requirements[i] = r.String()
+	}
+
+	return strings.Join(requirements, ",")
+}
+
+// Matches the selector against the annotations of a resource.
+func (s Selector) MatchesLabels(labels map[string]string) bool {
+	for _, r := range s {
+		value, exists := labels[r.Key]
+		if !r.Matches(value, exists) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Matches the selector against the fields of a resource, see ParseFieldSelector.
+func (s Selector) MatchesFields(resource *Generic) bool {
+	for _, r := range s {
+		value, exists := resourceField(resource, r.Key)
+		if !r.Matches(value, exists) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// NOTE: Resources decoded by the controller carry their spec as a map, those
+// mapped from the api as a pointer to one.
+func specMap(resource *Generic) (map[string]any, bool) {
+	spec := resource.Spec
+	if p, ok := spec.(*any); ok && p != nil {
+		spec = *p
+	}
+
+	m, ok := spec.(map[string]any)
+	return m, ok
+}
+
+func resourceField(resource *Generic, key string) (string, bool) {
+	switch key {
+	case FieldPhase:
+		return string(resource.Phase), true
+	case FieldSpecName:
+		spec, ok := specMap(resource)
+		if !ok {
+			return "", false
+		}
+
+		name, ok := spec["name"].(string)
+		return name, ok
+	case FieldEventObjectKind, FieldEventObjectId:
+		spec, ok := specMap(resource)
+		if !ok {
+			return "", false
+		}
+
+		object, ok := spec["object"].(map[string]any)
+		if !ok {
+			return "", false
+		}
+
+		value, ok := object[strings.TrimPrefix(key, "spec.object.")].(string)
+		return value, ok
+	}
+
+	return "", false
+}
+
+// Parses a label selector, which is matched against the annotations of a resource.
+func ParseSelector(in string) (Selector, error) {
+	selector := Selector{}
+
+	for _, term := range splitTerms(in) {
+		if term == "" {
+			continue
+		}
+
+		requirement, err := parseRequirement(term)
+		if err != nil {
+			return nil, err
+		}
+
+		selector = append(selector, requirement)
+	}
+
+	return selector, nil
+}
+
+// Parses a field selector. Only phase, spec.name and the object of events are
+// supported and existence checks are not allowed.
+func ParseFieldSelector(in string) (Selector, error) {
+	selector, err := ParseSelector(in)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, r := range selector {
+		if !slices.Contains(fieldKeys, r.Key) {
+			return nil, fmt.Errorf("%w: unsupported field %q", ErrorInvalidSelector, r.Key)
+		}
+
+		if r.Operator == SelectorOperatorExists || r.Operator == SelectorOperatorDoesNotExist {
+			return nil, fmt.Errorf("%w: field %q does not support existence checks", ErrorInvalidSelector, r.Key)
+		}
+	}
+
+	return selector, nil
+}
+
+// Splits on commas which are not part of a value list.
+func splitTerms(in string) []string {
+	terms := []string{}
+	depth := 0
+	start := 0
+
+	for i, c := range in {
+		switch c {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case ',':
+			if depth == 0 {
+				terms = append(terms, strings.TrimSpace(in[start:i]))
+				start = i + 1
+			}
+		}
+	}
+
+	return append(terms, strings.TrimSpace(in[start:]))
+}
+
+func parseRequirement(term string) (*Requirement, error) {
+	r := new(Requirement)
+
+	if fields := strings.Fields(term); len(fields) >= 2 && (SelectorOperator(fields[1]) == SelectorOperatorIn || SelectorOperator(fields[1]) == SelectorOperatorNotIn) {
+		r.Key = fields[0]
+		r.Operator = SelectorOperator(fields[1])
+
+		list := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(term[len(fields[0]):]), fields[1]))
+		if !strings.HasPrefix(list, "(") || !strings.HasSuffix(list, ")") {
+			return nil, fmt.Errorf("%w: expected a value list in %q", ErrorInvalidSelector, term)
+		}
+
+		for _, value := range strings.Split(list[1:len(list)-1], ",") {
+			if value = strings.TrimSpace(value); value != "" {
+				r.Values = append(r.Values, value)
+			}
+		}
+
+		if len(r.Values) == 0 {
+			return nil, fmt.Errorf("%w: empty value list in %q", ErrorInvalidSelector, term)
+		}
+	} else if key, ok := strings.CutPrefix(term, "!"); ok && !strings.Contains(key, "=") {
+		r.Key = strings.TrimSpace(key)
+		r.Operator = SelectorOperatorDoesNotExist
+	} else if key, value, ok := strings.Cut(term, "!="); ok {
+		r.Key = strings.TrimSpace(key)
+		r.Operator = SelectorOperatorNotEquals
+		r.Values = []string{strings.TrimSpace(value)}
+	} else if key, value, ok := strings.Cut(term, "="); ok {
+		r.Key = strings.TrimSpace(key)
+		r.Operator = SelectorOperatorEquals
+		// NOTE: Both = and == are accepted.
+		r.Values = []string{strings.TrimSpace(strings.TrimPrefix(value, "="))}
+	} else {
+		r.Key = term
+		r.Operator = SelectorOperatorExists
+	}
+
+	if r.Key == "" || strings.ContainsAny(r.Key, " \t()!=") {
+		return nil, fmt.Errorf("%w: invalid key in %q", ErrorInvalidSelector, term)
+	}
+
+	return r, nil
+}
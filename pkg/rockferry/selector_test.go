@@ -0,0 +1,61 @@
+package rockferry
+
+import "testing"
+
+func TestMatchesFieldsSpecName(t *testing.T) {
+	var mapped any = map[string]any{"name": "web"}
+
+	tests := []struct {
+		name     string
+		spec     any
+		selector string
+		want     bool
+	}{
+		{"decoded by the controller", map[string]any{"name": "web"}, "spec.name=web", true},
+		{"mapped from the api", &mapped, "spec.name=web", true},
+		{"mapped from the api, other name", &mapped, "spec.name=db", false},
+		{"mapped from the api, not equals", &mapped, "spec.name!=db", true},
+		{"without a spec", nil, "spec.name!=web", true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			selector, err := ParseFieldSelector(test.selector)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			resource := new(Generic)
+			resource.Spec = test.spec
+
+			if got := selector.MatchesFields(resource); got != test.want {
+				t.Fatalf("%s matched %v, want %v", test.selector, got, test.want)
+			}
+		})
+	}
+}
+
+func TestParseSelectorOperators(t *testing.T) {
+	tests := []struct {
+		selector string
+		want     SelectorOperator
+	}{
+		{"env=prod", SelectorOperatorEquals},
+		{"env!=prod", SelectorOperatorNotEquals},
+		{"env in (prod, dev)", SelectorOperatorIn},
+		{"env notin (prod)", SelectorOperatorNotIn},
+		{"env", SelectorOperatorExists},
+		{"!env", SelectorOperatorDoesNotExist},
+	}
+
+	for _, test := range tests {
+		selector, err := ParseSelector(test.selector)
+		if err != nil {
+			t.Fatalf("%s: %v", test.selector, err)
+		}
+
+		if len(selector) != 1 || selector[0].Key != "env" || selector[0].Operator != test.want {
+			t.Fatalf("%s parsed as %s, want operator %s", test.selector, selector, test.want)
+		}
+	}
+}
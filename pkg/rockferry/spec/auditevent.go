@@ -0,0 +1,27 @@
+package spec
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// A create, patch or delete made through one of the apis.
+type AuditEventSpec struct {
+	Time time.Time `json:"time"`
+	// Who made the call, empty when the apis are served without tls.
+	User   string   `json:"user,omitempty"`
+	Groups []string `json:"groups,omitempty"`
+	// The address the call came from.
+	Source string `json:"source,omitempty"`
+	// Either grpc or http.
+	Api  string `json:"api"`
+	Verb string `json:"verb"`
+	Kind string `json:"kind"`
+	Id   string `json:"id,omitempty"`
+	// Set to status for patches to the status subresource.
+	Subresource string `json:"subresource,omitempty"`
+	// The json patch, only set for patches.
+	Patch json.RawMessage `json:"patch,omitempty"`
+	// The grpc code or http status the call was answered with.
+	Code string `json:"code"`
+}
@@ -0,0 +1,13 @@
+package spec
+
+import "time"
+
+// Lets a single node agent register itself. Only a hash of the secret is kept,
+// the token itself is handed to the node agent, see rockferry.NewBootstrapToken.
+type BootstrapTokenSpec struct {
+	// Hex encoded sha256 of the secret.
+	SecretHash string `json:"secret_hash"`
+	// The token is refused, and removed, after it expires.
+	Expires     time.Time `json:"expires"`
+	Description string    `json:"description,omitempty"`
+}
@@ -12,6 +12,13 @@ const (
 	ClusterStatusStateUpgrading                    = "upgrading"
 )
 
+// The steps of creating a cluster, Ready is set once all of them are done.
+const (
+	ClusterConditionControlPlaneReady ConditionType = "ControlPlaneReady"
+	ClusterConditionBootstrapped      ConditionType = "Bootstrapped"
+	ClusterConditionWorkersReady      ConditionType = "WorkersReady"
+)
+
 type ClusterNodeSpec struct {
 	Kind      ClusterNodeKind `json:"kind"`
 	MachineId string          `json:"machine_id"`
@@ -30,5 +37,8 @@ type ClusterSpec struct {
 }
 
 type ClusterStatus struct {
-	State ClusterStatusState `json:"state"`
+	State      ClusterStatusState `json:"state"`
+	Conditions Conditions         `json:"conditions"`
+
+	ObservedGeneration int64 `json:"observed_generation"`
 }
@@ -0,0 +1,141 @@
+package spec
+
+import "time"
+
+type ConditionType string
+type ConditionStatus string
+
+const (
+	ConditionStatusTrue    ConditionStatus = "True"
+	ConditionStatusFalse   ConditionStatus = "False"
+	ConditionStatusUnknown ConditionStatus = "Unknown"
+)
+
+// Every kind uses Ready for whether the resource is doing what it was asked to,
+// the other types describe the steps getting there.
+const ConditionReady ConditionType = "Ready"
+
+// Reconciled is set by whoever acts on the spec of a resource, false if acting
+// on the generation it observed failed.
+const ConditionReconciled ConditionType = "Reconciled"
+
+// An aspect of the state of a resource. Unlike the phase a resource has many of
+// them, which tells a volume which was created apart from a domain which failed
+// to be defined.
+type Condition struct {
+	Type   ConditionType   `json:"type"`
+	Status ConditionStatus `json:"status"`
+
+	// A short machine readable reason, such as LeaseExpired.
+	Reason  string `json:"reason"`
+	Message string `json:"message"`
+
+	// When the status last changed, not when the condition was last set.
+	LastTransitionTime time.Time `json:"last_transition_time"`
+	// The generation of the resource the condition was set for.
+	ObservedGeneration int64 `json:"observed_generation,omitempty"`
+}
+
+func NewCondition(kind ConditionType, status ConditionStatus, reason string, message string) *Condition {
+	condition := new(Condition)
+	condition.Type = kind
+	condition.Status = status
+	condition.Reason = reason
+	condition.Message = message
+	return condition
+}
+
+// OutcomeCondition describes a step which ended with err. It is true with reason
+// if err is nil, otherwise false with failure as the reason and err as the message.
+func OutcomeCondition(kind ConditionType, err error, reason string, failure string) *Condition {
+	if err != nil {
+		return NewCondition(kind, ConditionStatusFalse, failure, err.Error())
+	}
+
+	return NewCondition(kind, ConditionStatusTrue, reason, "")
+}
+
+type Conditions []*Condition
+
+func (c Conditions) Get(kind ConditionType) *Condition {
+	for _, condition := range c {
+		if condition != nil && condition.Type == kind {
+			return condition
+		}
+	}
+
+	return nil
+}
+
+func (c Conditions) IsTrue(kind ConditionType) bool {
+	condition := c.Get(kind)
+	return condition != nil && condition.Status == ConditionStatusTrue
+}
+
+func (c Conditions) IsFalse(kind ConditionType) bool {
+	condition := c.Get(kind)
+	return condition != nil && condition.Status == ConditionStatusFalse
+}
+
+// Set replaces the condition of the same type, or adds it. The transition time
+// is kept unless the status changed. Reports whether anything but the transition
+// time changed, which saves writing a resource which would stay the same.
+//
+// NOTE: The conditions are copied rather than changed in place, copies of a
+// resource share them with the original.
+func (c *Conditions) Set(condition *Condition) bool {
+	updated := *condition
+	updated.LastTransitionTime = time.Now().UTC()
+
+	conditions := make(Conditions, 0, len(*c)+1)
+	changed := true
+	found := false
+
+	for _, existing := range *c {
+		if existing == nil || existing.Type != updated.Type {
+			conditions = append(conditions, existing)
+			continue
+		}
+
+		found = true
+
+		if existing.Status == updated.Status {
+			updated.LastTransitionTime = existing.LastTransitionTime
+		}
+
+		changed = existing.Status != updated.Status ||
+			existing.Reason != updated.Reason ||
+			existing.Message != updated.Message ||
+			existing.ObservedGeneration != updated.ObservedGeneration
+
+		conditions = append(conditions, &updated)
+	}
+
+	if !found {
+		conditions = append(conditions, &updated)
+	}
+
+	*c = conditions
+
+	return changed
+}
+
+// Remove drops the condition of the given type.
+func (c *Conditions) Remove(kind ConditionType) {
+	conditions := make(Conditions, 0, len(*c))
+	for _, existing := range *c {
+		if existing != nil && existing.Type != kind {
+			conditions = append(conditions, existing)
+		}
+	}
+
+	*c = conditions
+}
+
+// SetReconciled records whether acting on generation of the spec failed, see
+// ConditionReconciled. The status records the generation as observed as well.
+func (c *Conditions) SetReconciled(generation int64, err error) bool {
+	condition := OutcomeCondition(ConditionReconciled, err, "Reconciled", "FailedReconcile")
+	condition.ObservedGeneration = generation
+	return c.Set(condition)
+}
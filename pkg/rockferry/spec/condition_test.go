@@ -0,0 +1,40 @@
+package spec
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestConditionsSet(t *testing.T) {
+	conditions := Conditions{}
+
+	if !conditions.Set(OutcomeCondition(ConditionReady, errors.New("failed"), "Started", "Failed")) {
+		t.Fatal("adding a condition changed nothing")
+	}
+
+	if !conditions.IsFalse(ConditionReady) || conditions.Get(ConditionReady).Status != ConditionStatusFalse {
+		t.Fatal("failed outcome is not false")
+	}
+
+	transition := conditions.Get(ConditionReady).LastTransitionTime
+
+	if !conditions.Set(NewCondition(ConditionReady, ConditionStatusFalse, "Failed", "again")) {
+		t.Fatal("changing the message changed nothing")
+	}
+
+	if !conditions.Get(ConditionReady).LastTransitionTime.Equal(transition) {
+		t.Fatal("transition time moved while the status stayed the same")
+	}
+
+	if !conditions.Set(NewCondition(ConditionReady, ConditionStatusUnknown, "Lost", "")) {
+		t.Fatal("changing the status changed nothing")
+	}
+
+	if conditions.IsTrue(ConditionReady) || conditions.IsFalse(ConditionReady) || len(conditions) != 1 {
+		t.Fatal("unknown condition is true or false, or was added twice")
+	}
+
+	if conditions.Set(NewCondition(ConditionReady, ConditionStatusUnknown, "Lost", "")) {
+		t.Fatal("setting the same condition reported a change")
+	}
+}
@@ -0,0 +1,26 @@
+package spec
+
+import "time"
+
+// The resource an event is about.
+type EventObject struct {
+	Kind string `json:"kind"`
+	Id   string `json:"id"`
+}
+
+// Something which happened to a resource, such as a task failing on a node.
+// Repeats of the same event are counted instead of being stored again.
+type EventSpec struct {
+	Object EventObject `json:"object"`
+	// Either Normal or Warning.
+	Type string `json:"type"`
+	// A short machine readable reason, such as FailedCreate.
+	Reason  string `json:"reason"`
+	Message string `json:"message"`
+	// Who reported the event, a node or a controller.
+	Source string `json:"source,omitempty"`
+
+	Count     int       `json:"count"`
+	FirstSeen time.Time `json:"first_seen"`
+	LastSeen  time.Time `json:"last_seen"`
+}
@@ -1,7 +1,10 @@
 package spec
 
+import "time"
+
 type MachineStatusState string
 type MachineStatusVNCType string
+type MachineSpecPowerState string
 
 const (
 	MachineStatusStateRunning   MachineStatusState = "running"
@@ -10,9 +13,14 @@ const (
 	MachineStatusStateStopped                      = "stopped"
 	MachineStatusStateShutdown                     = "shutdown"
 	MachineStatusStateBooting                      = "booting"
+	// The node running the machine stopped reporting.
+	MachineStatusStateUnknown = "unknown"
 
 	MachineStatusVNCTypeWebsocket MachineStatusVNCType = "websocket"
 	MachineStatusVNCTypeNative                         = "native"
+
+	MachineSpecPowerStateOn  MachineSpecPowerState = "on"
+	MachineSpecPowerStateOff MachineSpecPowerState = "off"
 )
 
 type MachineSpecInterface struct {
@@ -64,6 +72,9 @@ type MachineSpec struct {
 
 	Disks      []*MachineSpecDisk      `json:"disks"`
 	Interfaces []*MachineSpecInterface `json:"interfaces"`
+
+	// The desired power state, the node starts or shuts down the machine when it changes.
+	PowerState MachineSpecPowerState `json:"power_state,omitempty"`
 }
 
 type MachineStatusVNC struct {
@@ -82,12 +93,41 @@ type MachineStatusInterface struct {
 	Addrs []MachineStatusIp `json:"addrs"`
 }
 
+type MachineStatusFailoverState string
+
+const (
+	// The machine can not be failed over, see the reason.
+	MachineStatusFailoverStateRefused MachineStatusFailoverState = "refused"
+	// The machine will be failed over once possible, see the reason.
+	MachineStatusFailoverStateWaiting = "waiting"
+	// The machine has been handed to the target node, which has yet to start it.
+	MachineStatusFailoverStatePending   = "pending"
+	MachineStatusFailoverStateCompleted = "completed"
+)
+
+// Written when the node running the machine has died.
+type MachineStatusFailover struct {
+	State  MachineStatusFailoverState `json:"state"`
+	Reason string                     `json:"reason,omitempty"`
+
+	SourceNode string `json:"source_node"`
+	TargetNode string `json:"target_node,omitempty"`
+
+	Time time.Time `json:"time"`
+}
+
 type MachineStatus struct {
 	State  MachineStatusState `json:"state"`
 	Errors []string           `json:"errors"`
 
+	Failover *MachineStatusFailover `json:"failover,omitempty"`
+
 	VNC        []MachineStatusVNC       `json:"vnc"`
 	Interfaces []MachineStatusInterface `json:"interfaces"`
 
 	ReachableIps []MachineStatusIp `json:"reachable_ips"`
+
+	Conditions Conditions `json:"conditions"`
+
+	ObservedGeneration int64 `json:"observed_generation"`
 }
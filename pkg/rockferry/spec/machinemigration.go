@@ -0,0 +1,40 @@
+package spec
+
+import "time"
+
+type MachineMigrationStatusState string
+
+const (
+	MachineMigrationStatusStatePending   MachineMigrationStatusState = "pending"
+	MachineMigrationStatusStateMigrating                             = "migrating"
+	MachineMigrationStatusStateCompleted                             = "completed"
+	MachineMigrationStatusStateFailed                                = "failed"
+)
+
+// Moves a running machine to another node. Every disk of the machine has to be
+// on shared storage, only the memory is copied.
+type MachineMigrationSpec struct {
+	Machine    string `json:"machine"`
+	TargetNode string `json:"target_node"`
+}
+
+type MachineMigrationStatus struct {
+	Error *string                     `json:"error"`
+	State MachineMigrationStatusState `json:"state"`
+
+	SourceNode string `json:"source_node"`
+
+	// Percentage of the memory copied to the target node so far.
+	Progress      uint64 `json:"progress"`
+	DataTotal     uint64 `json:"data_total"`
+	DataProcessed uint64 `json:"data_processed"`
+	DataRemaining uint64 `json:"data_remaining"`
+	TimeElapsedMs uint64 `json:"time_elapsed_ms"`
+
+	StartedAt   *time.Time `json:"started_at,omitempty"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+
+	Conditions Conditions `json:"conditions"`
+
+	ObservedGeneration int64 `json:"observed_generation"`
+}
@@ -1,5 +1,14 @@
 package spec
 
+// The steps of turning a request into a machine, Ready is set once the node
+// has created it.
+const (
+	MachineRequestConditionScheduled     ConditionType = "Scheduled"
+	MachineRequestConditionAllocated     ConditionType = "Allocated"
+	MachineRequestConditionDisksCreated  ConditionType = "DisksCreated"
+	MachineRequestConditionDomainDefined ConditionType = "DomainDefined"
+)
+
 type MachineRequestSpecDisk struct {
 	Pool       string `json:"pool"`
 	Capacity   uint64 `json:"capacity"`
@@ -19,3 +28,30 @@ type MachineRequestSpec struct {
 	Disks    []*MachineRequestSpecDisk `json:"disks"`
 	Cdrom    *MachineRequestSpecCdrom  `json:"cdrom"`
 }
+
+// Why a node was or was not picked for a machine request.
+type MachineRequestStatusSchedulingNode struct {
+	Node     string `json:"node"`
+	Feasible bool   `json:"feasible"`
+	Score    int64  `json:"score,omitempty"`
+	Reason   string `json:"reason,omitempty"`
+}
+
+type MachineRequestStatusScheduling struct {
+	// Empty if no node could fit the request.
+	Node     string `json:"node"`
+	Strategy string `json:"strategy"`
+
+	Nodes []*MachineRequestStatusSchedulingNode `json:"nodes"`
+}
+
+type MachineRequestStatus struct {
+	Error *string `json:"error"`
+
+	// Filled in by the scheduler for requests created without an owner.
+	Scheduling *MachineRequestStatusScheduling `json:"scheduling,omitempty"`
+
+	Conditions Conditions `json:"conditions"`
+
+	ObservedGeneration int64 `json:"observed_generation"`
+}
@@ -16,10 +16,29 @@ type NodeSpec struct {
 
 	Name   string `json:"name"`
 	Kernel string `json:"kernel"`
-	Uptime int64  `json:"uptime"`
 
 	Interfaces []*NodeInterfaceSpec `json:"interfaces"`
 
 	ActiveMachines uint64 `json:"active_machines"`
 	TotalMachines  uint64 `json:"total_machines"`
 }
+
+const (
+	// Whether the node agent is alive, judged by its lease.
+	NodeConditionReady = ConditionReady
+)
+
+type NodeStatus struct {
+	Error      *string    `json:"error"`
+	Conditions Conditions `json:"conditions"`
+
+	ObservedGeneration int64 `json:"observed_generation"`
+
+	// Seconds since the node booted, reported by the node agent.
+	Uptime int64 `json:"uptime"`
+}
+
+// A node is only ready once the controller has seen its lease.
+func (s *NodeStatus) Ready() bool {
+	return s.Conditions.IsTrue(NodeConditionReady)
+}
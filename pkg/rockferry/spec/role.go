@@ -0,0 +1,47 @@
+package spec
+
+type RoleScope string
+
+const (
+	// Every resource of the kinds.
+	RoleScopeAll RoleScope = "all"
+	// The node of a node agent and the resources owned by it, directly or
+	// through other resources such as storage pools.
+	RoleScopeNode RoleScope = "node"
+	// Resources created by the caller and those created for them, such as the
+	// machine of a machine request.
+	RoleScopeOwn RoleScope = "own"
+)
+
+// Grants verbs on kinds of resources. "*" matches every verb or kind.
+type RoleRule struct {
+	Verbs []string `json:"verbs"`
+	Kinds []string `json:"kinds"`
+	// Defaults to RoleScopeAll.
+	Scope RoleScope `json:"scope,omitempty"`
+}
+
+// What holders of the role may do, everything else is denied.
+type RoleSpec struct {
+	Rules []*RoleRule `json:"rules"`
+}
+
+type RoleSubjectKind string
+
+const (
+	RoleSubjectKindUser  RoleSubjectKind = "user"
+	RoleSubjectKindGroup RoleSubjectKind = "group"
+)
+
+type RoleSubject struct {
+	Kind RoleSubjectKind `json:"kind"`
+	// Users and groups from an openid provider carry its prefix, for example oidc:alice.
+	Name string `json:"name"`
+}
+
+// Grants a role to users and groups.
+type RoleBindingSpec struct {
+	// Id of a role, or the name of a builtin one such as rockferry:operator.
+	Role     string         `json:"role"`
+	Subjects []*RoleSubject `json:"subjects"`
+}
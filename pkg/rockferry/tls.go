@@ -0,0 +1,38 @@
+package rockferry
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+)
+
+// LoadTLS builds a config for WithTLS from pem files. The controller is verified
+// against ca, or the system roots if it is empty. The certificate and key are
+// presented to the controller, they are both left empty when there is none.
+func LoadTLS(ca string, cert string, key string) (*tls.Config, error) {
+	config := new(tls.Config)
+
+	if ca != "" {
+		contents, err := os.ReadFile(ca)
+		if err != nil {
+			return nil, err
+		}
+
+		config.RootCAs = x509.NewCertPool()
+		if !config.RootCAs.AppendCertsFromPEM(contents) {
+			return nil, fmt.Errorf("no certificates found in %s", ca)
+		}
+	}
+
+	if cert != "" || key != "" {
+		pair, err := tls.LoadX509KeyPair(cert, key)
+		if err != nil {
+			return nil, err
+		}
+
+		config.Certificates = []tls.Certificate{pair}
+	}
+
+	return config, nil
+}
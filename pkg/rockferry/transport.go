@@ -3,6 +3,8 @@ package rockferry
 import (
 	"context"
 	"fmt"
+	"maps"
+	"strings"
 	"time"
 
 	"github.com/eskpil/rockferry/controllerapi"
@@ -10,6 +12,7 @@ import (
 	"github.com/snorwin/jsonpatch"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/credentials/insecure"
 	"google.golang.org/grpc/status"
 )
@@ -18,24 +21,57 @@ type WatchEvent[T any, S any] struct {
 	Action   WatchAction     `json:"action"`
 	Resource *Resource[T, S] `json:"resource"`
 	Prev     *Resource[T, S] `json:"prev"`
+
+	// Which parts of the resource were changed. Always both for creations and deletions.
+	SpecChanged   bool `json:"spec_changed"`
+	StatusChanged bool `json:"status_changed"`
+
+	// The revision of the change, deletions included.
+	Revision int64 `json:"revision"`
+	// Bookmarks carry no resource, only the revision the watch has caught up to.
+	Bookmark bool `json:"bookmark,omitempty"`
 }
 
 type Transport struct {
 	client controllerapi.ControllerApiClient
+	admin  controllerapi.AdminApiClient
 }
 
-func NewTransport(url string) (*Transport, error) {
+func NewTransport(url string, opts ...ClientOption) (*Transport, error) {
 	t := new(Transport)
+	o := collectClientOptions(opts)
+
+	creds := insecure.NewCredentials()
+	if o.TLS != nil {
+		creds = credentials.NewTLS(o.TLS)
+	}
+
+	dial := []grpc.DialOption{grpc.WithTransportCredentials(creds)}
+	if o.Token != "" {
+		dial = append(dial, grpc.WithPerRPCCredentials(bearerToken(o.Token)))
+	}
 
-	cc, err := grpc.NewClient(url, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	cc, err := grpc.NewClient(url, dial...)
 	if err != nil {
 		return nil, err
 	}
 	t.client = controllerapi.NewControllerApiClient(cc)
+	t.admin = controllerapi.NewAdminApiClient(cc)
 
 	return t, nil
 }
 
+// Sends the token along with every call.
+type bearerToken string
+
+func (t bearerToken) GetRequestMetadata(ctx context.Context, uri ...string) (map[string]string, error) {
+	return map[string]string{"authorization": "Bearer " + string(t)}, nil
+}
+
+func (t bearerToken) RequireTransportSecurity() bool {
+	return true
+}
+
 func MapResource(unmapped *controllerapi.Resource) *Generic {
 	mapped := new(Generic)
 
@@ -50,7 +86,24 @@ func MapResource(unmapped *controllerapi.Resource) *Generic {
 	}
 	mapped.Phase = Phase(unmapped.Phase)
 
+	for _, parent := range unmapped.Parents {
+		p := new(OwnerRef)
+		p.Id = parent.Id
+		p.Kind = parent.Kind
+		mapped.Parents = append(mapped.Parents, p)
+	}
+
+	mapped.Finalizers = unmapped.Finalizers
+
+	if unmapped.DeletionTimestamp != nil {
+		if timestamp, err := time.Parse(time.RFC3339Nano, *unmapped.DeletionTimestamp); err == nil {
+			mapped.DeletionTimestamp = &timestamp
+		}
+	}
+
 	mapped.Annotations = unmapped.Annotations
+	mapped.ResourceVersion = unmapped.ResourceVersion
+	mapped.Generation = unmapped.Generation
 
 	mapped.RawStatus = unmapped.Status
 	mapped.RawSpec = unmapped.Spec
@@ -65,95 +118,127 @@ func (t *Transport) C() controllerapi.ControllerApiClient {
 	return t.client
 }
 
-func (t *Transport) Watch(ctx context.Context, action WatchAction, kind ResourceKind, id string, owner *OwnerRef) (chan *WatchEvent[any, any], error) {
-	api := t.C()
+func (t *Transport) A() controllerapi.AdminApiClient {
+	return t.admin
+}
 
-	// Create the initial watch request
-	req := new(controllerapi.WatchRequest)
-	req.Kind = kind
-	if owner != nil {
-		req.Owner.Id = owner.Id
-		req.Owner.Kind = owner.Kind
+// Watch keeps streaming events until ctx is done. When the stream breaks it is
+// resumed from the last revision seen. If that revision has been compacted the
+// resources are listed again and whatever was missed is sent as synthetic events.
+func (t *Transport) Watch(ctx context.Context, action WatchAction, kind ResourceKind, id string, owner *OwnerRef, opts ...ListOption) (chan *WatchEvent[any, any], error) {
+	w, err := t.watch(ctx, action, kind, id, owner, opts...)
+	if err != nil {
+		return nil, err
 	}
 
-	req.Action = controllerapi.WatchAction(action)
-	req.Action = action
+	return w.out, nil
+}
 
-	// Create the channel to send updates
-	out := make(chan *WatchEvent[any, any])
+// ListAndWatch is like Watch for every action, but also returns the resources the
+// events build upon. No change is missed between the list and the first event.
+func (t *Transport) ListAndWatch(ctx context.Context, kind ResourceKind, owner *OwnerRef, opts ...ListOption) ([]*Resource[any, any], chan *WatchEvent[any, any], error) {
+	w, err := t.watch(ctx, WatchActionAll, kind, "", owner, opts...)
+	if err != nil {
+		return nil, nil, err
+	}
 
-	// Function to start watching and handle reconnection
-	var watch func() error
-	watch = func() error {
-		// Call the API to start watching
-		response, err := api.Watch(ctx, req)
-		if err != nil {
-			return err
-		}
+	list := make([]*Resource[any, any], 0, len(w.initial))
+	for _, resource := range w.initial {
+		list = append(list, resource)
+	}
 
-		// Process incoming resources and handle reconnection
-		go func() {
-			for {
-				select {
-				default:
-					res, err := response.Recv()
-					if err != nil {
-						// Log the error (can be enhanced with more detailed logging)
-						fmt.Printf("Watch receive error: %v. Reconnecting...\n", err)
-
-						// Retry after a delay (backoff logic can be implemented here)
-						// You may add exponential backoff, but here we'll retry immediately for simplicity
-						retryDelay := 2 * time.Second // You can increase this over multiple retries
-						select {
-						case <-time.After(retryDelay): // Retry after waiting for the delay
-							// Attempt to restart the watch connection
-							if err := watch(); err != nil {
-								fmt.Printf("Failed to reconnect watch: %v\n", err)
-								return
-							}
-						case <-ctx.Done():
-							close(out)
-							return
-						}
-						return
-					}
-
-					event := new(WatchEvent[any, any])
-					event.Resource = MapResource(res.Resource)
-
-					if res.PrevResource != nil {
-						event.Prev = MapResource(res.PrevResource)
-					}
-
-					// Send the mapped resource to the channel
-					select {
-					case out <- event:
-					case <-ctx.Done():
-						close(out)
-						return
-					}
-				}
-			}
-		}()
-		return nil
+	return list, w.out, nil
+}
+
+func (t *Transport) watch(ctx context.Context, action WatchAction, kind ResourceKind, id string, owner *OwnerRef, opts ...ListOption) (*watcher, error) {
+	o := collectListOptions(opts)
+
+	// NOTE: The stream would otherwise keep reconnecting with a selector the controller rejects.
+	if _, err := ParseSelector(o.LabelSelector); err != nil {
+		return nil, err
+	}
+
+	if _, err := ParseFieldSelector(o.FieldSelector); err != nil {
+		return nil, err
 	}
 
-	// Start the watch and handle the error if it fails
-	if err := watch(); err != nil {
+	w := new(watcher)
+	w.t = t
+	w.action = action
+	w.kind = kind
+	w.id = id
+	w.owner = owner
+	w.opts = opts
+	w.selectors = o
+	w.known = map[string]*Resource[any, any]{}
+	w.out = make(chan *WatchEvent[any, any])
+
+	// NOTE: Knowing what exists up front allows detecting deletions missed while disconnected.
+	if err := w.relist(ctx, false); err != nil {
 		return nil, err
 	}
 
-	return out, nil
+	w.initial = maps.Clone(w.known)
+
+	stream, err := w.open(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	go w.run(ctx, stream)
+
+	return w, nil
 }
 
+// The parts of a resource which can only be changed through PatchStatus.
+type statusSubresource struct {
+	Status any   `json:"status"`
+	Phase  Phase `json:"phase"`
+}
+
+type patchCall func(context.Context, *controllerapi.PatchRequest, ...grpc.CallOption) (*controllerapi.PatchResponse, error)
+
 func (t *Transport) Patch(ctx context.Context, original *Resource[any, any], modified *Resource[any, any]) error {
-	api := t.C()
+	patch, err := jsonpatch.CreateJSONPatch(modified, original, jsonpatch.WithPredicate(specPredicate))
+	if err != nil {
+		return err
+	}
 
-	patch, err := jsonpatch.CreateJSONPatch(modified, original)
+	return t.sendPatch(ctx, t.C().Patch, original, modified, patch)
+}
+
+// PatchStatus only sends the changes made to the status and phase of modified.
+func (t *Transport) PatchStatus(ctx context.Context, original *Resource[any, any], modified *Resource[any, any]) error {
+	patch, err := jsonpatch.CreateJSONPatch(
+		&statusSubresource{Status: modified.Status, Phase: modified.Phase},
+		&statusSubresource{Status: original.Status, Phase: original.Phase},
+	)
 	if err != nil {
 		return err
 	}
 
+	return t.sendPatch(ctx, t.C().PatchStatus, original, modified, patch)
+}
+
+// Skips the status and phase, they belong to the status subresource.
+var specPredicate = jsonpatch.Funcs{
+	AddFunc: func(path jsonpatch.JSONPointer, _ interface{}) bool {
+		return !isStatusPointer(path)
+	},
+	RemoveFunc: func(path jsonpatch.JSONPointer, _ interface{}) bool {
+		return !isStatusPointer(path)
+	},
+	ReplaceFunc: func(path jsonpatch.JSONPointer, _ interface{}, _ interface{}) bool {
+		return !isStatusPointer(path)
+	},
+}
+
+func isStatusPointer(path jsonpatch.JSONPointer) bool {
+	p := path.String()
+	return p == "/status" || strings.HasPrefix(p, "/status/") || p == "/phase"
+}
+
+func (t *Transport) sendPatch(ctx context.Context, call patchCall, original *Resource[any, any], modified *Resource[any, any], patch jsonpatch.JSONPatchList) error {
 	// NOTE: If the resource is not update it, why bother the controller
 	if 0 >= len(patch.Raw()) {
 		return nil
@@ -172,16 +257,41 @@ func (t *Transport) Patch(ctx context.Context, original *Resource[any, any], mod
 
 	req.Patches = patch.Raw()
 
-	_, err = api.Patch(ctx, req)
+	// NOTE: Only apply the patch to the version the caller based its changes on.
+	if original.ResourceVersion != 0 {
+		req.ResourceVersion = new(int64)
+		*req.ResourceVersion = original.ResourceVersion
+	}
+
+	response, err := call(ctx, req)
 	if err != nil {
+		if s, ok := status.FromError(err); ok && s != nil {
+			switch s.Code() {
+			case codes.NotFound:
+				return ErrorNotFound
+			case codes.Aborted:
+				return ErrorConflict
+			}
+		}
+
 		return err
 	}
 
+	modified.ResourceVersion = response.ResourceVersion
+	modified.Generation = response.Generation
+
 	return nil
 }
 
-func (t *Transport) List(ctx context.Context, kind ResourceKind, id string, owner *OwnerRef) ([]*Resource[any, any], error) {
+func (t *Transport) List(ctx context.Context, kind ResourceKind, id string, owner *OwnerRef, opts ...ListOption) ([]*Resource[any, any], error) {
+	list, _, err := t.list(ctx, kind, id, owner, opts...)
+	return list, err
+}
+
+// Also returns the revision the list was read at.
+func (t *Transport) list(ctx context.Context, kind ResourceKind, id string, owner *OwnerRef, opts ...ListOption) ([]*Resource[any, any], int64, error) {
 	api := t.C()
+	o := collectListOptions(opts)
 
 	req := new(controllerapi.ListRequest)
 	if id != "" {
@@ -194,16 +304,26 @@ func (t *Transport) List(ctx context.Context, kind ResourceKind, id string, owne
 		req.Owner.Id = owner.Id
 		req.Owner.Kind = owner.Kind
 	}
+	if o.LabelSelector != "" {
+		req.LabelSelector = &o.LabelSelector
+	}
+	if o.FieldSelector != "" {
+		req.FieldSelector = &o.FieldSelector
+	}
 
 	response, err := api.List(ctx, req)
 	if err != nil {
 		if s, ok := status.FromError(err); ok && s != nil {
-			if s.Code() == codes.NotFound {
-				return nil, ErrorNotFound
+			switch s.Code() {
+			case codes.NotFound:
+				return nil, 0, ErrorNotFound
+			case codes.InvalidArgument:
+				// NOTE: The controller already prefixes the message with ErrorInvalidSelector.
+				return nil, 0, fmt.Errorf("%w%s", ErrorInvalidSelector, strings.TrimPrefix(s.Message(), string(ErrorInvalidSelector)))
 			}
 		}
 
-		return nil, err
+		return nil, 0, err
 	}
 
 	list := make([]*Resource[any, any], len(response.Resources))
@@ -212,7 +332,7 @@ func (t *Transport) List(ctx context.Context, kind ResourceKind, id string, owne
 		list[i] = MapResource(unmapped)
 	}
 
-	return list, nil
+	return list, response.Revision, nil
 }
 
 func (t *Transport) Create(ctx context.Context, in *Resource[any, any]) error {
@@ -226,8 +346,20 @@ func (t *Transport) Create(ctx context.Context, in *Resource[any, any]) error {
 		return err
 	}
 
-	_, err = api.Create(ctx, req)
-	return err
+	response, err := api.Create(ctx, req)
+	if status.Code(err) == codes.AlreadyExists {
+		return ErrorAlreadyExists
+	}
+
+	if err != nil {
+		return err
+	}
+
+	in.Id = response.Id
+	in.ResourceVersion = response.ResourceVersion
+	in.Generation = 1
+
+	return nil
 }
 
 func (t *Transport) Delete(ctx context.Context, kind ResourceKind, id string) error {
@@ -238,5 +370,9 @@ func (t *Transport) Delete(ctx context.Context, kind ResourceKind, id string) er
 	req.Id = id
 
 	_, err := api.Delete(ctx, req)
+	if status.Code(err) == codes.NotFound {
+		return ErrorNotFound
+	}
+
 	return err
 }
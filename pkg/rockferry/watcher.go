@@ -0,0 +1,219 @@
+package rockferry
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/eskpil/rockferry/controllerapi"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const watchRetryDelay = 2 * time.Second
+
+// Keeps a watch going across reconnects. Every resource seen is remembered, so a
+// relist can be turned into the events which were missed.
+type watcher struct {
+	t *Transport
+
+	action    WatchAction
+	kind      ResourceKind
+	id        string
+	owner     *OwnerRef
+	opts      []ListOption
+	selectors *ListOptions
+
+	// The last revision the watch has caught up to.
+	revision int64
+	known    map[string]*Resource[any, any]
+	// What was known when the watch was started.
+	initial map[string]*Resource[any, any]
+
+	out chan *WatchEvent[any, any]
+}
+
+func (w *watcher) open(ctx context.Context) (grpc.ServerStreamingClient[controllerapi.WatchResponse], error) {
+	req := new(controllerapi.WatchRequest)
+	req.Kind = w.kind
+	if w.id != "" {
+		req.Id = new(string)
+		*req.Id = w.id
+	}
+	if w.owner != nil {
+		req.Owner = new(controllerapi.Owner)
+		req.Owner.Id = w.owner.Id
+		req.Owner.Kind = w.owner.Kind
+	}
+	if w.selectors.LabelSelector != "" {
+		req.LabelSelector = &w.selectors.LabelSelector
+	}
+	if w.selectors.FieldSelector != "" {
+		req.FieldSelector = &w.selectors.FieldSelector
+	}
+	if w.revision != 0 {
+		req.Revision = new(int64)
+		*req.Revision = w.revision
+	}
+
+	// NOTE: Actions are filtered here, every change is needed to keep track of what exists.
+	req.Action = WatchActionAll
+	req.Bookmarks = true
+
+	return w.t.C().Watch(ctx, req)
+}
+
+func (w *watcher) run(ctx context.Context, stream grpc.ServerStreamingClient[controllerapi.WatchResponse]) {
+	defer close(w.out)
+
+	for {
+		err := w.receive(ctx, stream)
+		if ctx.Err() != nil {
+			return
+		}
+
+		fmt.Printf("Watch receive error: %v. Reconnecting...\n", err)
+
+		compacted := status.Code(err) == codes.OutOfRange
+
+		for {
+			select {
+			case <-time.After(watchRetryDelay):
+			case <-ctx.Done():
+				return
+			}
+
+			if compacted {
+				if err := w.relist(ctx, true); err != nil {
+					fmt.Printf("Failed to relist after compaction: %v\n", err)
+					continue
+				}
+
+				compacted = false
+			}
+
+			stream, err = w.open(ctx)
+			if err == nil {
+				break
+			}
+
+			fmt.Printf("Failed to reconnect watch: %v\n", err)
+		}
+	}
+}
+
+func (w *watcher) receive(ctx context.Context, stream grpc.ServerStreamingClient[controllerapi.WatchResponse]) error {
+	for {
+		res, err := stream.Recv()
+		if err != nil {
+			return err
+		}
+
+		if res.Revision > w.revision {
+			w.revision = res.Revision
+		}
+
+		if res.Bookmark {
+			continue
+		}
+
+		event := new(WatchEvent[any, any])
+		event.Action = res.Action
+		event.Resource = MapResource(res.Resource)
+
+		if res.PrevResource != nil {
+			event.Prev = MapResource(res.PrevResource)
+		}
+
+		event.SpecChanged = res.SpecChanged
+		event.StatusChanged = res.StatusChanged
+		event.Revision = res.Revision
+
+		if !w.emit(ctx, event) {
+			return ctx.Err()
+		}
+	}
+}
+
+// Lists the resources again and, if synthesize is set, sends an event for every
+// difference to what was known before.
+func (w *watcher) relist(ctx context.Context, synthesize bool) error {
+	resources, revision, err := w.t.list(ctx, w.kind, w.id, w.owner, w.opts...)
+	if err != nil && err != ErrorNotFound {
+		return err
+	}
+
+	listed := map[string]*Resource[any, any]{}
+
+	for _, resource := range resources {
+		listed[resource.Id] = resource
+
+		prev, ok := w.known[resource.Id]
+		if !synthesize {
+			w.known[resource.Id] = resource
+			continue
+		}
+
+		if !ok {
+			if !w.emit(ctx, w.synthetic(WatchActionCreate, resource, nil, revision)) {
+				return ctx.Err()
+			}
+		} else if prev.ResourceVersion != resource.ResourceVersion {
+			if !w.emit(ctx, w.synthetic(WatchActionUpdate, resource, prev, revision)) {
+				return ctx.Err()
+			}
+		}
+	}
+
+	for id, prev := range w.known {
+		if _, ok := listed[id]; ok {
+			continue
+		}
+
+		if !synthesize {
+			delete(w.known, id)
+			continue
+		}
+
+		if !w.emit(ctx, w.synthetic(WatchActionDelete, prev, nil, revision)) {
+			return ctx.Err()
+		}
+	}
+
+	w.revision = revision
+
+	return nil
+}
+
+func (w *watcher) synthetic(action WatchAction, resource *Resource[any, any], prev *Resource[any, any], revision int64) *WatchEvent[any, any] {
+	event := new(WatchEvent[any, any])
+	event.Action = action
+	event.Resource = resource
+	event.Prev = prev
+	event.SpecChanged = true
+	event.StatusChanged = true
+	event.Revision = revision
+	return event
+}
+
+// Records the change and passes it on if the caller is interested in the action.
+// Returns false once ctx is done.
+func (w *watcher) emit(ctx context.Context, event *WatchEvent[any, any]) bool {
+	if event.Action == WatchActionDelete {
+		delete(w.known, event.Resource.Id)
+	} else {
+		w.known[event.Resource.Id] = event.Resource
+	}
+
+	if w.action != WatchActionAll && event.Action != w.action {
+		return true
+	}
+
+	select {
+	case w.out <- event:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
@@ -33,6 +33,8 @@ export interface Machine {
 
     disks: MachineDisk[];
     interfaces: MachineInterface[];
+
+    power_state?: "on" | "off";
 }
 
 export interface MachineStatusVNC {
@@ -1,3 +1,4 @@
+import { Status } from "./resource";
 import { Topology } from "./topology";
 
 export interface NodeInterface {
@@ -15,6 +16,9 @@ export interface Node {
     active_machines: number;
     total_machines: number;
     topology: Topology;
-    uptime: number;
     interfaces: NodeInterface[];
 }
+
+export interface NodeStatus extends Status {
+    uptime: number;
+}
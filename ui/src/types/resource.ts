@@ -39,6 +39,7 @@ export interface Resource<T, S = Status> {
     owner: OwnerRef | undefined;
     spec: T | undefined;
     status: S;
+    resource_version?: number;
 }
 
 export interface Status {
@@ -64,6 +65,7 @@ export interface PatchResourceInput {
     kind: ResourceKind;
     id: string;
     patches: jsonpatch.Operation[];
+    resource_version?: number;
 }
 
 export interface WatchResponse<T, S = Status> {
@@ -15,7 +15,7 @@ import { useParams } from "react-router";
 import { VmsView } from "./vms";
 import { get } from "../../data/queries/get";
 import { useQuery } from "@tanstack/react-query";
-import { Node } from "../../types/node";
+import { Node, NodeStatus } from "../../types/node";
 import { Resource, ResourceKind } from "../../types/resource";
 import { CopyIcon } from "@radix-ui/react-icons";
 import { convert, Units } from "../../utils/conversion";
@@ -26,9 +26,9 @@ import { Machine, MachineStatus } from "../../types/machine";
 import { list } from "../../data/queries/list";
 import { Breadcrumbs } from "../../components/breadcrumbs";
 
-const NodeMetadata: React.FC<{ node: Resource<Node> }> = ({ node }) => {
+const NodeMetadata: React.FC<{ node: Resource<Node, NodeStatus> }> = ({ node }) => {
     const now = new Date();
-    const lastReboot = new Date(now.getTime() - node.spec!.uptime * 1000);
+    const lastReboot = new Date(now.getTime() - node.status.uptime * 1000);
 
     return (
         <Card>
@@ -109,7 +109,7 @@ const NodeMetadata: React.FC<{ node: Resource<Node> }> = ({ node }) => {
                     <DataList.Label minWidth="88px">Uptime</DataList.Label>
                     <DataList.Value>
                         <Text color="purple">
-                            {getUptime(node.spec!.uptime!)}
+                            {getUptime(node.status.uptime)}
                         </Text>
                     </DataList.Value>
                 </DataList.Item>
@@ -122,7 +122,7 @@ export const NodeView: React.FC<unknown> = () => {
     const { id } = useParams<{ id: string }>();
     const data = useQuery({
         queryKey: [ResourceKind.Node, id],
-        queryFn: () => get<Node>(id!, ResourceKind.Node),
+        queryFn: () => get<Node, NodeStatus>(id!, ResourceKind.Node),
     });
 
     const [tab, setTab] = useTabState("overview");
@@ -180,7 +180,7 @@ export const NodeView: React.FC<unknown> = () => {
     );
 };
 
-const MainArea: React.FC<{ node: Resource<Node> }> = ({ node }) => {
+const MainArea: React.FC<{ node: Resource<Node, NodeStatus> }> = ({ node }) => {
     const {
         data: vms,
         isError,
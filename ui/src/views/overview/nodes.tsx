@@ -3,7 +3,7 @@ import { useNavigate } from "react-router";
 import { list } from "../../data/queries/list";
 import { ResourceKind } from "../../types/resource";
 import { Table, Badge, Card } from "@radix-ui/themes";
-import { Node } from "../../types/node";
+import { Node, NodeStatus } from "../../types/node";
 import { getUptime } from "../../utils/uptime";
 
 export const NodesTab: React.FC<unknown> = () => {
@@ -11,7 +11,7 @@ export const NodesTab: React.FC<unknown> = () => {
     const nodes = useQuery({
         queryKey: [ResourceKind.Instance, "self", ResourceKind.Node],
         queryFn: () =>
-            list<Node>(ResourceKind.Node, "self", ResourceKind.Instance),
+            list<Node, NodeStatus>(ResourceKind.Node, "self", ResourceKind.Instance),
     });
 
     if (nodes.error) {
@@ -36,7 +36,7 @@ export const NodesTab: React.FC<unknown> = () => {
                     {nodes.data?.list?.map((node) => {
                         const color = "green";
 
-                        const uptime = getUptime(node.spec!.uptime!);
+                        const uptime = getUptime(node.status.uptime);
 
                         return (
                             <Table.Row
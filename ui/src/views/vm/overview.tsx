@@ -44,7 +44,7 @@ const startVm = (
     mutate: UseMutateFunction<Response, Error, PatchResourceInput, unknown>,
 ) => {
     const observer = jsonpatch.observe<Resource<Machine, MachineStatus>>(vm);
-    vm.status.state = "booting";
+    vm.spec!.power_state = "on";
     const patches = jsonpatch.generate(observer);
     mutate({ id: vm.id, kind: vm.kind, patches });
 };
@@ -54,7 +54,7 @@ const shutdownVm = (
     mutate: UseMutateFunction<Response, Error, PatchResourceInput, unknown>,
 ) => {
     const observer = jsonpatch.observe<Resource<Machine, MachineStatus>>(vm);
-    vm.status.state = "shutdown";
+    vm.spec!.power_state = "off";
     const patches = jsonpatch.generate(observer);
     mutate({ id: vm.id, kind: vm.kind, patches });
 };